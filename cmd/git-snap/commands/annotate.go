@@ -0,0 +1,244 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fraser-isbester/git-snap/pkg/git"
+	"github.com/fraser-isbester/git-snap/pkg/notes"
+	"github.com/fraser-isbester/git-snap/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+func NewAnnotateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "annotate",
+		Short: "Record correlation results as git notes",
+		Long: `Write each commit's correlations as structured JSON into the
+refs/notes/git-snap namespace. Existing notes are merged by event ID, so
+annotating the same results twice leaves the notes unchanged.
+
+Use 'git log --notes=git-snap' to view the attribution alongside commits.
+
+With --trailers, attribution is recorded in the commits themselves instead:
+unpushed commits on the current branch are rewritten to append Snap-Event and
+Snap-Score trailers. Commits reachable from a remote branch are never touched.
+A repository without remote branches needs --base, which limits the rewrite to
+the commits after it, or --amend, which only rewrites HEAD.`,
+		RunE: runAnnotate,
+	}
+
+	cmd.Flags().StringP("results", "R", "", "Path to stored correlation results (JSON output of correlate)")
+	cmd.Flags().StringP("config", "c", "", "Configuration name recorded with each correlation")
+	cmd.Flags().StringP("repo", "r", ".", "Path to git repository")
+	cmd.Flags().String("notes-ref", git.DefaultNotesRef, "Notes ref to write to")
+	cmd.Flags().Float64P("threshold", "t", 0, "Minimum correlation score threshold")
+	cmd.Flags().Bool("trailers", false, "Rewrite unpushed commits to append Snap-Event/Snap-Score trailers instead of writing notes")
+	cmd.Flags().Bool("amend", false, "With --trailers, only amend HEAD")
+	cmd.Flags().String("base", "", "With --trailers, only rewrite commits after this revision; required without remote branches")
+	cmd.Flags().Bool("dry-run", false, "With --trailers, print the trailers that would be added without rewriting")
+	cmd.Flags().Bool("drop-signatures", false, "With --trailers, rewrite signed commits even though their signatures are lost")
+
+	cmd.MarkFlagRequired("results")
+
+	cmd.AddCommand(newAnnotateShowCommand())
+
+	return cmd
+}
+
+func newAnnotateShowCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show [commit...]",
+		Short: "Show correlations stored in git notes",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoPath, _ := cmd.Flags().GetString("repo")
+			notesRef, _ := cmd.Flags().GetString("notes-ref")
+
+			repoPath, err := git.FindGitRepository(repoPath)
+			if err != nil {
+				return fmt.Errorf("failed to find git repository: %w", err)
+			}
+
+			gitClient := git.NewGitClient(repoPath)
+			store := notes.NewStore(gitClient, notesRef)
+
+			stored := make(map[string]notes.CommitNote)
+			if len(args) == 0 {
+				stored, err = store.ReadAll()
+				if err != nil {
+					return fmt.Errorf("failed to read notes: %w", err)
+				}
+			}
+			for _, rev := range args {
+				sha, err := gitClient.ResolveCommit(rev)
+				if err != nil {
+					return err
+				}
+				note, err := store.Read(sha)
+				if err != nil {
+					return fmt.Errorf("failed to read note: %w", err)
+				}
+				if note != nil {
+					stored[sha] = *note
+				}
+			}
+
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(stored)
+		},
+	}
+
+	cmd.Flags().StringP("repo", "r", ".", "Path to git repository")
+	cmd.Flags().String("notes-ref", git.DefaultNotesRef, "Notes ref to read from")
+
+	return cmd
+}
+
+func runAnnotate(cmd *cobra.Command, args []string) error {
+	resultsFile, _ := cmd.Flags().GetString("results")
+	configName, _ := cmd.Flags().GetString("config")
+	repoPath, _ := cmd.Flags().GetString("repo")
+	notesRef, _ := cmd.Flags().GetString("notes-ref")
+	threshold, _ := cmd.Flags().GetFloat64("threshold")
+	useTrailers, _ := cmd.Flags().GetBool("trailers")
+	amend, _ := cmd.Flags().GetBool("amend")
+	base, _ := cmd.Flags().GetString("base")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	dropSignatures, _ := cmd.Flags().GetBool("drop-signatures")
+
+	if amend && base != "" {
+		return fmt.Errorf("--amend and --base cannot be combined")
+	}
+
+	results, err := loadResults(resultsFile)
+	if err != nil {
+		return err
+	}
+
+	filteredResults := make([]types.CorrelationResult, 0, len(results))
+	for _, result := range results {
+		if result.Score >= threshold {
+			filteredResults = append(filteredResults, result)
+		}
+	}
+
+	repoPath, err = git.FindGitRepository(repoPath)
+	if err != nil {
+		return fmt.Errorf("failed to find git repository: %w", err)
+	}
+
+	gitClient := git.NewGitClient(repoPath)
+
+	if useTrailers {
+		if amend {
+			return amendTrailers(gitClient, filteredResults, dryRun, dropSignatures)
+		}
+		return annotateTrailers(gitClient, filteredResults, base, dryRun, dropSignatures)
+	}
+
+	commitNotes := notes.FromResults(filteredResults, configName)
+	written, err := notes.NewStore(gitClient, notesRef).Write(commitNotes)
+	if err != nil {
+		return fmt.Errorf("failed to write notes: %w", err)
+	}
+
+	fmt.Printf("Annotated %d of %d commits in %s\n", written, len(commitNotes), notesRef)
+	return nil
+}
+
+func annotateTrailers(gitClient *git.GitClient, results []types.CorrelationResult, base string, dryRun, dropSignatures bool) error {
+	trailers := git.TrailersForResults(results)
+
+	unpushed, err := gitClient.UnpushedCommits(base)
+	if err != nil {
+		if base == "" {
+			return fmt.Errorf("%w; pass --base, or --amend to only rewrite HEAD", err)
+		}
+		return err
+	}
+
+	targets := make(map[string][]git.Trailer)
+	for _, sha := range unpushed {
+		if commitTrailers, exists := trailers[sha]; exists {
+			targets[sha] = commitTrailers
+		}
+	}
+
+	if skipped := len(trailers) - len(targets); skipped > 0 {
+		fmt.Printf("Skipping %d correlated commits that are pushed or not on the current branch\n", skipped)
+	}
+
+	if len(targets) == 0 {
+		fmt.Println("No unpushed commits to annotate")
+		return nil
+	}
+
+	if dryRun {
+		for _, sha := range unpushed {
+			for _, trailer := range targets[sha] {
+				fmt.Printf("%s  %s: %s\n", sha[:8], trailer.Key, trailer.Value)
+			}
+		}
+		return nil
+	}
+
+	rewritten, err := gitClient.RewriteWithTrailers(targets, base, dropSignatures)
+	if err != nil {
+		return err
+	}
+
+	for _, sha := range unpushed {
+		if newSHA, exists := rewritten[sha]; exists {
+			fmt.Printf("%s -> %s\n", sha[:8], newSHA[:8])
+		}
+	}
+	fmt.Printf("Added trailers to %d commits\n", len(rewritten))
+
+	return nil
+}
+
+// amendTrailers adds HEAD's trailers to HEAD alone, which needs no remote
+// branches to tell which commits are unpushed.
+func amendTrailers(gitClient *git.GitClient, results []types.CorrelationResult, dryRun, dropSignatures bool) error {
+	head, err := gitClient.ResolveCommit("HEAD")
+	if err != nil {
+		return err
+	}
+
+	trailers := git.TrailersForResults(results)[head]
+	if len(trailers) == 0 {
+		fmt.Println("No correlations for HEAD")
+		return nil
+	}
+
+	if dryRun {
+		for _, trailer := range trailers {
+			fmt.Printf("%s  %s: %s\n", head[:8], trailer.Key, trailer.Value)
+		}
+		return nil
+	}
+
+	newHead, err := gitClient.AmendWithTrailers(trailers, dropSignatures)
+	if err != nil {
+		return err
+	}
+	if newHead == "" {
+		fmt.Println("HEAD already has its trailers")
+		return nil
+	}
+
+	fmt.Printf("%s -> %s\n", head[:8], newHead[:8])
+	fmt.Println("Added trailers to 1 commit")
+	return nil
+}
+
+func loadNoteResults(gitClient *git.GitClient, notesRef string) ([]types.CorrelationResult, error) {
+	stored, err := notes.NewStore(gitClient, notesRef).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notes: %w", err)
+	}
+
+	return notes.ToResults(stored), nil
+}
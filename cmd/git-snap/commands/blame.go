@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fraser-isbester/git-snap/pkg/attribution"
+	"github.com/fraser-isbester/git-snap/pkg/git"
+	"github.com/fraser-isbester/git-snap/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+func NewBlameCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "blame [paths...]",
+		Short: "Report what share of current source lines came from correlated commits",
+		Long: `Run git blame over a set of paths, map every surviving line to the commit
+that introduced it, and join those commits with stored correlation results
+(a results file, or the git-snap notes written by --write-notes) to report per-file and per-directory percentages of lines attributable to
+each event type.`,
+		RunE: runBlame,
+	}
+
+	cmd.Flags().StringP("results", "R", "", "Path to stored correlation results (JSON output of correlate)")
+	cmd.Flags().String("notes-ref", git.DefaultNotesRef, "Notes ref to read correlations from when --results is not set")
+	cmd.Flags().StringP("repo", "r", ".", "Path to git repository")
+	cmd.Flags().String("type-key", "request_type", "Event attribute used as the event type")
+	cmd.Flags().Float64P("threshold", "t", 0, "Minimum correlation score for a result to count")
+	cmd.Flags().StringP("output", "o", "table", "Output format (json, table)")
+	cmd.Flags().Bool("files", true, "Include per-file rows in table output")
+	cmd.Flags().BoolP("verbose", "v", false, "Verbose output")
+
+	return cmd
+}
+
+func runBlame(cmd *cobra.Command, args []string) error {
+	resultsFile, _ := cmd.Flags().GetString("results")
+	notesRef, _ := cmd.Flags().GetString("notes-ref")
+	repoPath, _ := cmd.Flags().GetString("repo")
+	typeKey, _ := cmd.Flags().GetString("type-key")
+	threshold, _ := cmd.Flags().GetFloat64("threshold")
+	outputFormat, _ := cmd.Flags().GetString("output")
+	showFiles, _ := cmd.Flags().GetBool("files")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+
+	repoPath, err := git.FindGitRepository(repoPath)
+	if err != nil {
+		return fmt.Errorf("failed to find git repository: %w", err)
+	}
+
+	gitClient := git.NewGitClient(repoPath)
+
+	var results []types.CorrelationResult
+	if resultsFile != "" {
+		results, err = loadResults(resultsFile)
+	} else {
+		results, err = loadNoteResults(gitClient, notesRef)
+	}
+	if err != nil {
+		return err
+	}
+
+	filteredResults := make([]types.CorrelationResult, 0, len(results))
+	for _, result := range results {
+		if result.Score >= threshold {
+			filteredResults = append(filteredResults, result)
+		}
+	}
+
+	paths := args
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	files, err := gitClient.ListFiles(paths)
+	if err != nil {
+		return err
+	}
+
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Blaming %d files against %d correlation results\n", len(files), len(filteredResults))
+	}
+
+	blame := make(map[string]map[string]int, len(files))
+	for _, file := range files {
+		counts, err := gitClient.BlameLineCounts(file)
+		if err != nil {
+			if verbose {
+				fmt.Fprintf(os.Stderr, "Skipping %s: %v\n", file, err)
+			}
+			continue
+		}
+		blame[file] = counts
+	}
+
+	report := attribution.BuildReport(blame, attribution.CommitEventTypes(filteredResults, typeKey))
+
+	switch outputFormat {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case "table":
+		return outputBlameTable(report, showFiles)
+	default:
+		return fmt.Errorf("unsupported output format: %s", outputFormat)
+	}
+}
+
+func outputBlameTable(report *attribution.Report, showFiles bool) error {
+	header := fmt.Sprintf("%-50s %8s %8s", "Path", "Lines", "Any")
+	for _, eventType := range report.EventTypes {
+		header += fmt.Sprintf(" %15s", truncate(eventType, 15))
+	}
+	fmt.Println(header)
+	fmt.Println(strings.Repeat("-", len(header)))
+
+	printRow := func(stats attribution.LineStats) {
+		row := fmt.Sprintf("%-50s %8d %7.1f%%", truncate(stats.Path, 50), stats.TotalLines, stats.AttributedPercent())
+		for _, eventType := range report.EventTypes {
+			row += fmt.Sprintf(" %14.1f%%", stats.Percent(eventType))
+		}
+		fmt.Println(row)
+	}
+
+	printRow(report.Total)
+	for _, stats := range report.Directories {
+		printRow(withTrailingSlash(stats))
+	}
+	if showFiles {
+		for _, stats := range report.Files {
+			printRow(stats)
+		}
+	}
+
+	return nil
+}
+
+func withTrailingSlash(stats attribution.LineStats) attribution.LineStats {
+	stats.Path += "/"
+	return stats
+}
+
+func truncate(s string, width int) string {
+	if len(s) <= width {
+		return s
+	}
+	if width <= 3 {
+		return s[:width]
+	}
+	return "..." + s[len(s)-width+3:]
+}
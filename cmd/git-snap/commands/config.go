@@ -2,6 +2,7 @@ package commands
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/fraser-isbester/git-snap/pkg/config"
 	"github.com/spf13/cobra"
@@ -107,6 +108,64 @@ func newConfigShowCommand() *cobra.Command {
 						}())
 				}
 			}
+			if mapping := snapConfig.FieldMapping; mapping != nil {
+				fmt.Printf("Field Mapping:\n")
+				fmt.Printf("  id: %s\n", mapping.ID)
+				fmt.Printf("  timestamp: %s", mapping.Timestamp.Field)
+				if mapping.Timestamp.Format != "" {
+					fmt.Printf(" (format %q)", mapping.Timestamp.Format)
+				}
+				for _, format := range mapping.Timestamp.Formats {
+					fmt.Printf(" (format %q)", format)
+				}
+				if mapping.Timestamp.Timezone != "" {
+					fmt.Printf(" (timezone %s)", mapping.Timestamp.Timezone)
+				}
+				fmt.Println()
+				if len(mapping.Attributes) > 0 {
+					fmt.Printf("  attributes: %s\n", strings.Join(mapping.Attributes, ", "))
+				}
+				if len(mapping.Metadata) > 0 {
+					fmt.Printf("  metadata: %s\n", strings.Join(mapping.Metadata, ", "))
+				}
+				for from, to := range mapping.Rename {
+					fmt.Printf("  rename: %s -> %s\n", from, to)
+				}
+				for key, value := range mapping.Constants {
+					fmt.Printf("  constant: %s = %v\n", key, value)
+				}
+				if mapping.GenerateIDs {
+					fmt.Printf("  generate_ids: true\n")
+				}
+				if mapping.Pattern != "" {
+					fmt.Printf("  pattern: %s\n", mapping.Pattern)
+				}
+			}
+			if schema := snapConfig.Schema; schema != nil {
+				fmt.Printf("Schema:\n")
+				if schema.RequireID {
+					fmt.Printf("  require_id: true\n")
+				}
+				if schema.Strict {
+					fmt.Printf("  strict: true\n")
+				}
+				for name, field := range schema.Attributes {
+					fmt.Printf("  attributes.%s: %s%s\n", name, field.Type, func() string {
+						if field.Required {
+							return " [required]"
+						}
+						return ""
+					}())
+				}
+				for name, field := range schema.Metadata {
+					fmt.Printf("  metadata.%s: %s%s\n", name, field.Type, func() string {
+						if field.Required {
+							return " [required]"
+						}
+						return ""
+					}())
+				}
+			}
 
 			return nil
 		},
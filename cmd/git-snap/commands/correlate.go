@@ -1,17 +1,30 @@
 package commands
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/fraser-isbester/git-snap/pkg/config"
 	"github.com/fraser-isbester/git-snap/pkg/correlation"
 	"github.com/fraser-isbester/git-snap/pkg/git"
+	"github.com/fraser-isbester/git-snap/pkg/metrics"
+	"github.com/fraser-isbester/git-snap/pkg/notes"
+	"github.com/fraser-isbester/git-snap/pkg/output"
 	"github.com/fraser-isbester/git-snap/pkg/parser"
+	"github.com/fraser-isbester/git-snap/pkg/stats"
+	"github.com/fraser-isbester/git-snap/pkg/store"
 	"github.com/fraser-isbester/git-snap/pkg/types"
 	"github.com/spf13/cobra"
 )
@@ -25,13 +38,31 @@ attribute matching and temporal proximity.`,
 		RunE: runCorrelate,
 	}
 
-	cmd.Flags().StringP("events", "e", "", "Path to events file (JSON, JSONL, or CSV)")
+	cmd.Flags().StringArrayP("events", "e", nil, "Events file, glob or directory (JSON, JSONL, CSV, Parquet or OTLP, optionally .gz/.zst/.bz2 compressed), sqlite://path?query=... or - for stdin; repeatable")
+	cmd.Flags().String("events-format", "", "Events format (json, jsonl, csv, logfmt, regex, cloudevents, otlp, otlp-proto, parquet); detected from the file name or content when empty")
 	cmd.Flags().StringP("config", "c", "default", "Configuration name to use")
 	cmd.Flags().StringP("repo", "r", ".", "Path to git repository")
 	cmd.Flags().StringP("since", "s", "7d", "Time window to look back for commits (e.g., 7d, 24h, 30m)")
-	cmd.Flags().StringP("output", "o", "json", "Output format (json, table)")
+	cmd.Flags().StringP("output", "o", "json", "Output format (json, envelope, jsonl, table, csv, tsv, markdown, parquet, template); envelope is versioned JSON with a schema; parquet requires --out-file")
+	cmd.Flags().String("columns", output.DefaultColumns, "Columns for jsonl, csv, tsv and markdown output: score, delta, delta_seconds, event.id, event.timestamp, event.<attribute>, metadata.<key>, commit.<field>, match.<event key> or match.*")
+	cmd.Flags().String("template-file", "", "Go template rendered by -o template; .html/.htm files use html/template")
+	cmd.Flags().String("out-file", "", "Write results to this file instead of stdout")
+	cmd.Flags().String("group-by", "", "Aggregate results by commit, event or author (json, jsonl, table, csv, tsv and markdown output; not combined with --columns)")
+	cmd.Flags().String("type-key", "request_type", "Event attribute used as the event type by --group-by and metrics")
+	cmd.Flags().Bool("summary", false, "Add summary statistics of the run: match rate, score and time-delta percentiles, rule hit rates, coverage and ambiguity (json and table output)")
+	cmd.Flags().Bool("unmatched", false, "Also report events without a correlation, with the reason, and commits without events (json, table and markdown output)")
 	cmd.Flags().Float64P("threshold", "t", 0.5, "Minimum correlation score threshold")
 	cmd.Flags().BoolP("verbose", "v", false, "Verbose output")
+	cmd.Flags().Bool("write-notes", false, "Write correlations to git notes on each correlated commit")
+	cmd.Flags().String("notes-ref", git.DefaultNotesRef, "Notes ref used by --write-notes")
+	cmd.Flags().Int("max-line-size", parser.DefaultMaxLineSize, "Maximum size in bytes of a single JSONL line")
+	cmd.Flags().String("on-parse-error", parser.OnErrorFail, "How to handle malformed events (fail, skip, quarantine)")
+	cmd.Flags().String("quarantine-file", "git-snap-quarantine.jsonl", "File receiving rejected events with --on-parse-error=quarantine")
+	cmd.Flags().String("store", "", "SQLite database receiving events, commits and correlations of this run")
+	cmd.Flags().String("metrics-file", "", "Write run metrics to this file for the node_exporter textfile collector")
+	cmd.Flags().Duration("interval", 0, "Correlate repeatedly at this interval (e.g. 5m) until interrupted; requires --out-file, and notes and --store are written on every run")
+	cmd.Flags().String("metrics-addr", "", "Serve metrics at /metrics on this address (e.g. :9464) while running with --interval")
+	cmd.Flags().Bool("hunks", false, "Load diff hunks and attribute added lines to events carrying a file path and snippet or content hash")
 
 	cmd.MarkFlagRequired("events")
 
@@ -39,48 +70,187 @@ attribute matching and temporal proximity.`,
 }
 
 func runCorrelate(cmd *cobra.Command, args []string) error {
-	eventsFile, _ := cmd.Flags().GetString("events")
-	configName, _ := cmd.Flags().GetString("config")
-	repoPath, _ := cmd.Flags().GetString("repo")
-	sinceStr, _ := cmd.Flags().GetString("since")
+	metricsFile, _ := cmd.Flags().GetString("metrics-file")
+	metricsAddr, _ := cmd.Flags().GetString("metrics-addr")
+	interval, _ := cmd.Flags().GetDuration("interval")
+
+	tmpl, err := validateCorrelateFlags(cmd)
+	if err != nil {
+		return err
+	}
+	if metricsAddr != "" && interval == 0 {
+		return fmt.Errorf("--metrics-addr requires --interval")
+	}
+	if outFile, _ := cmd.Flags().GetString("out-file"); interval != 0 && outFile == "" {
+		return fmt.Errorf("--interval requires --out-file, which each run replaces")
+	}
+
+	var registry *metrics.Registry
+	if metricsFile != "" || metricsAddr != "" {
+		registry = metrics.NewRegistry()
+		if metricsFile != "" {
+			if err := registry.Load(metricsFile); err != nil {
+				return err
+			}
+		}
+	}
+
+	if interval == 0 {
+		run, err := correlateOnce(cmd, tmpl)
+		if recordErr := recordRun(registry, metricsFile, run); err == nil {
+			err = recordErr
+		}
+		return err
+	}
+	return watchCorrelate(cmd, tmpl, registry, metricsFile, metricsAddr, interval)
+}
+
+// validateCorrelateFlags rejects flag combinations before any work is done,
+// and parses the template of -o template so that it is read only once.
+func validateCorrelateFlags(cmd *cobra.Command) (output.Template, error) {
 	outputFormat, _ := cmd.Flags().GetString("output")
-	threshold, _ := cmd.Flags().GetFloat64("threshold")
-	verbose, _ := cmd.Flags().GetBool("verbose")
+	columns, _ := cmd.Flags().GetString("columns")
+	templateFile, _ := cmd.Flags().GetString("template-file")
+	outFile, _ := cmd.Flags().GetString("out-file")
+	groupBy, _ := cmd.Flags().GetString("group-by")
+	reportUnmatched, _ := cmd.Flags().GetBool("unmatched")
+	reportSummary, _ := cmd.Flags().GetBool("summary")
+	onParseError, _ := cmd.Flags().GetString("on-parse-error")
 
-	if verbose {
-		fmt.Printf("Loading events from: %s\n", eventsFile)
-		fmt.Printf("Using configuration: %s\n", configName)
-		fmt.Printf("Repository path: %s\n", repoPath)
+	if err := parser.ValidateErrorMode(onParseError); err != nil {
+		return nil, err
+	}
+	if outputFormat == "template" && templateFile == "" {
+		return nil, fmt.Errorf("-o template requires --template-file")
+	}
+	if outputFormat == "parquet" && outFile == "" {
+		return nil, fmt.Errorf("-o parquet requires --out-file")
+	}
+	if err := output.ValidateGroupBy(groupBy); err != nil {
+		return nil, err
+	}
+	if groupBy == "" && usesColumns(outputFormat) {
+		if err := output.ValidateColumns(columns); err != nil {
+			return nil, err
+		}
+	}
+	if groupBy != "" && (outputFormat == "parquet" || outputFormat == "template") {
+		return nil, fmt.Errorf("--group-by is not supported with -o %s", outputFormat)
+	}
+	if groupBy != "" && cmd.Flags().Changed("columns") {
+		return nil, fmt.Errorf("--columns cannot be combined with --group-by, whose columns are fixed")
+	}
+	if reportUnmatched && outputFormat != "json" && outputFormat != "envelope" && outputFormat != "table" && outputFormat != "markdown" {
+		return nil, fmt.Errorf("--unmatched is not supported with -o %s", outputFormat)
+	}
+	if reportSummary && outputFormat != "json" && outputFormat != "envelope" && outputFormat != "table" {
+		return nil, fmt.Errorf("--summary is not supported with -o %s", outputFormat)
 	}
 
-	events, err := parser.ParseEventsFromFile(eventsFile)
-	if err != nil {
-		return fmt.Errorf("failed to parse events: %w", err)
+	if outputFormat != "template" {
+		return nil, nil
 	}
+	return output.ParseTemplate(templateFile)
+}
 
-	if verbose {
-		fmt.Printf("Loaded %d events\n", len(events))
+// usesColumns reports whether --columns applies to an output format.
+func usesColumns(format string) bool {
+	switch format {
+	case "jsonl", "csv", "tsv", "markdown":
+		return true
 	}
+	return false
+}
 
-	repoPath, err = git.FindGitRepository(repoPath)
-	if err != nil {
-		return fmt.Errorf("failed to find git repository: %w", err)
+// watchCorrelate correlates every interval until interrupted, serving
+// metrics on addr when it is set. A failed run is reported and counted, and
+// the next run is attempted as usual. Every run repeats all side effects: it
+// replaces the output file, rewrites the notes with --write-notes and adds a
+// run to the --store database.
+func watchCorrelate(cmd *cobra.Command, tmpl output.Template, registry *metrics.Registry, metricsFile, addr string, interval time.Duration) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if addr != "" {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("failed to serve metrics: %w", err)
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", registry)
+		server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				fmt.Fprintf(os.Stderr, "Metrics server failed: %v\n", err)
+			}
+		}()
+		defer server.Close()
+		fmt.Fprintf(os.Stderr, "Serving metrics on http://%s/metrics\n", listener.Addr())
 	}
 
-	gitClient := git.NewGitClient(repoPath)
+	for {
+		run, err := correlateOnce(cmd, tmpl)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Correlation failed: %v\n", err)
+		}
+		if err := recordRun(registry, metricsFile, run); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+		}
 
-	since, err := parseTimeWindow(sinceStr)
-	if err != nil {
-		return fmt.Errorf("invalid time window: %w", err)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
 	}
+}
 
-	commits, err := gitClient.GetCommits(since)
-	if err != nil {
-		return fmt.Errorf("failed to get commits: %w", err)
+func recordRun(registry *metrics.Registry, metricsFile string, run metrics.Run) error {
+	if registry == nil {
+		return nil
+	}
+	registry.Record(run)
+	if metricsFile == "" {
+		return nil
 	}
+	return registry.WriteFile(metricsFile)
+}
+
+// correlateOnce performs one correlation run and describes it for metrics,
+// whether or not it succeeded. tmpl is the parsed template of -o template.
+func correlateOnce(cmd *cobra.Command, tmpl output.Template) (run metrics.Run, err error) {
+	eventsFiles, _ := cmd.Flags().GetStringArray("events")
+	configName, _ := cmd.Flags().GetString("config")
+	repoPath, _ := cmd.Flags().GetString("repo")
+	sinceStr, _ := cmd.Flags().GetString("since")
+	outputFormat, _ := cmd.Flags().GetString("output")
+	columns, _ := cmd.Flags().GetString("columns")
+	outFile, _ := cmd.Flags().GetString("out-file")
+	groupBy, _ := cmd.Flags().GetString("group-by")
+	typeKey, _ := cmd.Flags().GetString("type-key")
+	reportUnmatched, _ := cmd.Flags().GetBool("unmatched")
+	reportSummary, _ := cmd.Flags().GetBool("summary")
+	threshold, _ := cmd.Flags().GetFloat64("threshold")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	loadHunks, _ := cmd.Flags().GetBool("hunks")
+	writeNotes, _ := cmd.Flags().GetBool("write-notes")
+	notesRef, _ := cmd.Flags().GetString("notes-ref")
+	maxLineSize, _ := cmd.Flags().GetInt("max-line-size")
+	eventsFormat, _ := cmd.Flags().GetString("events-format")
+	onParseError, _ := cmd.Flags().GetString("on-parse-error")
+	quarantineFile, _ := cmd.Flags().GetString("quarantine-file")
+	storePath, _ := cmd.Flags().GetString("store")
+
+	run = metrics.Run{Config: configName, TypeKey: typeKey, Started: time.Now()}
+	defer func() {
+		run.Duration = time.Since(run.Started)
+		run.Err = err
+	}()
 
 	if verbose {
-		fmt.Printf("Found %d commits since %s\n", len(commits), since.Format("2006-01-02"))
+		fmt.Printf("Loading events from: %s\n", strings.Join(eventsFiles, ", "))
+		fmt.Printf("Using configuration: %s\n", configName)
+		fmt.Printf("Repository path: %s\n", repoPath)
 	}
 
 	configManager := config.NewConfigManager(getConfigPath())
@@ -114,8 +284,81 @@ func runCorrelate(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	repoPath, err = git.FindGitRepository(repoPath)
+	if err != nil {
+		return run, fmt.Errorf("failed to find git repository: %w", err)
+	}
+
+	gitClient := git.NewGitClient(repoPath)
+	gitClient.SetLoadHunks(loadHunks)
+
+	since, err := parseTimeWindow(sinceStr)
+	if err != nil {
+		return run, fmt.Errorf("invalid time window: %w", err)
+	}
+
+	commits, err := gitClient.GetCommits(since)
+	if err != nil {
+		return run, fmt.Errorf("failed to get commits: %w", err)
+	}
+
+	if verbose {
+		fmt.Printf("Found %d commits since %s\n", len(commits), since.Format("2006-01-02"))
+	}
+
+	// Events further than the time window from every commit cannot
+	// correlate, so they are dropped and counted while reading, and Parquet
+	// row groups holding only such events are not read at all. Reporting unmatched
+	// events needs every event, so nothing is dropped then.
+	var eventsSince, eventsUntil time.Time
+	if !reportUnmatched {
+		eventsSince, eventsUntil = eventRange(commits, snapConfig.TimeWindow)
+	}
+	eventReader, err := parser.OpenEventFiles(eventsFiles, parser.ReaderOptions{
+		MaxLineSize: maxLineSize,
+		Format:      eventsFormat,
+		Mapping:     snapConfig.FieldMapping,
+		Schema:      snapConfig.Schema,
+		OnError:     onParseError,
+		Since:       eventsSince,
+		Until:       eventsUntil,
+	})
+	if err != nil {
+		return run, fmt.Errorf("failed to parse events: %w", err)
+	}
+	defer eventReader.Close()
+
 	engine := correlation.NewCorrelationEngine(*snapConfig)
-	results := engine.SnapToCommits(events, commits)
+	engine.SetTrackUnmatched(reportUnmatched)
+	results, err := engine.SnapStream(eventReader, commits)
+	if err != nil {
+		return run, fmt.Errorf("failed to parse events: %w", err)
+	}
+
+	if verbose {
+		fmt.Printf("Loaded %d events from %d files\n", eventReader.Count(), len(eventReader.Paths()))
+		if eventReader.OutOfRange() > 0 {
+			fmt.Printf("Skipped %d events outside the time window of the commits\n", eventReader.OutOfRange())
+		}
+		if eventReader.Duplicates() > 0 {
+			fmt.Printf("Skipped %d events with duplicate IDs\n", eventReader.Duplicates())
+		}
+	}
+
+	// Events dropped for lying outside the time window were read too, and
+	// count as unmatched.
+	eventsRead := eventReader.Count() + eventReader.OutOfRange()
+	run.EventsRead = eventsRead
+	run.ParseErrors = len(eventReader.Errors())
+
+	if parseErrors := eventReader.Errors(); len(parseErrors) > 0 {
+		if onParseError == parser.OnErrorQuarantine {
+			if err := parser.WriteQuarantine(quarantineFile, parseErrors); err != nil {
+				return run, err
+			}
+		}
+		printParseErrors(parseErrors, onParseError, quarantineFile)
+	}
 
 	filteredResults := make([]types.CorrelationResult, 0)
 	for _, result := range results {
@@ -124,11 +367,126 @@ func runCorrelate(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	run.Results = filteredResults
+
 	if verbose {
 		fmt.Printf("Found %d correlations above threshold %.2f\n", len(filteredResults), threshold)
+		if loadHunks {
+			for sha, fraction := range correlation.CommitLineAttribution(filteredResults) {
+				fmt.Printf("  %s: %.1f%% of added lines attributed to events\n", sha[:8], fraction*100)
+			}
+		}
 	}
 
-	return outputResults(filteredResults, outputFormat)
+	if writeNotes {
+		written, err := notes.NewStore(gitClient, notesRef).Write(notes.FromResults(filteredResults, configName))
+		if err != nil {
+			return run, fmt.Errorf("failed to write notes: %w", err)
+		}
+		if verbose {
+			fmt.Printf("Wrote %d notes to %s\n", written, notesRef)
+		}
+	}
+
+	if storePath != "" {
+		if err := storeResults(storePath, store.Run{
+			StartedAt:  run.Started,
+			Config:     configName,
+			Repository: repoPath,
+			Threshold:  threshold,
+			Events:     eventsRead,
+		}, filteredResults, verbose); err != nil {
+			return run, err
+		}
+	}
+
+	opts := outputOptions{
+		format:     outputFormat,
+		columns:    columns,
+		template:   tmpl,
+		groupBy:    groupBy,
+		typeKey:    typeKey,
+		config:     *snapConfig,
+		configName: configName,
+		threshold:  threshold,
+		run: output.RunInfo{
+			Version:    Version,
+			StartedAt:  run.Started,
+			Repository: repoPath,
+			ConfigName: configName,
+			Threshold:  threshold,
+			Since:      since,
+			Sources:    eventsFiles,
+		},
+	}
+	if reportUnmatched {
+		opts.unmatched = &unmatchedReport{
+			events:  engine.Unmatched(results, threshold),
+			commits: correlation.UncorrelatedCommits(commits, filteredResults),
+		}
+		if verbose {
+			fmt.Printf("Found %d unmatched events and %d uncorrelated commits\n", len(opts.unmatched.events), len(opts.unmatched.commits))
+		}
+	}
+	if reportSummary || outputFormat == "template" {
+		input := stats.Input{Results: filteredResults, Candidates: results, Commits: commits, Events: eventsRead}
+		if opts.unmatched != nil {
+			input.Unmatched = opts.unmatched.events
+		}
+		summary := stats.Compute(input, stats.DefaultAmbiguityMargin)
+		opts.summary = &summary
+	}
+
+	return run, writeResults(outFile, filteredResults, opts)
+}
+
+func storeResults(path string, run store.Run, results []types.CorrelationResult, verbose bool) error {
+	resultStore, err := store.Open(path)
+	if err != nil {
+		return err
+	}
+	defer resultStore.Close()
+
+	runID, err := resultStore.WriteRun(run, results)
+	if err != nil {
+		return fmt.Errorf("failed to store results: %w", err)
+	}
+	if verbose {
+		fmt.Printf("Stored %d correlations in %s as run %d\n", len(results), path, runID)
+	}
+	return nil
+}
+
+func printParseErrors(parseErrors []parser.RecordError, mode, quarantineFile string) {
+	const maxListed = 10
+
+	fmt.Fprintf(os.Stderr, "Skipped %d malformed events:\n", len(parseErrors))
+	for i, parseErr := range parseErrors {
+		if i == maxListed {
+			fmt.Fprintf(os.Stderr, "  ... and %d more\n", len(parseErrors)-maxListed)
+			break
+		}
+		fmt.Fprintf(os.Stderr, "  %s: %s\n", parseErr.File, parseErr.Reason)
+	}
+	if mode == parser.OnErrorQuarantine {
+		fmt.Fprintf(os.Stderr, "Rejected events written to %s\n", quarantineFile)
+	}
+}
+
+func eventRange(commits []types.EnrichedCommit, window time.Duration) (time.Time, time.Time) {
+	var earliest, latest time.Time
+	for _, commit := range commits {
+		if earliest.IsZero() || commit.Timestamp.Before(earliest) {
+			earliest = commit.Timestamp
+		}
+		if latest.IsZero() || commit.Timestamp.After(latest) {
+			latest = commit.Timestamp
+		}
+	}
+	if earliest.IsZero() {
+		return time.Time{}, time.Time{}
+	}
+	return earliest.Add(-window), latest.Add(window)
 }
 
 func parseTimeWindow(window string) (time.Time, error) {
@@ -150,25 +508,262 @@ func parseTimeWindow(window string) (time.Time, error) {
 	return time.Now().Add(-duration), nil
 }
 
-func outputResults(results []types.CorrelationResult, format string) error {
-	switch format {
+// writeResults writes to path, or to stdout when path is empty. The output
+// is written to a temporary file next to path and renamed over it once
+// complete, so a failed run leaves any previous output in place.
+func writeResults(path string, results []types.CorrelationResult, opts outputOptions) error {
+	if path == "" {
+		return outputResults(os.Stdout, results, opts)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if err := outputResults(file, results, opts); err != nil {
+		file.Close()
+		return err
+	}
+	// CreateTemp makes the file private; give it the mode os.Create would.
+	if err := file.Chmod(0644); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
+
+type outputOptions struct {
+	format     string
+	columns    string
+	template   output.Template
+	groupBy    string
+	typeKey    string
+	unmatched  *unmatchedReport
+	summary    *stats.Stats
+	config     types.SnapConfig
+	configName string
+	threshold  float64
+	run        output.RunInfo
+}
+
+// unmatchedReport is what --unmatched adds to the output.
+type unmatchedReport struct {
+	events  []types.UnmatchedEvent
+	commits []types.EnrichedCommit
+}
+
+// correlateOutput is the JSON document written with --unmatched or
+// --summary, in place of the bare list of results or groups.
+type correlateOutput struct {
+	Results             interface{}             `json:"results"`
+	UnmatchedEvents     *[]types.UnmatchedEvent `json:"unmatched_events,omitempty"`
+	UncorrelatedCommits *[]types.EnrichedCommit `json:"uncorrelated_commits,omitempty"`
+	Summary             *stats.Stats            `json:"summary,omitempty"`
+}
+
+func outputResults(w io.Writer, results []types.CorrelationResult, opts outputOptions) error {
+	if opts.groupBy != "" {
+		return outputGroups(w, results, opts)
+	}
+
+	switch opts.format {
 	case "json":
-		encoder := json.NewEncoder(os.Stdout)
-		encoder.SetIndent("", "  ")
-		return encoder.Encode(results)
+		return outputJSON(w, results, opts)
+	case "envelope":
+		return outputEnvelope(w, results, nil, opts)
 	case "table":
-		return outputTable(results)
+		if err := outputTable(w, results); err != nil {
+			return err
+		}
+		return outputTableSections(w, opts)
+	case "parquet":
+		return output.WriteParquet(w, results)
+	case "jsonl", "csv", "tsv", "markdown":
+		selected, err := output.ParseColumns(opts.columns, results)
+		if err != nil {
+			return err
+		}
+		switch opts.format {
+		case "jsonl":
+			return output.WriteJSONL(w, results, selected)
+		case "csv":
+			return output.WriteCSV(w, results, selected)
+		case "tsv":
+			return output.WriteTSV(w, results, selected)
+		default:
+			if err := output.WriteMarkdown(w, results, selected); err != nil {
+				return err
+			}
+			return outputUnmatchedMarkdown(w, opts.unmatched)
+		}
+	case "template":
+		data := output.NewTemplateData(results, opts.config, opts.configName, opts.threshold)
+		if opts.summary != nil {
+			data.Summary = *opts.summary
+		}
+		return output.RenderTemplate(w, opts.template, data)
+	default:
+		return fmt.Errorf("unsupported output format: %s", opts.format)
+	}
+}
+
+func outputGroups(w io.Writer, results []types.CorrelationResult, opts outputOptions) error {
+	groups, err := output.GroupResults(results, opts.groupBy, opts.typeKey)
+	if err != nil {
+		return err
+	}
+
+	switch opts.format {
+	case "json":
+		return outputJSON(w, groups, opts)
+	case "envelope":
+		return outputEnvelope(w, results, groups, opts)
+	case "table":
+		if err := outputGroupTable(w, groups, opts.groupBy); err != nil {
+			return err
+		}
+		return outputTableSections(w, opts)
+	case "jsonl":
+		return output.GroupTable(groups, opts.groupBy).WriteJSONL(w)
+	case "csv":
+		return output.GroupTable(groups, opts.groupBy).WriteCSV(w)
+	case "tsv":
+		return output.GroupTable(groups, opts.groupBy).WriteTSV(w)
+	case "markdown":
+		if err := output.GroupTable(groups, opts.groupBy).WriteMarkdown(w); err != nil {
+			return err
+		}
+		return outputUnmatchedMarkdown(w, opts.unmatched)
 	default:
-		return fmt.Errorf("unsupported output format: %s", format)
+		return fmt.Errorf("unsupported output format with --group-by: %s", opts.format)
+	}
+}
+
+func outputEnvelope(w io.Writer, results []types.CorrelationResult, groups []output.Group, opts outputOptions) error {
+	envelopeOpts := output.EnvelopeOptions{Groups: groups, Summary: opts.summary}
+	if opts.unmatched != nil {
+		envelopeOpts.UnmatchedEvents = opts.unmatched.events
+		envelopeOpts.UncorrelatedCommits = opts.unmatched.commits
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output.NewEnvelope(opts.run, opts.config, results, envelopeOpts))
+}
+
+func outputJSON(w io.Writer, results interface{}, opts outputOptions) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if opts.unmatched == nil && opts.summary == nil {
+		return encoder.Encode(results)
+	}
+
+	document := correlateOutput{Results: results, Summary: opts.summary}
+	if unmatched := opts.unmatched; unmatched != nil {
+		events := append([]types.UnmatchedEvent{}, unmatched.events...)
+		commits := append([]types.EnrichedCommit{}, unmatched.commits...)
+		document.UnmatchedEvents = &events
+		document.UncorrelatedCommits = &commits
+	}
+	return encoder.Encode(document)
+}
+
+func outputTableSections(w io.Writer, opts outputOptions) error {
+	if err := outputUnmatchedTable(w, opts.unmatched); err != nil {
+		return err
+	}
+	if opts.summary != nil {
+		fmt.Fprintf(w, "\nSummary\n\n")
+		outputStats(w, *opts.summary)
 	}
+	return nil
+}
+
+func outputUnmatchedTable(w io.Writer, unmatched *unmatchedReport) error {
+	if unmatched == nil {
+		return nil
+	}
+
+	fmt.Fprintf(w, "\nUnmatched events: %d\n", len(unmatched.events))
+	if len(unmatched.events) > 0 {
+		fmt.Fprintf(w, "%-20s %-20s %-22s %-10s %s\n", "Event ID", "Timestamp", "Reason", "Candidates", "Detail")
+		fmt.Fprintln(w, strings.Repeat("-", 100))
+		for _, event := range unmatched.events {
+			fmt.Fprintf(w, "%-20s %-20s %-22s %-10d %s\n",
+				truncate(event.Event.Key(), 20),
+				event.Event.Timestamp.Format("2006-01-02 15:04:05"),
+				event.Reason,
+				event.Candidates,
+				output.UnmatchedDetail(event))
+		}
+	}
+
+	fmt.Fprintf(w, "\nUncorrelated commits: %d\n", len(unmatched.commits))
+	if len(unmatched.commits) > 0 {
+		fmt.Fprintf(w, "%-8s %-20s %-20s %s\n", "SHA", "Timestamp", "Author", "Message")
+		fmt.Fprintln(w, strings.Repeat("-", 100))
+		for _, commit := range unmatched.commits {
+			message, _, _ := strings.Cut(commit.Message, "\n")
+			fmt.Fprintf(w, "%-8s %-20s %-20s %s\n",
+				output.ShortSHA(commit.SHA),
+				commit.Timestamp.Format("2006-01-02 15:04:05"),
+				truncate(commit.Author, 20),
+				message)
+		}
+	}
+
+	return nil
+}
+
+func outputUnmatchedMarkdown(w io.Writer, unmatched *unmatchedReport) error {
+	if unmatched == nil {
+		return nil
+	}
+
+	fmt.Fprintf(w, "\n### Unmatched events (%d)\n\n", len(unmatched.events))
+	if len(unmatched.events) > 0 {
+		if err := output.UnmatchedTable(unmatched.events).WriteMarkdown(w); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "\n### Uncorrelated commits (%d)\n\n", len(unmatched.commits))
+	if len(unmatched.commits) > 0 {
+		return output.UncorrelatedTable(unmatched.commits).WriteMarkdown(w)
+	}
+	return nil
+}
+
+func outputGroupTable(w io.Writer, groups []output.Group, groupBy string) error {
+	fmt.Fprintf(w, "%-40s %-8s %-8s %-7s %-7s %s\n", strings.ToUpper(groupBy[:1])+groupBy[1:], "Best", "Mean", "Events", "Commits", "Event Types")
+	fmt.Fprintln(w, strings.Repeat("-", 100))
+
+	for _, group := range groups {
+		fmt.Fprintf(w, "%-40s %.3f    %.3f    %-7d %-7d %s\n",
+			truncate(group.Key, 40),
+			group.BestScore,
+			group.MeanScore,
+			len(group.Events),
+			len(group.Commits),
+			strings.Join(group.EventTypes, ", "))
+	}
+
+	return nil
 }
 
-func outputTable(results []types.CorrelationResult) error {
-	fmt.Printf("%-8s %-12s %-40s %-10s %-15s\n", "Score", "Event ID", "Commit SHA", "Time Delta", "Author")
-	fmt.Println(strings.Repeat("-", 90))
+func outputTable(w io.Writer, results []types.CorrelationResult) error {
+	fmt.Fprintf(w, "%-8s %-12s %-40s %-10s %-15s\n", "Score", "Event ID", "Commit SHA", "Time Delta", "Author")
+	fmt.Fprintln(w, strings.Repeat("-", 90))
 
 	for _, result := range results {
-		fmt.Printf("%.3f    %-12s %-40s %-10s %-15s\n",
+		fmt.Fprintf(w, "%.3f    %-12s %-40s %-10s %-15s\n",
 			result.Score,
 			result.Event.ID,
 			result.Commit.SHA[:8],
@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/store"
+)
+
+func TestCorrelateCountsEventsOutsideTheWindow(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	repo := t.TempDir()
+	committed := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	gitRun(t, repo, nil, "init", "-q")
+	if err := os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, repo, nil, "add", "main.go")
+	date := committed.Format(time.RFC3339)
+	gitRun(t, repo, []string{"GIT_AUTHOR_DATE=" + date, "GIT_COMMITTER_DATE=" + date},
+		"-c", "user.name=Dev", "-c", "user.email=dev@example.com", "commit", "-q", "-m", "Add main")
+
+	// The default configuration correlates within 15 minutes, so only the
+	// first event can match; the other two are dropped while reading.
+	var events strings.Builder
+	for i, offset := range []time.Duration{time.Minute, -48 * time.Hour, 48 * time.Hour} {
+		fmt.Fprintf(&events, `{"id": "e%d", "timestamp": %q, "attributes": {"user_id": "dev@example.com"}}`+"\n",
+			i, committed.Add(offset).Format(time.RFC3339))
+	}
+	dir := t.TempDir()
+	eventsFile := filepath.Join(dir, "events.jsonl")
+	if err := os.WriteFile(eventsFile, []byte(events.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	outFile := filepath.Join(dir, "results.json")
+	storePath := filepath.Join(dir, "snaps.db")
+	metricsFile := filepath.Join(dir, "git-snap.prom")
+	cmd := NewCorrelateCommand()
+	cmd.SetArgs([]string{"-r", repo, "-e", eventsFile, "-t", "0", "--summary", "-o", "envelope", "--out-file", outFile,
+		"--store", storePath, "--metrics-file", metricsFile})
+	cmd.SilenceUsage = true
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("correlate failed: %v", err)
+	}
+
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	var envelope struct {
+		Summary struct {
+			Events        int     `json:"events"`
+			MatchedEvents int     `json:"matched_events"`
+			MatchRate     float64 `json:"match_rate"`
+		} `json:"summary"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		t.Fatalf("Failed to parse output: %v", err)
+	}
+	if envelope.Summary.Events != 3 || envelope.Summary.MatchedEvents != 1 {
+		t.Errorf("Expected 1 of 3 events matched, got %d of %d", envelope.Summary.MatchedEvents, envelope.Summary.Events)
+	}
+
+	resultStore, err := store.Open(storePath)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	defer resultStore.Close()
+	run, results, err := resultStore.ReadRun(0)
+	if err != nil {
+		t.Fatalf("Failed to read run: %v", err)
+	}
+	if run.Events != 3 || len(results) != 1 {
+		t.Errorf("Expected a stored run of 3 events with 1 correlation, got %d and %d", run.Events, len(results))
+	}
+
+	metrics, err := os.ReadFile(metricsFile)
+	if err != nil {
+		t.Fatalf("Failed to read metrics: %v", err)
+	}
+	if !strings.Contains(string(metrics), `git_snap_events_read{config="default"} 3`) {
+		t.Errorf("Expected 3 events read in metrics, got:\n%s", metrics)
+	}
+}
+
+func TestCorrelateIntervalRequiresOutFile(t *testing.T) {
+	cmd := NewCorrelateCommand()
+	cmd.SetArgs([]string{"-e", "events.jsonl", "--interval", "1m"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--out-file") {
+		t.Errorf("Expected --interval without --out-file to be rejected, got %v", err)
+	}
+}
+
+func gitRun(t *testing.T, dir string, env []string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, output)
+	}
+}
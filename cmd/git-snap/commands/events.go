@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/fraser-isbester/git-snap/pkg/config"
+	"github.com/fraser-isbester/git-snap/pkg/correlation"
+	"github.com/fraser-isbester/git-snap/pkg/parser"
+	"github.com/fraser-isbester/git-snap/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+func NewEventsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "Inspect event files",
+	}
+
+	cmd.AddCommand(newEventsValidateCommand())
+
+	return cmd
+}
+
+func newEventsValidateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate events against a configuration",
+		Long: `Parse events with a configuration's field mapping, check each one against
+the configuration's schema, and report attribute rules that can never be
+satisfied by the config or by the events given.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			eventsFiles, _ := cmd.Flags().GetStringArray("events")
+			eventsFormat, _ := cmd.Flags().GetString("events-format")
+			configName, _ := cmd.Flags().GetString("config")
+			maxLineSize, _ := cmd.Flags().GetInt("max-line-size")
+			maxErrors, _ := cmd.Flags().GetInt("max-errors")
+
+			snapConfig, err := config.NewConfigManager(getConfigPath()).LoadConfig(configName)
+			if err != nil {
+				if configName != "default" {
+					return fmt.Errorf("failed to load config %s: %w", configName, err)
+				}
+				snapConfig = config.DefaultConfig()
+			}
+
+			reader, err := parser.OpenEventFiles(eventsFiles, parser.ReaderOptions{
+				MaxLineSize: maxLineSize,
+				Format:      eventsFormat,
+				Mapping:     snapConfig.FieldMapping,
+				Schema:      snapConfig.Schema,
+				OnError:     parser.OnErrorSkip,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to open events: %w", err)
+			}
+			defer reader.Close()
+
+			coverage := correlation.NewKeyCoverage()
+			for {
+				event, err := reader.Read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return fmt.Errorf("failed to read events: %w", err)
+				}
+				coverage.Add(event)
+			}
+
+			invalid := reader.Errors()
+			issues := correlation.CheckRules(*snapConfig, coverage)
+
+			fmt.Printf("Events: %d valid, %d invalid in %d files (config %s)\n", reader.Count(), len(invalid), len(reader.Paths()), configName)
+			if snapConfig.Schema == nil {
+				fmt.Printf("Config %s declares no schema; only parse errors are reported\n", configName)
+			}
+
+			if len(invalid) > 0 {
+				fmt.Printf("\nInvalid events:\n")
+				for i, recordErr := range invalid {
+					if maxErrors > 0 && i == maxErrors {
+						fmt.Printf("  ... and %d more\n", len(invalid)-maxErrors)
+						break
+					}
+					fmt.Printf("  %s: %s\n", recordErr.File, recordErr.Reason)
+				}
+			}
+
+			unsatisfiable := 0
+			if len(issues) > 0 {
+				fmt.Printf("\nRules:\n")
+				for _, issue := range issues {
+					label := "warning"
+					if issue.Unsatisfiable {
+						label = "never satisfiable"
+						unsatisfiable++
+					}
+					fmt.Printf("  %s: %s\n", formatRule(issue.Rule), label)
+					fmt.Printf("    %s\n", issue.Reason)
+				}
+			}
+
+			if len(invalid) > 0 || unsatisfiable > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("validation failed: %d invalid events, %d unsatisfiable rules", len(invalid), unsatisfiable)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringArrayP("events", "e", nil, "Events file, glob or directory, sqlite://path?query=..., or - for stdin; repeatable")
+	cmd.Flags().String("events-format", "", "Events format (json, jsonl, csv, logfmt, regex, cloudevents, otlp, otlp-proto); detected from the file name or content when empty")
+	cmd.Flags().StringP("config", "c", "default", "Configuration name to validate against")
+	cmd.Flags().Int("max-line-size", parser.DefaultMaxLineSize, "Maximum size in bytes of a single JSONL line")
+	cmd.Flags().Int("max-errors", 20, "Maximum number of invalid events to list (0 lists all)")
+
+	cmd.MarkFlagRequired("events")
+
+	return cmd
+}
+
+func formatRule(rule types.AttributeRule) string {
+	return fmt.Sprintf("%s -> %s (%s)", rule.EventKey, rule.CommitKey, rule.MatchType.String())
+}
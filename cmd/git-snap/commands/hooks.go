@@ -0,0 +1,237 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/correlation"
+	"github.com/fraser-isbester/git-snap/pkg/git"
+	"github.com/fraser-isbester/git-snap/pkg/hooks"
+	"github.com/fraser-isbester/git-snap/pkg/notes"
+	"github.com/fraser-isbester/git-snap/pkg/parser"
+	"github.com/fraser-isbester/git-snap/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+func NewHooksCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hooks",
+		Short: "Manage git hooks for real-time correlation",
+		Long: `Install git hooks that correlate recent events from a local spool with
+each commit as it is made, recording the attribution as commit trailers
+(prepare-commit-msg) or git notes (post-commit).`,
+	}
+
+	cmd.AddCommand(newHooksInstallCommand())
+	cmd.AddCommand(newHooksUninstallCommand())
+	cmd.AddCommand(newHooksRunCommand())
+
+	return cmd
+}
+
+func newHooksInstallCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "install",
+		Short: "Install git-snap hooks in the repository",
+		Long: `Install git-snap hooks in the repository. Existing hooks are kept and
+chained: they are renamed with a .pre-git-snap suffix and run first.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoPath, _ := cmd.Flags().GetString("repo")
+			spool, _ := cmd.Flags().GetString("spool")
+			configName, _ := cmd.Flags().GetString("config")
+			mode, _ := cmd.Flags().GetString("mode")
+			threshold, _ := cmd.Flags().GetFloat64("threshold")
+			notesRef, _ := cmd.Flags().GetString("notes-ref")
+
+			repoPath, err := git.FindGitRepository(repoPath)
+			if err != nil {
+				return fmt.Errorf("failed to find git repository: %w", err)
+			}
+
+			hooksDir, err := git.NewGitClient(repoPath).HooksPath()
+			if err != nil {
+				return err
+			}
+
+			spool, err = filepath.Abs(spool)
+			if err != nil {
+				return fmt.Errorf("invalid spool path: %w", err)
+			}
+
+			executable, err := os.Executable()
+			if err != nil {
+				executable = "git-snap"
+			}
+
+			installed, err := hooks.Install(hooksDir, hooks.Options{
+				Executable: executable,
+				Config:     configName,
+				Spool:      spool,
+				Mode:       mode,
+				Threshold:  threshold,
+				NotesRef:   notesRef,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to install hooks: %w", err)
+			}
+
+			fmt.Printf("Installed hooks in %s: %s\n", hooksDir, strings.Join(installed, ", "))
+			fmt.Printf("Events are read from %s\n", spool)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("repo", "r", ".", "Path to git repository")
+	cmd.Flags().String("spool", filepath.Join(filepath.Dir(getConfigPath()), "spool"), "Events file or directory the hooks read from")
+	cmd.Flags().StringP("config", "c", "default", "Configuration name to use")
+	cmd.Flags().String("mode", hooks.ModeTrailers, "Record attribution as trailers, notes or both")
+	cmd.Flags().Float64P("threshold", "t", 0.5, "Minimum correlation score threshold")
+	cmd.Flags().String("notes-ref", git.DefaultNotesRef, "Notes ref used in notes mode")
+
+	return cmd
+}
+
+func newHooksUninstallCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Remove git-snap hooks and restore chained hooks",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoPath, _ := cmd.Flags().GetString("repo")
+
+			repoPath, err := git.FindGitRepository(repoPath)
+			if err != nil {
+				return fmt.Errorf("failed to find git repository: %w", err)
+			}
+
+			hooksDir, err := git.NewGitClient(repoPath).HooksPath()
+			if err != nil {
+				return err
+			}
+
+			removed, err := hooks.Uninstall(hooksDir)
+			if err != nil {
+				return fmt.Errorf("failed to uninstall hooks: %w", err)
+			}
+
+			if len(removed) == 0 {
+				fmt.Println("No git-snap hooks installed")
+				return nil
+			}
+
+			fmt.Printf("Removed hooks from %s: %s\n", hooksDir, strings.Join(removed, ", "))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("repo", "r", ".", "Path to git repository")
+
+	return cmd
+}
+
+func newHooksRunCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "run <hook> [hook-args...]",
+		Short:  "Run a git-snap hook (invoked by the installed hook scripts)",
+		Hidden: true,
+		Args:   cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			spool, _ := cmd.Flags().GetString("spool")
+			configName, _ := cmd.Flags().GetString("config")
+			threshold, _ := cmd.Flags().GetFloat64("threshold")
+			notesRef, _ := cmd.Flags().GetString("notes-ref")
+
+			repoPath, err := git.FindGitRepository(".")
+			if err != nil {
+				return fmt.Errorf("failed to find git repository: %w", err)
+			}
+			gitClient := git.NewGitClient(repoPath)
+
+			var commit *types.EnrichedCommit
+			switch args[0] {
+			case hooks.PrepareCommitMsg:
+				if len(args) < 2 {
+					return fmt.Errorf("prepare-commit-msg requires the message file")
+				}
+				commit, err = gitClient.PendingCommit()
+			case hooks.PostCommit:
+				commit, err = gitClient.GetCommitDetails("HEAD")
+			default:
+				return fmt.Errorf("unsupported hook: %s", args[0])
+			}
+			if err != nil {
+				return err
+			}
+
+			snapConfig := loadSnapConfig(configName)
+			events, err := loadSpoolEvents(spool, commit.Timestamp, snapConfig)
+			if err != nil {
+				return err
+			}
+
+			engine := correlation.NewCorrelationEngine(*snapConfig)
+			var results []types.CorrelationResult
+			for _, result := range engine.SnapToCommits(events, []types.EnrichedCommit{*commit}) {
+				if result.Score >= threshold {
+					results = append(results, result)
+				}
+			}
+
+			if len(results) == 0 {
+				return nil
+			}
+
+			if args[0] == hooks.PrepareCommitMsg {
+				return gitClient.AppendTrailersToFile(args[1], git.TrailersForCommit(results))
+			}
+
+			_, err = notes.NewStore(gitClient, notesRef).Write(notes.FromResults(results, configName))
+			return err
+		},
+	}
+
+	cmd.Flags().String("spool", "", "Events file or directory")
+	cmd.Flags().StringP("config", "c", "default", "Configuration name to use")
+	cmd.Flags().Float64P("threshold", "t", 0.5, "Minimum correlation score threshold")
+	cmd.Flags().String("notes-ref", git.DefaultNotesRef, "Notes ref used for post-commit")
+
+	return cmd
+}
+
+func loadSpoolEvents(spool string, at time.Time, snapConfig *types.SnapConfig) ([]types.SnapEvent, error) {
+	if _, err := os.Stat(spool); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	reader, err := parser.OpenEventFiles([]string{spool}, parser.ReaderOptions{
+		Mapping: snapConfig.FieldMapping,
+		Schema:  snapConfig.Schema,
+		OnError: parser.OnErrorSkip,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool: %w", err)
+	}
+	defer reader.Close()
+
+	var events []types.SnapEvent
+	for {
+		event, err := reader.Read()
+		if err == io.EOF {
+			return events, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse spool: %w", err)
+		}
+
+		delta := at.Sub(event.Timestamp)
+		if delta < 0 {
+			delta = -delta
+		}
+		if delta <= snapConfig.TimeWindow {
+			events = append(events, event)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fraser-isbester/git-snap/pkg/config"
+	"github.com/fraser-isbester/git-snap/pkg/git"
+	"github.com/fraser-isbester/git-snap/pkg/output"
+	"github.com/fraser-isbester/git-snap/pkg/parser"
+	"github.com/fraser-isbester/git-snap/pkg/report"
+	"github.com/fraser-isbester/git-snap/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+func NewReportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Summarize stored correlation results",
+		Long: `Summarize stored correlation results (a results file, or the git-snap notes
+written by --write-notes) per repository. With --html, render a single-file
+HTML report instead: a timeline per author showing events and commits on the
+same axis with their correlations, score histograms, per-repository summaries
+and, when the correlated events are given with --events, the events that
+matched no commit. The page embeds all of its styles and charts and can be
+viewed offline.`,
+		RunE: runReport,
+	}
+
+	cmd.Flags().StringP("results", "R", "", "Path to stored correlation results (JSON output of correlate)")
+	cmd.Flags().String("notes-ref", git.DefaultNotesRef, "Notes ref to read correlations from when --results is not set")
+	cmd.Flags().StringP("repo", "r", ".", "Path to git repository")
+	cmd.Flags().StringArrayP("events", "e", nil, "Events that were correlated, to list unmatched events (repeatable)")
+	cmd.Flags().String("events-format", "", "Events format; detected from the file name or content when empty")
+	cmd.Flags().StringP("config", "c", "default", "Configuration whose field mapping reads the events")
+	cmd.Flags().Float64P("threshold", "t", 0, "Minimum correlation score for a result to count")
+	cmd.Flags().Bool("html", false, "Render a self-contained HTML report")
+	cmd.Flags().String("title", "git-snap correlation report", "Title of the HTML report")
+	cmd.Flags().String("out-file", "", "Write the report to a file instead of stdout")
+	cmd.Flags().BoolP("verbose", "v", false, "Verbose output")
+
+	return cmd
+}
+
+func runReport(cmd *cobra.Command, args []string) error {
+	resultsFile, _ := cmd.Flags().GetString("results")
+	notesRef, _ := cmd.Flags().GetString("notes-ref")
+	repoPath, _ := cmd.Flags().GetString("repo")
+	eventsFiles, _ := cmd.Flags().GetStringArray("events")
+	eventsFormat, _ := cmd.Flags().GetString("events-format")
+	configName, _ := cmd.Flags().GetString("config")
+	threshold, _ := cmd.Flags().GetFloat64("threshold")
+	html, _ := cmd.Flags().GetBool("html")
+	title, _ := cmd.Flags().GetString("title")
+	outFile, _ := cmd.Flags().GetString("out-file")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+
+	var results []types.CorrelationResult
+	var err error
+	if resultsFile != "" {
+		results, err = loadResults(resultsFile)
+	} else {
+		repoPath, err = git.FindGitRepository(repoPath)
+		if err != nil {
+			return fmt.Errorf("failed to find git repository: %w", err)
+		}
+		results, err = loadNoteResults(git.NewGitClient(repoPath), notesRef)
+	}
+	if err != nil {
+		return err
+	}
+
+	filteredResults := make([]types.CorrelationResult, 0, len(results))
+	for _, result := range results {
+		if result.Score >= threshold {
+			filteredResults = append(filteredResults, result)
+		}
+	}
+
+	var events []types.SnapEvent
+	if len(eventsFiles) > 0 {
+		events, err = readReportEvents(eventsFiles, eventsFormat, configName, verbose)
+		if err != nil {
+			return err
+		}
+	}
+
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Reporting on %d correlation results and %d events\n", len(filteredResults), len(events))
+	}
+
+	built := report.Build(title, filteredResults, events, threshold)
+
+	var buf bytes.Buffer
+	if html {
+		if err := report.Render(&buf, built); err != nil {
+			return err
+		}
+	} else {
+		outputReportTable(&buf, built)
+	}
+
+	if outFile == "" {
+		_, err = os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := os.WriteFile(outFile, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outFile, err)
+	}
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Report written to %s\n", outFile)
+	}
+	return nil
+}
+
+func readReportEvents(eventsFiles []string, eventsFormat, configName string, verbose bool) ([]types.SnapEvent, error) {
+	snapConfig, err := config.NewConfigManager(getConfigPath()).LoadConfig(configName)
+	if err != nil {
+		if configName != "default" {
+			return nil, fmt.Errorf("failed to load config %s: %w", configName, err)
+		}
+		snapConfig = config.DefaultConfig()
+	}
+
+	reader, err := parser.OpenEventFiles(eventsFiles, parser.ReaderOptions{
+		Format:  eventsFormat,
+		Mapping: snapConfig.FieldMapping,
+		OnError: parser.OnErrorSkip,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open events: %w", err)
+	}
+	defer reader.Close()
+
+	var events []types.SnapEvent
+	for {
+		event, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read events: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if skipped := len(reader.Errors()); skipped > 0 && verbose {
+		fmt.Fprintf(os.Stderr, "Skipped %d malformed events\n", skipped)
+	}
+	return events, nil
+}
+
+func outputReportTable(w io.Writer, built *report.Report) {
+	summary := built.Summary
+	fmt.Fprintf(w, "Correlations: %d (%d events, %d commits, %d authors)\n",
+		summary.Correlations, summary.MatchedEvents, summary.CorrelatedCommits, len(summary.Authors))
+	if built.EventsRead > 0 {
+		fmt.Fprintf(w, "Unmatched events: %d of %d (match rate %s)\n", len(built.Unmatched), built.EventsRead, output.Percent(summary.MatchRate))
+	}
+	fmt.Fprintln(w)
+
+	header := fmt.Sprintf("%-40s %8s %8s %8s %13s %10s", "Repository", "Commits", "Events", "Authors", "Correlations", "Mean")
+	fmt.Fprintln(w, header)
+	fmt.Fprintln(w, strings.Repeat("-", len(header)))
+	for _, repository := range built.Repositories {
+		fmt.Fprintf(w, "%-40s %8d %8d %8d %13d %10s\n",
+			truncate(repository.Repository, 40), repository.Commits, repository.Events,
+			repository.Authors, repository.Correlations, output.Percent(repository.MeanScore))
+	}
+}
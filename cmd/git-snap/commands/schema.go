@@ -0,0 +1,19 @@
+package commands
+
+import (
+	"os"
+
+	"github.com/fraser-isbester/git-snap/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+func NewSchemaCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of correlate's envelope output",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := os.Stdout.Write(output.Schema)
+			return err
+		},
+	}
+}
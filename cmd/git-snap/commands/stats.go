@@ -0,0 +1,204 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/correlation"
+	"github.com/fraser-isbester/git-snap/pkg/git"
+	"github.com/fraser-isbester/git-snap/pkg/output"
+	"github.com/fraser-isbester/git-snap/pkg/stats"
+	"github.com/fraser-isbester/git-snap/pkg/store"
+	"github.com/fraser-isbester/git-snap/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+func NewStatsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Report match rate, score distribution and coverage of stored results",
+		Long: `Compute summary statistics of stored correlation results (a results file, a
+run in a correlate --store database, or the git-snap notes written by
+--write-notes): match rate, score and time-delta percentiles, per-rule hit
+rates, per-author and per-repository commit coverage, and ambiguity, i.e.
+events whose best candidates score nearly the same.
+
+Unmatched events and uncorrelated commits are only known when the results file
+was written by correlate --unmatched; otherwise the match rate and coverage
+only count events and commits that appear in the results. A store records how
+many events each run read, so its match rate counts unmatched events too.
+correlate --summary computes the same statistics for a run.`,
+		RunE: runStats,
+	}
+
+	cmd.Flags().StringP("results", "R", "", "Path to stored correlation results (JSON output of correlate)")
+	cmd.Flags().String("store", "", "SQLite database written by correlate --store to read a run from")
+	cmd.Flags().Int64("run", 0, "Run to read from --store; the latest run when 0")
+	cmd.Flags().String("notes-ref", git.DefaultNotesRef, "Notes ref to read correlations from when neither --results nor --store is set")
+	cmd.Flags().StringP("repo", "r", ".", "Path to git repository")
+	cmd.Flags().Float64P("threshold", "t", 0, "Minimum correlation score for a result to count")
+	cmd.Flags().Float64("ambiguity-margin", stats.DefaultAmbiguityMargin, "Score difference under which an event's two best candidates are ambiguous")
+	cmd.Flags().StringP("output", "o", "table", "Output format (json, table)")
+
+	return cmd
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	resultsFile, _ := cmd.Flags().GetString("results")
+	storePath, _ := cmd.Flags().GetString("store")
+	runID, _ := cmd.Flags().GetInt64("run")
+	notesRef, _ := cmd.Flags().GetString("notes-ref")
+	repoPath, _ := cmd.Flags().GetString("repo")
+	threshold, _ := cmd.Flags().GetFloat64("threshold")
+	margin, _ := cmd.Flags().GetFloat64("ambiguity-margin")
+	outputFormat, _ := cmd.Flags().GetString("output")
+
+	if resultsFile != "" && storePath != "" {
+		return fmt.Errorf("--results and --store cannot be combined")
+	}
+
+	var document resultsDocument
+	var events int
+	var err error
+	switch {
+	case resultsFile != "":
+		document, err = loadResultsDocument(resultsFile)
+	case storePath != "":
+		document.Results, events, err = loadStoreRun(storePath, runID)
+	default:
+		repoPath, err = git.FindGitRepository(repoPath)
+		if err != nil {
+			return fmt.Errorf("failed to find git repository: %w", err)
+		}
+		document.Results, err = loadNoteResults(git.NewGitClient(repoPath), notesRef)
+	}
+	if err != nil {
+		return err
+	}
+
+	input := applyThreshold(document.Results, threshold)
+	input.Commits = append(input.Commits, document.UncorrelatedCommits...)
+	input.Unmatched = append(input.Unmatched, document.UnmatchedEvents...)
+	input.Events = events
+
+	summary := stats.Compute(input, margin)
+
+	switch outputFormat {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(summary)
+	case "table":
+		outputStats(os.Stdout, summary)
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format: %s", outputFormat)
+	}
+}
+
+// applyThreshold keeps the results scoring at least threshold. An event is
+// unmatched only when its best result scores below it, and a commit is
+// uncorrelated only when no kept result references it.
+func applyThreshold(results []types.CorrelationResult, threshold float64) stats.Input {
+	input := stats.Input{Candidates: results}
+	best := make(map[string]int)
+	var order []string
+	for i, result := range results {
+		key := result.Event.Key()
+		j, exists := best[key]
+		if !exists {
+			order = append(order, key)
+		}
+		if !exists || result.Score > results[j].Score {
+			best[key] = i
+		}
+		if result.Score >= threshold {
+			input.Results = append(input.Results, result)
+		}
+	}
+
+	for _, key := range order {
+		if result := results[best[key]]; result.Score < threshold {
+			input.Unmatched = append(input.Unmatched, types.UnmatchedEvent{
+				Event:     result.Event,
+				Reason:    correlation.ReasonBelowThreshold,
+				BestScore: result.Score,
+			})
+		}
+	}
+
+	kept := make(map[string]bool)
+	for _, result := range input.Results {
+		kept[result.Commit.SHA] = true
+	}
+	for _, result := range results {
+		if !kept[result.Commit.SHA] {
+			kept[result.Commit.SHA] = true
+			input.Commits = append(input.Commits, result.Commit)
+		}
+	}
+	return input
+}
+
+// loadStoreRun reads a run from a results store, returning its correlations
+// and the number of events it read.
+func loadStoreRun(path string, runID int64) ([]types.CorrelationResult, int, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, 0, fmt.Errorf("failed to open store: %w", err)
+	}
+
+	resultStore, err := store.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resultStore.Close()
+
+	run, results, err := resultStore.ReadRun(runID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return results, run.Events, nil
+}
+
+func outputStats(w io.Writer, summary stats.Stats) {
+	fmt.Fprintf(w, "Events:        %d, %d matched (%s)\n", summary.Events, summary.MatchedEvents, output.Percent(summary.MatchRate))
+	fmt.Fprintf(w, "Commits:       %d, %d correlated (%s)\n", summary.Commits, summary.CorrelatedCommits, output.Percent(summary.CommitCoverage))
+	fmt.Fprintf(w, "Correlations:  %d\n", summary.Correlations)
+	fmt.Fprintf(w, "Ambiguous:     %d events (%s) with candidates within %.2f\n",
+		summary.Ambiguity.Events, output.Percent(summary.Ambiguity.Rate), summary.Ambiguity.Margin)
+
+	fmt.Fprintf(w, "\n%-12s %8s %8s %8s %8s %8s %8s %8s %8s\n", "", "Min", "P25", "P50", "P75", "P90", "P99", "Max", "Mean")
+	score := summary.Score
+	fmt.Fprintf(w, "%-12s %8.3f %8.3f %8.3f %8.3f %8.3f %8.3f %8.3f %8.3f\n", "Score",
+		score.Min, score.P25, score.P50, score.P75, score.P90, score.P99, score.Max, score.Mean)
+	delta := summary.TimeDelta
+	fmt.Fprintf(w, "%-12s %8s %8s %8s %8s %8s %8s %8s %8s\n", "Time delta",
+		seconds(delta.Min), seconds(delta.P25), seconds(delta.P50), seconds(delta.P75),
+		seconds(delta.P90), seconds(delta.P99), seconds(delta.Max), seconds(delta.Mean))
+
+	if len(summary.Rules) > 0 {
+		fmt.Fprintf(w, "\n%-30s %10s %10s %10s\n", "Rule", "Evaluated", "Matched", "Hit rate")
+		fmt.Fprintln(w, strings.Repeat("-", 63))
+		for _, rule := range summary.Rules {
+			fmt.Fprintf(w, "%-30s %10d %10d %10s\n", truncate(rule.Rule, 30), rule.Evaluated, rule.Matched, output.Percent(rule.HitRate))
+		}
+	}
+
+	printCoverage := func(title string, coverages []stats.Coverage) {
+		fmt.Fprintf(w, "\n%-40s %8s %11s %9s %8s\n", title, "Commits", "Correlated", "Coverage", "Events")
+		fmt.Fprintln(w, strings.Repeat("-", 80))
+		for _, c := range coverages {
+			fmt.Fprintf(w, "%-40s %8d %11d %9s %8d\n", truncate(c.Name, 40), c.Commits, c.CorrelatedCommits, output.Percent(c.Coverage), c.Events)
+		}
+	}
+	printCoverage("Author", summary.Authors)
+	printCoverage("Repository", summary.Repositories)
+}
+
+func seconds(s float64) string {
+	return output.Humanize(time.Duration(s * float64(time.Second)))
+}
@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/fraser-isbester/git-snap/pkg/correlation"
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+func TestApplyThreshold(t *testing.T) {
+	kept := types.EnrichedCommit{SHA: "a1"}
+	dropped := types.EnrichedCommit{SHA: "a2"}
+	results := []types.CorrelationResult{
+		{Event: types.SnapEvent{ID: "e1"}, Commit: kept, Score: 0.9},
+		{Event: types.SnapEvent{ID: "e1"}, Commit: dropped, Score: 0.4},
+		{Event: types.SnapEvent{ID: "e2"}, Commit: kept, Score: 0.3},
+		{Event: types.SnapEvent{ID: "e2"}, Commit: dropped, Score: 0.45},
+	}
+
+	input := applyThreshold(results, 0.5)
+
+	if len(input.Results) != 1 || input.Results[0].Event.ID != "e1" {
+		t.Errorf("Expected only e1's best result to be kept, got %+v", input.Results)
+	}
+	if len(input.Unmatched) != 1 {
+		t.Fatalf("Expected only e2 to be unmatched, got %+v", input.Unmatched)
+	}
+	if unmatched := input.Unmatched[0]; unmatched.Event.ID != "e2" || unmatched.Reason != correlation.ReasonBelowThreshold || unmatched.BestScore != 0.45 {
+		t.Errorf("Unexpected unmatched event: %+v", unmatched)
+	}
+	if len(input.Commits) != 1 || input.Commits[0].SHA != "a2" {
+		t.Errorf("Expected only a2 to be uncorrelated, got %+v", input.Commits)
+	}
+	if len(input.Candidates) != len(results) {
+		t.Errorf("Expected all results as ambiguity candidates, got %d", len(input.Candidates))
+	}
+}
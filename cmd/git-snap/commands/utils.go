@@ -1,8 +1,15 @@
 package commands
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/fraser-isbester/git-snap/pkg/config"
+	"github.com/fraser-isbester/git-snap/pkg/output"
+	"github.com/fraser-isbester/git-snap/pkg/types"
 )
 
 func getConfigPath() string {
@@ -12,3 +19,67 @@ func getConfigPath() string {
 	}
 	return filepath.Join(home, ".git-snap", "config")
 }
+
+func loadSnapConfig(configName string) *types.SnapConfig {
+	snapConfig, err := config.NewConfigManager(getConfigPath()).LoadConfig(configName)
+	if err != nil || len(snapConfig.AttributeRules) == 0 {
+		return config.DefaultConfig()
+	}
+	return snapConfig
+}
+
+func loadResults(filename string) ([]types.CorrelationResult, error) {
+	document, err := loadResultsDocument(filename)
+	if err != nil {
+		return nil, err
+	}
+	return document.Results, nil
+}
+
+// resultsDocument is a results file: the JSON output of correlate, which is
+// either a list of results or, with --unmatched or --summary, an object holding
+// them, or its versioned envelope.
+type resultsDocument struct {
+	Results             []types.CorrelationResult `json:"results"`
+	UnmatchedEvents     []types.UnmatchedEvent    `json:"unmatched_events"`
+	UncorrelatedCommits []types.EnrichedCommit    `json:"uncorrelated_commits"`
+}
+
+func loadResultsDocument(filename string) (resultsDocument, error) {
+	var document resultsDocument
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return document, fmt.Errorf("failed to read results file: %w", err)
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var version struct {
+			SchemaVersion int `json:"schema_version"`
+		}
+		if err := json.Unmarshal(data, &version); err != nil {
+			return document, fmt.Errorf("failed to parse results file: %w", err)
+		}
+		if version.SchemaVersion > output.SchemaVersion {
+			return document, fmt.Errorf("results file has schema version %d, newer than the supported %d", version.SchemaVersion, output.SchemaVersion)
+		}
+		if version.SchemaVersion > 0 {
+			var envelope output.Envelope
+			if err := json.Unmarshal(data, &envelope); err != nil {
+				return document, fmt.Errorf("failed to parse results file: %w", err)
+			}
+			document.Results = envelope.Correlations()
+			document.UnmatchedEvents = envelope.Unmatched()
+			document.UncorrelatedCommits = envelope.Uncorrelated()
+			return document, nil
+		}
+		err = json.Unmarshal(data, &document)
+	} else {
+		err = json.Unmarshal(data, &document.Results)
+	}
+	if err != nil {
+		return document, fmt.Errorf("failed to parse results file: %w", err)
+	}
+
+	return document, nil
+}
@@ -23,6 +23,13 @@ system events like AI inference calls, deployments, build events, etc.`,
 	}
 
 	rootCmd.AddCommand(commands.NewCorrelateCommand())
+	rootCmd.AddCommand(commands.NewBlameCommand())
+	rootCmd.AddCommand(commands.NewAnnotateCommand())
+	rootCmd.AddCommand(commands.NewReportCommand())
+	rootCmd.AddCommand(commands.NewStatsCommand())
+	rootCmd.AddCommand(commands.NewSchemaCommand())
+	rootCmd.AddCommand(commands.NewHooksCommand())
+	rootCmd.AddCommand(commands.NewEventsCommand())
 	rootCmd.AddCommand(commands.NewConfigCommand())
 	rootCmd.AddCommand(commands.NewInitCommand())
 	rootCmd.AddCommand(commands.NewVersionCommand())
@@ -0,0 +1,137 @@
+package attribution
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+const UntypedEvent = "untyped"
+
+type LineStats struct {
+	Path        string         `json:"path"`
+	TotalLines  int            `json:"total_lines"`
+	Attributed  int            `json:"attributed_lines"`
+	ByEventType map[string]int `json:"by_event_type"`
+}
+
+type Report struct {
+	EventTypes  []string    `json:"event_types"`
+	Total       LineStats   `json:"total"`
+	Directories []LineStats `json:"directories"`
+	Files       []LineStats `json:"files"`
+}
+
+func newLineStats(p string) *LineStats {
+	return &LineStats{Path: p, ByEventType: make(map[string]int)}
+}
+
+func (s LineStats) Percent(eventType string) float64 {
+	if s.TotalLines == 0 {
+		return 0
+	}
+	return float64(s.ByEventType[eventType]) / float64(s.TotalLines) * 100
+}
+
+func (s LineStats) AttributedPercent() float64 {
+	if s.TotalLines == 0 {
+		return 0
+	}
+	return float64(s.Attributed) / float64(s.TotalLines) * 100
+}
+
+func CommitEventTypes(results []types.CorrelationResult, typeKey string) map[string][]string {
+	seen := make(map[string]map[string]bool)
+
+	for _, result := range results {
+		eventType := UntypedEvent
+		if value, exists := result.Event.Attributes[typeKey]; exists && value != nil {
+			eventType = fmt.Sprintf("%v", value)
+		}
+
+		if seen[result.Commit.SHA] == nil {
+			seen[result.Commit.SHA] = make(map[string]bool)
+		}
+		seen[result.Commit.SHA][eventType] = true
+	}
+
+	commitTypes := make(map[string][]string, len(seen))
+	for sha, typeSet := range seen {
+		commitTypes[sha] = sortedKeys(typeSet)
+	}
+
+	return commitTypes
+}
+
+func BuildReport(blame map[string]map[string]int, commitTypes map[string][]string) *Report {
+	total := newLineStats(".")
+	directories := make(map[string]*LineStats)
+	eventTypes := make(map[string]bool)
+
+	var files []LineStats
+	for file, counts := range blame {
+		stats := newLineStats(file)
+		for sha, lines := range counts {
+			stats.TotalLines += lines
+
+			typesForCommit, correlated := commitTypes[sha]
+			if !correlated {
+				continue
+			}
+
+			stats.Attributed += lines
+			for _, eventType := range typesForCommit {
+				stats.ByEventType[eventType] += lines
+				eventTypes[eventType] = true
+			}
+		}
+		files = append(files, *stats)
+
+		total.add(*stats)
+		for dir := path.Dir(file); dir != "."; dir = path.Dir(dir) {
+			if directories[dir] == nil {
+				directories[dir] = newLineStats(dir)
+			}
+			directories[dir].add(*stats)
+		}
+	}
+
+	report := &Report{
+		EventTypes:  sortedKeys(eventTypes),
+		Total:       *total,
+		Directories: make([]LineStats, 0, len(directories)),
+		Files:       files,
+	}
+
+	for _, stats := range directories {
+		report.Directories = append(report.Directories, *stats)
+	}
+
+	sort.Slice(report.Directories, func(i, j int) bool {
+		return report.Directories[i].Path < report.Directories[j].Path
+	})
+	sort.Slice(report.Files, func(i, j int) bool {
+		return report.Files[i].Path < report.Files[j].Path
+	})
+
+	return report
+}
+
+func (s *LineStats) add(other LineStats) {
+	s.TotalLines += other.TotalLines
+	s.Attributed += other.Attributed
+	for eventType, lines := range other.ByEventType {
+		s.ByEventType[eventType] += lines
+	}
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
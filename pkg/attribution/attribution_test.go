@@ -0,0 +1,89 @@
+package attribution
+
+import (
+	"testing"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+func TestCommitEventTypes(t *testing.T) {
+	results := []types.CorrelationResult{
+		{
+			Event:  types.SnapEvent{Attributes: map[string]interface{}{"request_type": "code_generation"}},
+			Commit: types.EnrichedCommit{SHA: "abc123"},
+		},
+		{
+			Event:  types.SnapEvent{Attributes: map[string]interface{}{"request_type": "debugging"}},
+			Commit: types.EnrichedCommit{SHA: "abc123"},
+		},
+		{
+			Event:  types.SnapEvent{Attributes: map[string]interface{}{"request_type": "code_generation"}},
+			Commit: types.EnrichedCommit{SHA: "abc123"},
+		},
+		{
+			Event:  types.SnapEvent{Attributes: map[string]interface{}{}},
+			Commit: types.EnrichedCommit{SHA: "def456"},
+		},
+	}
+
+	commitTypes := CommitEventTypes(results, "request_type")
+
+	if len(commitTypes["abc123"]) != 2 {
+		t.Errorf("Expected 2 event types for abc123, got %v", commitTypes["abc123"])
+	}
+
+	if commitTypes["abc123"][0] != "code_generation" || commitTypes["abc123"][1] != "debugging" {
+		t.Errorf("Expected sorted event types, got %v", commitTypes["abc123"])
+	}
+
+	if len(commitTypes["def456"]) != 1 || commitTypes["def456"][0] != UntypedEvent {
+		t.Errorf("Expected untyped event for def456, got %v", commitTypes["def456"])
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	blame := map[string]map[string]int{
+		"pkg/server/handler.go": {"abc123": 30, "def456": 10},
+		"pkg/server/routes.go":  {"def456": 20},
+		"main.go":               {"abc123": 5, "ghi789": 15},
+	}
+
+	commitTypes := map[string][]string{
+		"abc123": {"code_generation"},
+		"ghi789": {"code_generation", "debugging"},
+	}
+
+	report := BuildReport(blame, commitTypes)
+
+	if report.Total.TotalLines != 80 {
+		t.Errorf("Expected 80 total lines, got %d", report.Total.TotalLines)
+	}
+
+	if report.Total.Attributed != 50 {
+		t.Errorf("Expected 50 attributed lines, got %d", report.Total.Attributed)
+	}
+
+	if len(report.Files) != 3 || report.Files[0].Path != "main.go" {
+		t.Fatalf("Expected 3 files sorted by path, got %+v", report.Files)
+	}
+
+	if report.Files[0].Percent("debugging") != 75 {
+		t.Errorf("Expected 75%% debugging in main.go, got %f", report.Files[0].Percent("debugging"))
+	}
+
+	if len(report.Directories) != 2 {
+		t.Fatalf("Expected 2 directories, got %d", len(report.Directories))
+	}
+
+	if report.Directories[0].Path != "pkg" || report.Directories[0].TotalLines != 60 {
+		t.Errorf("Expected pkg directory with 60 lines, got %+v", report.Directories[0])
+	}
+
+	if report.Directories[1].Percent("code_generation") != 50 {
+		t.Errorf("Expected 50%% code_generation in pkg/server, got %f", report.Directories[1].Percent("code_generation"))
+	}
+
+	if len(report.EventTypes) != 2 {
+		t.Errorf("Expected 2 event types, got %v", report.EventTypes)
+	}
+}
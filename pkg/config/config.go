@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"time"
 
 	"github.com/fraser-isbester/git-snap/pkg/types"
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 )
 
@@ -20,7 +22,8 @@ func NewConfigManager(configPath string) *ConfigManager {
 }
 
 func (cm *ConfigManager) LoadConfig(name string) (*types.SnapConfig, error) {
-	v := viper.New()
+	// Field names in mappings may contain dots, so don't treat them as nesting.
+	v := viper.NewWithOptions(viper.KeyDelimiter("::"))
 	v.SetConfigName(name)
 	v.SetConfigType("yaml")
 	v.AddConfigPath(cm.configPath)
@@ -32,7 +35,7 @@ func (cm *ConfigManager) LoadConfig(name string) (*types.SnapConfig, error) {
 	}
 
 	var config types.SnapConfig
-	if err := v.Unmarshal(&config); err != nil {
+	if err := v.Unmarshal(&config, yamlTags); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
@@ -48,7 +51,7 @@ func (cm *ConfigManager) LoadConfig(name string) (*types.SnapConfig, error) {
 }
 
 func (cm *ConfigManager) SaveConfig(name string, config *types.SnapConfig) error {
-	v := viper.New()
+	v := viper.NewWithOptions(viper.KeyDelimiter("::"))
 	v.SetConfigName(name)
 	v.SetConfigType("yaml")
 	v.AddConfigPath(cm.configPath)
@@ -56,6 +59,15 @@ func (cm *ConfigManager) SaveConfig(name string, config *types.SnapConfig) error
 	v.Set("time_window", config.TimeWindow.String())
 	v.Set("attribute_rules", config.AttributeRules)
 	v.Set("score_weights", config.ScoreWeights)
+	if config.HunkMatching != (types.HunkMatchConfig{}) {
+		v.Set("hunk_matching", config.HunkMatching)
+	}
+	if config.FieldMapping != nil {
+		v.Set("field_mapping", config.FieldMapping)
+	}
+	if config.Schema != nil {
+		v.Set("schema", config.Schema)
+	}
 
 	configFile := filepath.Join(cm.configPath, name+".yaml")
 	return v.WriteConfigAs(configFile)
@@ -171,6 +183,21 @@ func (cm *ConfigManager) CreateDefaultConfigs() error {
 	return nil
 }
 
+func yamlTags(dc *mapstructure.DecoderConfig) {
+	dc.TagName = "yaml"
+	dc.DecodeHook = mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		stringToMatchTypeHook,
+	)
+}
+
+func stringToMatchTypeHook(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() != reflect.String || to != reflect.TypeOf(types.MatchType(0)) {
+		return data, nil
+	}
+	return types.ParseMatchType(data.(string)), nil
+}
+
 func init() {
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.AutomaticEnv()
@@ -123,3 +123,50 @@ func TestConfigManager_NewConfigManager(t *testing.T) {
 		t.Errorf("Expected config path to be %s, got %s", configPath, cm.configPath)
 	}
 }
+
+func TestConfigManager_SaveAndLoadConfig(t *testing.T) {
+	cm := NewConfigManager(t.TempDir())
+
+	original := AIInferenceConfig()
+	original.FieldMapping = &types.FieldMapping{
+		ID:        "request_id",
+		Timestamp: types.TimestampMapping{Field: "created_at", Timezone: "UTC"},
+		Rename:    map[string]string{"user.email": "user_id"},
+	}
+	minTokens := 1.0
+	original.Schema = &types.EventSchema{
+		RequireID: true,
+		Attributes: map[string]types.FieldSchema{
+			"tokens": {Type: "integer", Required: true, Min: &minTokens},
+		},
+	}
+
+	if err := cm.SaveConfig("vendor", original); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	loaded, err := cm.LoadConfig("vendor")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if loaded.TimeWindow != original.TimeWindow {
+		t.Errorf("Expected time window %v, got %v", original.TimeWindow, loaded.TimeWindow)
+	}
+
+	if len(loaded.AttributeRules) != 2 || loaded.AttributeRules[1].MatchType != types.CONTAINS {
+		t.Errorf("Expected attribute rules to round trip, got %+v", loaded.AttributeRules)
+	}
+
+	if loaded.FieldMapping == nil || loaded.FieldMapping.Rename["user.email"] != "user_id" {
+		t.Errorf("Expected field mapping to round trip, got %+v", loaded.FieldMapping)
+	}
+
+	if loaded.Schema == nil || !loaded.Schema.RequireID {
+		t.Fatalf("Expected schema to round trip, got %+v", loaded.Schema)
+	}
+	tokens := loaded.Schema.Attributes["tokens"]
+	if tokens.Type != "integer" || !tokens.Required || tokens.Min == nil || *tokens.Min != 1 {
+		t.Errorf("Expected tokens schema to round trip, got %+v", tokens)
+	}
+}
@@ -2,6 +2,7 @@ package correlation
 
 import (
 	"fmt"
+	"io"
 	"math"
 	"regexp"
 	"strconv"
@@ -13,35 +14,91 @@ import (
 
 type CorrelationEngine struct {
 	config types.SnapConfig
+
+	trackUnmatched bool
+	unmatched      []types.UnmatchedEvent
+	candidates     map[string]int
 }
 
 func NewCorrelationEngine(config types.SnapConfig) *CorrelationEngine {
 	return &CorrelationEngine{config: config}
 }
 
+type EventSource interface {
+	Read() (types.SnapEvent, error)
+}
+
 func (e *CorrelationEngine) SnapToCommits(events []types.SnapEvent, commits []types.EnrichedCommit) []types.CorrelationResult {
 	var results []types.CorrelationResult
 
 	for _, event := range events {
-		for _, commit := range commits {
-			if !e.isWithinTimeWindow(event, commit) {
-				continue
-			}
+		results = append(results, e.snapEvent(event, commits)...)
+	}
+
+	return e.sortAndFilterResults(results)
+}
+
+// SnapStream correlates events as they are read from source, so only events
+// that produce a correlation are held in memory, unless unmatched events are
+// being tracked.
+func (e *CorrelationEngine) SnapStream(source EventSource, commits []types.EnrichedCommit) ([]types.CorrelationResult, error) {
+	var results []types.CorrelationResult
+
+	for {
+		event, err := source.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, e.snapEvent(event, commits)...)
+	}
 
-			matches, score := e.calculateCorrelation(event, commit)
-			if score > 0 {
-				results = append(results, types.CorrelationResult{
-					Event:     event,
-					Commit:    commit,
-					Score:     score,
-					Matches:   matches,
-					TimeDelta: e.calculateTimeDelta(event, commit),
-				})
+	return e.sortAndFilterResults(results), nil
+}
+
+func (e *CorrelationEngine) snapEvent(event types.SnapEvent, commits []types.EnrichedCommit) []types.CorrelationResult {
+	var results []types.CorrelationResult
+	candidates := 0
+	failedRules := make(map[string]bool)
+
+	for _, commit := range commits {
+		if !e.isWithinTimeWindow(event, commit) {
+			continue
+		}
+		candidates++
+
+		matches, score := e.calculateCorrelation(event, commit)
+		if score == 0 && e.trackUnmatched {
+			for _, rule := range e.config.AttributeRules {
+				if rule.Required && !matches[rule.EventKey] {
+					failedRules[rule.EventKey] = true
+				}
+			}
+		}
+		if score > 0 {
+			result := types.CorrelationResult{
+				Event:     event,
+				Commit:    commit,
+				Score:     score,
+				Matches:   matches,
+				TimeDelta: e.calculateTimeDelta(event, commit),
 			}
+			e.applyHunkMatches(&result)
+			results = append(results, result)
 		}
 	}
 
-	return e.sortAndFilterResults(results)
+	if e.trackUnmatched {
+		if len(results) == 0 {
+			e.recordUnmatched(event, candidates, failedRules)
+		} else {
+			e.candidates[event.Key()] = candidates
+		}
+	}
+
+	return results
 }
 
 func (e *CorrelationEngine) isWithinTimeWindow(event types.SnapEvent, commit types.EnrichedCommit) bool {
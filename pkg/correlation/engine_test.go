@@ -1,6 +1,7 @@
 package correlation
 
 import (
+	"io"
 	"testing"
 	"time"
 
@@ -221,3 +222,48 @@ func TestCorrelationEngine_FuzzyMatch(t *testing.T) {
 		}
 	}
 }
+
+type sliceSource struct {
+	events []types.SnapEvent
+}
+
+func (s *sliceSource) Read() (types.SnapEvent, error) {
+	if len(s.events) == 0 {
+		return types.SnapEvent{}, io.EOF
+	}
+	event := s.events[0]
+	s.events = s.events[1:]
+	return event, nil
+}
+
+func TestCorrelationEngine_SnapStream(t *testing.T) {
+	engine := NewCorrelationEngine(types.SnapConfig{
+		TimeWindow: 30 * time.Minute,
+		AttributeRules: []types.AttributeRule{
+			{EventKey: "user_id", CommitKey: "author_email", MatchType: types.EXACT, Required: true},
+		},
+	})
+
+	baseTime := time.Now()
+	events := []types.SnapEvent{
+		{ID: "event1", Timestamp: baseTime, Attributes: map[string]interface{}{"user_id": "john.doe@example.com"}},
+		{ID: "event2", Timestamp: baseTime, Attributes: map[string]interface{}{"user_id": "nobody@example.com"}},
+	}
+	commits := []types.EnrichedCommit{
+		{SHA: "abc123", AuthorEmail: "john.doe@example.com", Timestamp: baseTime.Add(5 * time.Minute)},
+	}
+
+	results, err := engine.SnapStream(&sliceSource{events: events}, commits)
+	if err != nil {
+		t.Fatalf("SnapStream returned error: %v", err)
+	}
+
+	expected := engine.SnapToCommits(events, commits)
+	if len(results) != len(expected) || len(results) != 1 {
+		t.Fatalf("Expected 1 result matching SnapToCommits, got %d", len(results))
+	}
+
+	if results[0].Event.ID != "event1" {
+		t.Errorf("Expected event1 to correlate, got %s", results[0].Event.ID)
+	}
+}
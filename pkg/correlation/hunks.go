@@ -0,0 +1,161 @@
+package correlation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+const (
+	defaultFileKey    = "file_path"
+	defaultSnippetKey = "code_snippet"
+	defaultHashKey    = "content_hash"
+)
+
+func (e *CorrelationEngine) applyHunkMatches(result *types.CorrelationResult) {
+	if len(result.Commit.Hunks) == 0 {
+		return
+	}
+
+	matches := e.matchHunks(result.Event, result.Commit)
+	if len(matches) == 0 {
+		return
+	}
+
+	attributed := 0
+	for _, hunk := range result.Commit.Hunks {
+		if containsHunk(matches, hunk) {
+			attributed += len(hunk.AddedLines)
+		}
+	}
+
+	result.HunkMatches = matches
+	result.AttributedLines = attributed
+	if total := addedLineCount(result.Commit.Hunks); total > 0 {
+		result.LineAttribution = float64(attributed) / float64(total)
+	}
+}
+
+func (e *CorrelationEngine) matchHunks(event types.SnapEvent, commit types.EnrichedCommit) []types.HunkMatch {
+	filePath := e.getEventValue(event, e.hunkKey(e.config.HunkMatching.FileKey, defaultFileKey))
+	if filePath == "" {
+		return nil
+	}
+
+	snippet := normalizeCode(e.getEventValue(event, e.hunkKey(e.config.HunkMatching.SnippetKey, defaultSnippetKey)))
+	hash := normalizeHash(e.getEventValue(event, e.hunkKey(e.config.HunkMatching.HashKey, defaultHashKey)))
+	if snippet == "" && hash == "" {
+		return nil
+	}
+
+	var matches []types.HunkMatch
+	for _, hunk := range commit.Hunks {
+		if len(hunk.AddedLines) == 0 || !pathsMatch(filePath, hunk.File) {
+			continue
+		}
+
+		matchedBy := ""
+		if hash != "" && hashHunk(hunk) == hash {
+			matchedBy = "hash"
+		} else if snippet != "" && strings.Contains(normalizeCode(strings.Join(hunk.AddedLines, "\n")), snippet) {
+			matchedBy = "snippet"
+		}
+
+		if matchedBy != "" {
+			matches = append(matches, types.HunkMatch{
+				File:      hunk.File,
+				NewStart:  hunk.NewStart,
+				NewLines:  hunk.NewLines,
+				MatchedBy: matchedBy,
+			})
+		}
+	}
+
+	return matches
+}
+
+func (e *CorrelationEngine) hunkKey(configured, fallback string) string {
+	if configured != "" {
+		return configured
+	}
+	return fallback
+}
+
+func CommitLineAttribution(results []types.CorrelationResult) map[string]float64 {
+	matched := make(map[string][]types.HunkMatch)
+	commits := make(map[string]types.EnrichedCommit)
+
+	for _, result := range results {
+		if len(result.HunkMatches) == 0 {
+			continue
+		}
+		commits[result.Commit.SHA] = result.Commit
+		matched[result.Commit.SHA] = append(matched[result.Commit.SHA], result.HunkMatches...)
+	}
+
+	attribution := make(map[string]float64)
+	for sha, commit := range commits {
+		total := addedLineCount(commit.Hunks)
+		if total == 0 {
+			continue
+		}
+
+		attributed := 0
+		for _, hunk := range commit.Hunks {
+			if containsHunk(matched[sha], hunk) {
+				attributed += len(hunk.AddedLines)
+			}
+		}
+		attribution[sha] = float64(attributed) / float64(total)
+	}
+
+	return attribution
+}
+
+func containsHunk(matches []types.HunkMatch, hunk types.DiffHunk) bool {
+	for _, match := range matches {
+		if match.File == hunk.File && match.NewStart == hunk.NewStart && match.NewLines == hunk.NewLines {
+			return true
+		}
+	}
+	return false
+}
+
+func addedLineCount(hunks []types.DiffHunk) int {
+	total := 0
+	for _, hunk := range hunks {
+		total += len(hunk.AddedLines)
+	}
+	return total
+}
+
+func pathsMatch(eventPath, hunkPath string) bool {
+	eventPath = strings.TrimPrefix(strings.ReplaceAll(eventPath, "\\", "/"), "./")
+	if eventPath == hunkPath {
+		return true
+	}
+	return strings.HasSuffix(eventPath, "/"+hunkPath) || strings.HasSuffix(hunkPath, "/"+eventPath)
+}
+
+func hashHunk(hunk types.DiffHunk) string {
+	sum := sha256.Sum256([]byte(strings.Join(hunk.AddedLines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeHash(hash string) string {
+	hash = strings.ToLower(strings.TrimSpace(hash))
+	return strings.TrimPrefix(hash, "sha256:")
+}
+
+func normalizeCode(code string) string {
+	var lines []string
+	for _, line := range strings.Split(code, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
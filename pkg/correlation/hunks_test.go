@@ -0,0 +1,109 @@
+package correlation
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+func hunkTestCommit(baseTime time.Time) types.EnrichedCommit {
+	return types.EnrichedCommit{
+		SHA:         "abc123",
+		AuthorEmail: "john.doe@example.com",
+		Timestamp:   baseTime,
+		Hunks: []types.DiffHunk{
+			{
+				File:       "pkg/server/handler.go",
+				NewStart:   10,
+				NewLines:   3,
+				AddedLines: []string{"func handle() {", "\treturn nil", "}"},
+			},
+			{
+				File:       "pkg/server/routes.go",
+				NewStart:   1,
+				NewLines:   1,
+				AddedLines: []string{"package server"},
+			},
+		},
+	}
+}
+
+func TestCorrelationEngine_HunkMatchesBySnippet(t *testing.T) {
+	engine := NewCorrelationEngine(types.SnapConfig{TimeWindow: 30 * time.Minute})
+
+	baseTime := time.Now()
+	event := types.SnapEvent{
+		ID:        "event1",
+		Timestamp: baseTime,
+		Attributes: map[string]interface{}{
+			"file_path":    "/home/john/repo/pkg/server/handler.go",
+			"code_snippet": "  return nil\n}",
+		},
+	}
+
+	results := engine.SnapToCommits([]types.SnapEvent{event}, []types.EnrichedCommit{hunkTestCommit(baseTime)})
+
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 correlation result, got %d", len(results))
+	}
+
+	if len(results[0].HunkMatches) != 1 {
+		t.Fatalf("Expected 1 hunk match, got %d", len(results[0].HunkMatches))
+	}
+
+	if results[0].HunkMatches[0].MatchedBy != "snippet" {
+		t.Errorf("Expected match by snippet, got %s", results[0].HunkMatches[0].MatchedBy)
+	}
+
+	if results[0].AttributedLines != 3 {
+		t.Errorf("Expected 3 attributed lines, got %d", results[0].AttributedLines)
+	}
+
+	if math.Abs(results[0].LineAttribution-0.75) > 1e-9 {
+		t.Errorf("Expected line attribution to be 0.75, got %f", results[0].LineAttribution)
+	}
+}
+
+func TestCorrelationEngine_HunkMatchesByHash(t *testing.T) {
+	engine := NewCorrelationEngine(types.SnapConfig{TimeWindow: 30 * time.Minute})
+
+	baseTime := time.Now()
+	commit := hunkTestCommit(baseTime)
+	event := types.SnapEvent{
+		ID:        "event1",
+		Timestamp: baseTime,
+		Attributes: map[string]interface{}{
+			"file_path":    "pkg/server/routes.go",
+			"content_hash": "sha256:" + hashHunk(commit.Hunks[1]),
+		},
+	}
+
+	matches := engine.matchHunks(event, commit)
+	if len(matches) != 1 || matches[0].MatchedBy != "hash" {
+		t.Fatalf("Expected 1 hash match, got %+v", matches)
+	}
+
+	attribution := CommitLineAttribution([]types.CorrelationResult{
+		{Commit: commit, HunkMatches: matches},
+	})
+
+	if math.Abs(attribution["abc123"]-0.25) > 1e-9 {
+		t.Errorf("Expected commit attribution to be 0.25, got %f", attribution["abc123"])
+	}
+}
+
+func TestCorrelationEngine_HunkMatchesRequireFilePath(t *testing.T) {
+	engine := NewCorrelationEngine(types.SnapConfig{TimeWindow: 30 * time.Minute})
+
+	event := types.SnapEvent{
+		Attributes: map[string]interface{}{
+			"code_snippet": "return nil",
+		},
+	}
+
+	if matches := engine.matchHunks(event, hunkTestCommit(time.Now())); len(matches) != 0 {
+		t.Errorf("Expected no hunk matches without a file path, got %d", len(matches))
+	}
+}
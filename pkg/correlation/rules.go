@@ -0,0 +1,98 @@
+package correlation
+
+import (
+	"fmt"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+var CommitKeys = []string{
+	"sha", "author", "author_email", "committer", "message", "repository",
+	"branch", "pr_number", "additions", "deletions", "files",
+}
+
+type RuleIssue struct {
+	Rule          types.AttributeRule
+	Reason        string
+	Unsatisfiable bool
+}
+
+// KeyCoverage counts how many events carry a non-empty value for each
+// attribute key.
+type KeyCoverage struct {
+	Events int
+	Keys   map[string]int
+}
+
+func NewKeyCoverage() *KeyCoverage {
+	return &KeyCoverage{Keys: make(map[string]int)}
+}
+
+func (c *KeyCoverage) Add(event types.SnapEvent) {
+	c.Events++
+	for key, value := range event.Attributes {
+		if value != nil && fmt.Sprintf("%v", value) != "" {
+			c.Keys[key]++
+		}
+	}
+}
+
+// CheckRules reports attribute rules that can never match, either because of
+// the config itself or because no event carries the key, and required rules
+// that some events cannot satisfy. coverage may be nil to check the config
+// alone.
+func CheckRules(config types.SnapConfig, coverage *KeyCoverage) []RuleIssue {
+	var issues []RuleIssue
+
+	for _, rule := range config.AttributeRules {
+		if !isCommitKey(rule.CommitKey) {
+			issues = append(issues, RuleIssue{
+				Rule:          rule,
+				Reason:        fmt.Sprintf("commit key %s is not a commit field", rule.CommitKey),
+				Unsatisfiable: true,
+			})
+			continue
+		}
+
+		if schema := config.Schema; schema != nil && schema.Strict {
+			if _, declared := schema.Attributes[rule.EventKey]; !declared {
+				issues = append(issues, RuleIssue{
+					Rule:          rule,
+					Reason:        fmt.Sprintf("event key %s is not declared in the strict schema", rule.EventKey),
+					Unsatisfiable: true,
+				})
+				continue
+			}
+		}
+
+		if coverage == nil || coverage.Events == 0 {
+			continue
+		}
+
+		present := coverage.Keys[rule.EventKey]
+		switch {
+		case present == 0:
+			issues = append(issues, RuleIssue{
+				Rule:          rule,
+				Reason:        fmt.Sprintf("event key %s is missing from all %d events", rule.EventKey, coverage.Events),
+				Unsatisfiable: true,
+			})
+		case rule.Required && present < coverage.Events:
+			issues = append(issues, RuleIssue{
+				Rule:   rule,
+				Reason: fmt.Sprintf("event key %s is missing from %d of %d events, which can never correlate", rule.EventKey, coverage.Events-present, coverage.Events),
+			})
+		}
+	}
+
+	return issues
+}
+
+func isCommitKey(key string) bool {
+	for _, commitKey := range CommitKeys {
+		if key == commitKey {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package correlation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+func TestCheckRules(t *testing.T) {
+	config := types.SnapConfig{
+		AttributeRules: []types.AttributeRule{
+			{EventKey: "user_id", CommitKey: "author_email", Required: true},
+			{EventKey: "project", CommitKey: "repo"},
+			{EventKey: "ticket", CommitKey: "message"},
+			{EventKey: "branch", CommitKey: "branch"},
+		},
+		Schema: &types.EventSchema{
+			Strict: true,
+			Attributes: map[string]types.FieldSchema{
+				"user_id": {Type: "string"},
+				"project": {Type: "string"},
+				"branch":  {Type: "string"},
+			},
+		},
+	}
+
+	coverage := NewKeyCoverage()
+	coverage.Add(types.SnapEvent{Attributes: map[string]interface{}{"user_id": "john.doe"}})
+	coverage.Add(types.SnapEvent{Attributes: map[string]interface{}{"user_id": ""}})
+
+	issues := CheckRules(config, coverage)
+	if len(issues) != 4 {
+		t.Fatalf("Expected 4 issues, got %+v", issues)
+	}
+
+	expected := []struct {
+		eventKey      string
+		reason        string
+		unsatisfiable bool
+	}{
+		{"user_id", "missing from 1 of 2 events", false},
+		{"project", "commit key repo is not a commit field", true},
+		{"ticket", "not declared in the strict schema", true},
+		{"branch", "missing from all 2 events", true},
+	}
+
+	for i, exp := range expected {
+		issue := issues[i]
+		if issue.Rule.EventKey != exp.eventKey || issue.Unsatisfiable != exp.unsatisfiable || !strings.Contains(issue.Reason, exp.reason) {
+			t.Errorf("Issue %d: expected %s (%q, unsatisfiable %t), got %+v", i, exp.eventKey, exp.reason, exp.unsatisfiable, issue)
+		}
+	}
+}
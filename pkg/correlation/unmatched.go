@@ -0,0 +1,96 @@
+package correlation
+
+import (
+	"sort"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+// Reasons an event did not correlate.
+const (
+	ReasonNoCommitInWindow   = "no_commit_in_window"
+	ReasonRequiredRuleFailed = "required_rule_failed"
+	ReasonBelowThreshold     = "below_threshold"
+)
+
+// SetTrackUnmatched makes the engine remember events that produce no
+// correlation, which otherwise are discarded as they are read.
+func (e *CorrelationEngine) SetTrackUnmatched(enabled bool) {
+	e.trackUnmatched = enabled
+	if enabled && e.candidates == nil {
+		e.candidates = make(map[string]int)
+	}
+}
+
+// Unmatched returns the events seen since tracking was enabled that have no
+// result scoring at least threshold, ordered by timestamp. results are the
+// engine's results before any threshold was applied.
+func (e *CorrelationEngine) Unmatched(results []types.CorrelationResult, threshold float64) []types.UnmatchedEvent {
+	unmatched := append([]types.UnmatchedEvent(nil), e.unmatched...)
+
+	best := make(map[string]int)
+	for i, result := range results {
+		key := result.Event.Key()
+		if j, exists := best[key]; !exists || result.Score > results[j].Score {
+			best[key] = i
+		}
+	}
+	for key, i := range best {
+		if results[i].Score >= threshold {
+			continue
+		}
+		unmatched = append(unmatched, types.UnmatchedEvent{
+			Event:      results[i].Event,
+			Reason:     ReasonBelowThreshold,
+			Candidates: e.candidates[key],
+			BestScore:  results[i].Score,
+		})
+	}
+
+	sort.SliceStable(unmatched, func(i, j int) bool {
+		if !unmatched[i].Event.Timestamp.Equal(unmatched[j].Event.Timestamp) {
+			return unmatched[i].Event.Timestamp.Before(unmatched[j].Event.Timestamp)
+		}
+		return unmatched[i].Event.Key() < unmatched[j].Event.Key()
+	})
+	return unmatched
+}
+
+func (e *CorrelationEngine) recordUnmatched(event types.SnapEvent, candidates int, failedRules map[string]bool) {
+	reason := ReasonNoCommitInWindow
+	if candidates > 0 {
+		reason = ReasonRequiredRuleFailed
+		if len(failedRules) == 0 {
+			reason = ReasonBelowThreshold
+		}
+	}
+
+	var rules []string
+	for rule := range failedRules {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+
+	e.unmatched = append(e.unmatched, types.UnmatchedEvent{
+		Event:       event,
+		Reason:      reason,
+		Candidates:  candidates,
+		FailedRules: rules,
+	})
+}
+
+// UncorrelatedCommits returns the commits that appear in no result.
+func UncorrelatedCommits(commits []types.EnrichedCommit, results []types.CorrelationResult) []types.EnrichedCommit {
+	correlated := make(map[string]bool, len(results))
+	for _, result := range results {
+		correlated[result.Commit.SHA] = true
+	}
+
+	var uncorrelated []types.EnrichedCommit
+	for _, commit := range commits {
+		if !correlated[commit.SHA] {
+			uncorrelated = append(uncorrelated, commit)
+		}
+	}
+	return uncorrelated
+}
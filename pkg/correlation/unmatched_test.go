@@ -0,0 +1,74 @@
+package correlation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+func TestCorrelationEngine_Unmatched(t *testing.T) {
+	config := types.SnapConfig{
+		TimeWindow: 30 * time.Minute,
+		AttributeRules: []types.AttributeRule{
+			{EventKey: "user_id", CommitKey: "author_email", MatchType: types.EXACT, Required: true},
+			{EventKey: "repository", CommitKey: "repository", MatchType: types.EXACT},
+		},
+	}
+
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	commits := []types.EnrichedCommit{
+		{SHA: "abc123", AuthorEmail: "john@example.com", Timestamp: base},
+		{SHA: "def456", AuthorEmail: "jane@example.com", Timestamp: base.Add(time.Minute)},
+		{SHA: "789abc", AuthorEmail: "john@example.com", Timestamp: base.Add(5 * time.Hour)},
+	}
+	events := []types.SnapEvent{
+		{ID: "matched", Timestamp: base.Add(time.Minute), Attributes: map[string]interface{}{"user_id": "john@example.com"}},
+		{ID: "weak", Timestamp: base.Add(-29 * time.Minute), Attributes: map[string]interface{}{"user_id": "john@example.com"}},
+		{ID: "stranger", Timestamp: base, Attributes: map[string]interface{}{"user_id": "bob@example.com"}},
+		{ID: "late", Timestamp: base.Add(2 * time.Hour), Attributes: map[string]interface{}{"user_id": "john@example.com"}},
+	}
+
+	engine := NewCorrelationEngine(config)
+	engine.SetTrackUnmatched(true)
+	results := engine.SnapToCommits(events, commits)
+
+	unmatched := engine.Unmatched(results, 0.5)
+	if len(unmatched) != 3 {
+		t.Fatalf("Expected 3 unmatched events, got %+v", unmatched)
+	}
+
+	expected := []struct {
+		id         string
+		reason     string
+		candidates int
+	}{
+		{"weak", ReasonBelowThreshold, 2},
+		{"stranger", ReasonRequiredRuleFailed, 2},
+		{"late", ReasonNoCommitInWindow, 0},
+	}
+	for i, want := range expected {
+		got := unmatched[i]
+		if got.Event.ID != want.id || got.Reason != want.reason || got.Candidates != want.candidates {
+			t.Errorf("Expected %s to be unmatched with %s and %d candidates, got %s with %s and %d",
+				want.id, want.reason, want.candidates, got.Event.ID, got.Reason, got.Candidates)
+		}
+	}
+	if rules := unmatched[1].FailedRules; len(rules) != 1 || rules[0] != "user_id" {
+		t.Errorf("Expected user_id to be the failed rule, got %v", rules)
+	}
+	if unmatched[0].BestScore == 0 || unmatched[0].BestScore >= 0.5 {
+		t.Errorf("Expected a best score below the threshold, got %f", unmatched[0].BestScore)
+	}
+
+	var kept []types.CorrelationResult
+	for _, result := range results {
+		if result.Score >= 0.5 {
+			kept = append(kept, result)
+		}
+	}
+	uncorrelated := UncorrelatedCommits(commits, kept)
+	if len(uncorrelated) != 2 || uncorrelated[0].SHA != "def456" || uncorrelated[1].SHA != "789abc" {
+		t.Errorf("Expected def456 and 789abc to be uncorrelated, got %+v", uncorrelated)
+	}
+}
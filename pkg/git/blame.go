@@ -0,0 +1,92 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var blameHeaderRe = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64}) \d+ \d+`)
+
+// ListFiles lists the tracked files under paths, which are relative to the
+// current directory like other command-line paths. The files returned are
+// relative to the repository root.
+func (g *GitClient) ListFiles(paths []string) ([]string, error) {
+	args := []string{"ls-files", "-z", "--"}
+	for _, path := range paths {
+		relative, err := repoRelative(g.repoPath, path)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, relative)
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	var files []string
+	for _, file := range strings.Split(string(output), "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+
+	return files, nil
+}
+
+func repoRelative(root, path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(absPath); err == nil {
+		absPath = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+
+	relative, err := filepath.Rel(root, absPath)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside repository %s", path, root)
+	}
+	return filepath.ToSlash(relative), nil
+}
+
+func (g *GitClient) BlameLineCounts(path string) (map[string]int, error) {
+	cmd := exec.Command("git", "blame", "--line-porcelain", "--", path)
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to blame %s: %w", path, err)
+	}
+
+	return parseBlameLineCounts(string(output)), nil
+}
+
+func parseBlameLineCounts(output string) map[string]int {
+	counts := make(map[string]int)
+
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "\t") {
+			continue
+		}
+
+		matches := blameHeaderRe.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+
+		counts[matches[1]]++
+	}
+
+	return counts
+}
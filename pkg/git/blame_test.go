@@ -0,0 +1,67 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseBlameLineCounts(t *testing.T) {
+	first := "1f0c2a8e4b6d8f0a2c4e6a8b0d2f4a6c8e0b2d4f"
+	second := "9e8d7c6b5a49382716059f8e7d6c5b4a39281706"
+	output := first + ` 1 1 2
+author John Doe
+author-mail <john@example.com>
+filename main.go
+	package main
+` + first + ` 2 2
+author John Doe
+filename main.go
+	
+` + second + ` 3 3 1
+author Jane Smith
+summary ` + first + ` 1 1 1
+filename main.go
+	` + second + ` 4 4 1
+`
+
+	counts := parseBlameLineCounts(output)
+
+	if len(counts) != 2 || counts[first] != 2 || counts[second] != 1 {
+		t.Errorf("Expected 2 lines from %s and 1 from %s, got %v", first, second, counts)
+	}
+}
+
+func TestRepoRelative(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(sub); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, expected := range map[string]string{
+		"file.go":                            "sub/file.go",
+		"./file.go":                          "sub/file.go",
+		".":                                  "sub",
+		"..":                                 ".",
+		filepath.Join(root, "other", "x.go"): "other/x.go",
+	} {
+		relative, err := repoRelative(root, path)
+		if err != nil || relative != expected {
+			t.Errorf("Expected %s to resolve to %s, got %q (%v)", path, expected, relative, err)
+		}
+	}
+
+	if _, err := repoRelative(root, "../.."); err == nil {
+		t.Error("Expected a path outside the repository to be rejected")
+	}
+}
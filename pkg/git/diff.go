@@ -0,0 +1,143 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+func (g *GitClient) SetLoadHunks(enabled bool) {
+	g.loadHunks = enabled
+}
+
+func (g *GitClient) GetCommitHunks(sha string) ([]types.DiffHunk, error) {
+	args := []string{
+		"diff-tree",
+		"-p",
+		"--unified=0",
+		"--no-color",
+		"--no-ext-diff",
+		"--no-commit-id",
+		"--root",
+		sha,
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get diff for %s: %w", sha, err)
+	}
+
+	return parseUnifiedDiff(string(output)), nil
+}
+
+func (g *GitClient) attachHunks(commits []types.EnrichedCommit) ([]types.EnrichedCommit, error) {
+	if !g.loadHunks {
+		return commits, nil
+	}
+
+	for i := range commits {
+		hunks, err := g.GetCommitHunks(commits[i].SHA)
+		if err != nil {
+			return nil, err
+		}
+		commits[i].Hunks = hunks
+	}
+
+	return commits, nil
+}
+
+// parseUnifiedDiff collects the hunks of a unified diff. Hunk bodies are
+// consumed by the line counts of their @@ header, so an added line that
+// itself starts with "++ " or "-- " is not mistaken for a file header.
+func parseUnifiedDiff(output string) []types.DiffHunk {
+	var hunks []types.DiffHunk
+	var currentFile string
+	var currentHunk *types.DiffHunk
+	oldRemaining, newRemaining := 0, 0
+
+	flush := func() {
+		if currentHunk != nil {
+			hunks = append(hunks, *currentHunk)
+			currentHunk = nil
+		}
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		if oldRemaining > 0 || newRemaining > 0 {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				newRemaining--
+				if currentHunk != nil {
+					currentHunk.AddedLines = append(currentHunk.AddedLines, strings.TrimPrefix(line, "+"))
+				}
+			case strings.HasPrefix(line, "-"):
+				oldRemaining--
+			case strings.HasPrefix(line, " "):
+				oldRemaining--
+				newRemaining--
+			case strings.HasPrefix(line, "\\"):
+				// "\ No newline at end of file" belongs to the previous line.
+			default:
+				oldRemaining, newRemaining = 0, 0
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			currentFile = ""
+		case strings.HasPrefix(line, "+++ "):
+			path := strings.TrimPrefix(line, "+++ ")
+			if path == "/dev/null" {
+				currentFile = ""
+			} else {
+				currentFile = strings.TrimPrefix(path, "b/")
+			}
+		case strings.HasPrefix(line, "--- "):
+			continue
+		case strings.HasPrefix(line, "@@"):
+			flush()
+			matches := hunkHeaderRe.FindStringSubmatch(line)
+			if matches == nil {
+				continue
+			}
+			oldRemaining = atoiDefault(matches[2], 1)
+			newRemaining = atoiDefault(matches[4], 1)
+			if currentFile == "" {
+				continue
+			}
+			currentHunk = &types.DiffHunk{
+				File:       currentFile,
+				OldStart:   atoiDefault(matches[1], 0),
+				OldLines:   oldRemaining,
+				NewStart:   atoiDefault(matches[3], 0),
+				NewLines:   newRemaining,
+				AddedLines: []string{},
+			}
+		}
+	}
+	flush()
+
+	return hunks
+}
+
+func atoiDefault(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+	return n
+}
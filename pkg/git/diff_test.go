@@ -0,0 +1,85 @@
+package git
+
+import (
+	"testing"
+)
+
+func TestParseUnifiedDiff(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+index 422c2b7..02b01c0 100644
+--- a/main.go
++++ b/main.go
+@@ -1,0 +2 @@ package main
++import "fmt"
+@@ -5,2 +6,3 @@ func main() {
+-	println("hi")
++	fmt.Println("hello")
++	fmt.Println("world")
++	return
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package old
+-
+`
+
+	hunks := parseUnifiedDiff(diff)
+
+	if len(hunks) != 2 {
+		t.Fatalf("Expected 2 hunks, got %d", len(hunks))
+	}
+
+	if hunks[0].File != "main.go" {
+		t.Errorf("Expected file to be 'main.go', got %s", hunks[0].File)
+	}
+
+	if hunks[0].NewStart != 2 || hunks[0].NewLines != 1 {
+		t.Errorf("Expected first hunk at +2,1, got +%d,%d", hunks[0].NewStart, hunks[0].NewLines)
+	}
+
+	if hunks[1].OldStart != 5 || hunks[1].OldLines != 2 {
+		t.Errorf("Expected second hunk at -5,2, got -%d,%d", hunks[1].OldStart, hunks[1].OldLines)
+	}
+
+	if len(hunks[1].AddedLines) != 3 {
+		t.Errorf("Expected 3 added lines, got %d", len(hunks[1].AddedLines))
+	}
+
+	if hunks[1].AddedLines[0] != "\tfmt.Println(\"hello\")" {
+		t.Errorf("Unexpected first added line: %q", hunks[1].AddedLines[0])
+	}
+}
+
+func TestParseUnifiedDiffHeaderLikeLines(t *testing.T) {
+	diff := `diff --git a/notes.md b/notes.md
+--- a/notes.md
++++ b/notes.md
+@@ -1 +1,3 @@
+--- old heading
++++ new heading
++++ b/not-a-file
++plain
+diff --git a/other.md b/other.md
+--- a/other.md
++++ b/other.md
+@@ -0,0 +1 @@
++other
+`
+
+	hunks := parseUnifiedDiff(diff)
+
+	if len(hunks) != 2 {
+		t.Fatalf("Expected 2 hunks, got %d: %+v", len(hunks), hunks)
+	}
+	if hunks[0].File != "notes.md" || len(hunks[0].AddedLines) != 3 {
+		t.Fatalf("Expected 3 added lines in notes.md, got %+v", hunks[0])
+	}
+	if hunks[0].AddedLines[0] != "++ new heading" || hunks[0].AddedLines[1] != "++ b/not-a-file" {
+		t.Errorf("Unexpected added lines: %q", hunks[0].AddedLines)
+	}
+	if hunks[1].File != "other.md" || hunks[1].AddedLines[0] != "other" {
+		t.Errorf("Expected other.md hunk, got %+v", hunks[1])
+	}
+}
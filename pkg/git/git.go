@@ -14,7 +14,8 @@ import (
 )
 
 type GitClient struct {
-	repoPath string
+	repoPath  string
+	loadHunks bool
 }
 
 func NewGitClient(repoPath string) *GitClient {
@@ -38,7 +39,12 @@ func (g *GitClient) GetCommits(since time.Time) ([]types.EnrichedCommit, error)
 		return nil, fmt.Errorf("failed to get git log: %w", err)
 	}
 
-	return g.parseCommits(string(output))
+	commits, err := g.parseCommits(string(output))
+	if err != nil {
+		return nil, err
+	}
+
+	return g.attachHunks(commits)
 }
 
 func (g *GitClient) GetCommitsByRange(fromCommit, toCommit string) ([]types.EnrichedCommit, error) {
@@ -57,7 +63,12 @@ func (g *GitClient) GetCommitsByRange(fromCommit, toCommit string) ([]types.Enri
 		return nil, fmt.Errorf("failed to get git log: %w", err)
 	}
 
-	return g.parseCommits(string(output))
+	commits, err := g.parseCommits(string(output))
+	if err != nil {
+		return nil, err
+	}
+
+	return g.attachHunks(commits)
 }
 
 func (g *GitClient) GetCommitDetails(sha string) (*types.EnrichedCommit, error) {
@@ -86,6 +97,11 @@ func (g *GitClient) GetCommitDetails(sha string) (*types.EnrichedCommit, error)
 		return nil, fmt.Errorf("commit not found: %s", sha)
 	}
 
+	commits, err = g.attachHunks(commits[:1])
+	if err != nil {
+		return nil, err
+	}
+
 	return &commits[0], nil
 }
 
@@ -235,7 +251,12 @@ func (g *GitClient) GetCommitsBetweenDates(start, end time.Time) ([]types.Enrich
 		return nil, fmt.Errorf("failed to get git log: %w", err)
 	}
 
-	return g.parseCommits(string(output))
+	commits, err := g.parseCommits(string(output))
+	if err != nil {
+		return nil, err
+	}
+
+	return g.attachHunks(commits)
 }
 
 func FindGitRepository(path string) (string, error) {
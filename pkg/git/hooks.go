@@ -0,0 +1,104 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+var identRe = regexp.MustCompile(`^(.*) <([^>]*)> (\d+) [+-]\d{4}$`)
+
+func (g *GitClient) HooksPath() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--git-path", "hooks")
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate hooks directory: %w", err)
+	}
+
+	path := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(g.repoPath, path)
+	}
+
+	return path, nil
+}
+
+// PendingCommit describes the commit currently being made from the staged
+// changes, for correlation before the commit object exists.
+func (g *GitClient) PendingCommit() (*types.EnrichedCommit, error) {
+	commit := &types.EnrichedCommit{
+		Timestamp:  time.Now(),
+		Repository: g.getRepositoryName(),
+		Branch:     g.getCurrentBranch(),
+		Files:      []string{},
+		Parents:    []string{},
+	}
+
+	author, err := g.gitVar("GIT_AUTHOR_IDENT")
+	if err != nil {
+		return nil, err
+	}
+	if matches := identRe.FindStringSubmatch(author); matches != nil {
+		commit.Author = matches[1]
+		commit.AuthorEmail = matches[2]
+	}
+
+	committer, err := g.gitVar("GIT_COMMITTER_IDENT")
+	if err != nil {
+		return nil, err
+	}
+	if matches := identRe.FindStringSubmatch(committer); matches != nil {
+		commit.Committer = matches[1]
+		if timestamp, err := strconv.ParseInt(matches[3], 10, 64); err == nil {
+			commit.Timestamp = time.Unix(timestamp, 0)
+		}
+	}
+
+	if head, err := g.ResolveCommit("HEAD"); err == nil {
+		commit.Parents = append(commit.Parents, head)
+	}
+
+	// Without --no-renames, a rename is listed as "old => new", which matches
+	// neither path.
+	cmd := exec.Command("git", "diff", "--cached", "--numstat", "--no-renames")
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read staged changes: %w", err)
+	}
+
+	for _, line := range strings.Split(string(output), "\n") {
+		parts := strings.Split(line, "\t")
+		if len(parts) != 3 {
+			continue
+		}
+		additions, _ := strconv.Atoi(parts[0])
+		deletions, _ := strconv.Atoi(parts[1])
+		commit.Additions += additions
+		commit.Deletions += deletions
+		commit.Files = append(commit.Files, parts[2])
+	}
+
+	return commit, nil
+}
+
+func (g *GitClient) gitVar(name string) (string, error) {
+	cmd := exec.Command("git", "var", name)
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,43 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestPendingCommitListsRenamedFiles(t *testing.T) {
+	dir := t.TempDir()
+	if output, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Skipf("git init failed: %v: %s", err, output)
+	}
+	gitOutput(t, dir, "", "config", "user.name", "Dev")
+	gitOutput(t, dir, "", "config", "user.email", "dev@example.com")
+
+	content := strings.Repeat("line\n", 20)
+	if err := os.MkdirAll(filepath.Join(dir, "pkg", "old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pkg", "old", "main.go"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitOutput(t, dir, "", "add", ".")
+	gitOutput(t, dir, "", "commit", "-q", "-m", "Add main")
+	gitOutput(t, dir, "", "mv", "pkg/old", "pkg/new")
+
+	commit, err := NewGitClient(dir).PendingCommit()
+	if err != nil {
+		t.Fatalf("Failed to read pending commit: %v", err)
+	}
+
+	sort.Strings(commit.Files)
+	if len(commit.Files) != 2 || commit.Files[0] != "pkg/new/main.go" || commit.Files[1] != "pkg/old/main.go" {
+		t.Errorf("Expected both paths of the rename, got %v", commit.Files)
+	}
+	if commit.Author != "Dev" || commit.AuthorEmail != "dev@example.com" {
+		t.Errorf("Unexpected author %q <%s>", commit.Author, commit.AuthorEmail)
+	}
+}
@@ -0,0 +1,104 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const DefaultNotesRef = "refs/notes/git-snap"
+
+func (g *GitClient) ListNotes(ref string) (map[string]string, error) {
+	cmd := exec.Command("git", "notes", "--ref", ref, "list")
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		if !g.refExists(ref) {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("failed to list notes: %w", err)
+	}
+
+	notes := make(map[string]string)
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			notes[fields[1]] = fields[0]
+		}
+	}
+
+	return notes, nil
+}
+
+// ReadNote reads the note attached to rev, which may be any revision that
+// names a commit.
+func (g *GitClient) ReadNote(ref, rev string) (string, bool, error) {
+	sha, err := g.ResolveCommit(rev)
+	if err != nil {
+		return "", false, err
+	}
+
+	cmd := exec.Command("git", "notes", "--ref", ref, "show", sha)
+	cmd.Dir = g.repoPath
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if strings.Contains(stderr.String(), "no note found") || !g.refExists(ref) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("failed to read note for %s: %w: %s", sha, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return string(output), true, nil
+}
+
+func (g *GitClient) ReadBlob(sha string) (string, error) {
+	cmd := exec.Command("git", "cat-file", "blob", sha)
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read blob %s: %w", sha, err)
+	}
+
+	return string(output), nil
+}
+
+func (g *GitClient) WriteNote(ref, sha, content string) error {
+	cmd := exec.Command("git", "notes", "--ref", ref, "add", "--force", "--file=-", sha)
+	cmd.Dir = g.repoPath
+	cmd.Stdin = strings.NewReader(content)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to write note for %s: %w: %s", sha, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+// ResolveCommit returns the full SHA of the commit rev names.
+func (g *GitClient) ResolveCommit(rev string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("unknown commit: %s", rev)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+func (g *GitClient) refExists(ref string) bool {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", ref)
+	cmd.Dir = g.repoPath
+	return cmd.Run() == nil
+}
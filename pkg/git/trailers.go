@@ -0,0 +1,471 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+const (
+	EventTrailer = "Snap-Event"
+	ScoreTrailer = "Snap-Score"
+)
+
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+type commitInfo struct {
+	tree        string
+	parents     []string
+	authorName  string
+	authorEmail string
+	authorDate  string
+	message     string
+	signed      bool
+}
+
+func TrailersForResults(results []types.CorrelationResult) map[string][]Trailer {
+	bySHA := make(map[string][]types.CorrelationResult)
+	for _, result := range results {
+		bySHA[result.Commit.SHA] = append(bySHA[result.Commit.SHA], result)
+	}
+
+	trailers := make(map[string][]Trailer, len(bySHA))
+	for sha, commitResults := range bySHA {
+		if commitTrailers := TrailersForCommit(commitResults); len(commitTrailers) > 0 {
+			trailers[sha] = commitTrailers
+		}
+	}
+
+	return trailers
+}
+
+// TrailersForCommit returns the trailers for results that all belong to one
+// commit, such as the pending commit of a prepare-commit-msg hook, which has
+// no SHA yet. Events are ordered by score and listed once, by their Key(), so
+// an event without an ID is recorded as @ followed by its timestamp.
+func TrailersForCommit(results []types.CorrelationResult) []Trailer {
+	sorted := append([]types.CorrelationResult{}, results...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Score > sorted[j].Score
+	})
+
+	var trailers []Trailer
+	seen := make(map[string]bool)
+	for _, result := range sorted {
+		key := result.Event.Key()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		trailers = append(trailers,
+			Trailer{Key: EventTrailer, Value: key},
+			Trailer{Key: ScoreTrailer, Value: fmt.Sprintf("%.2f", result.Score)},
+		)
+	}
+
+	return trailers
+}
+
+func (g *GitClient) AppendTrailers(message string, trailers []Trailer) (string, error) {
+	existing, err := g.parseTrailers(message)
+	if err != nil {
+		return "", err
+	}
+
+	recorded := make(map[string]bool)
+	for _, trailer := range existing {
+		if trailer.Key == EventTrailer {
+			recorded[trailer.Value] = true
+		}
+	}
+
+	args := []string{"interpret-trailers", "--if-exists", "add"}
+	added := 0
+	skipping := false
+	for _, trailer := range trailers {
+		if trailer.Key == EventTrailer {
+			skipping = recorded[trailer.Value]
+		}
+		if skipping {
+			continue
+		}
+		args = append(args, "--trailer", trailer.Key+": "+trailer.Value)
+		added++
+	}
+
+	if added == 0 {
+		return message, nil
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.repoPath
+	cmd.Stdin = strings.NewReader(message)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to add trailers: %w", err)
+	}
+
+	return string(output), nil
+}
+
+func (g *GitClient) AppendTrailersToFile(filename string, trailers []Trailer) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read commit message: %w", err)
+	}
+
+	message, err := g.AppendTrailers(string(data), trailers)
+	if err != nil {
+		return err
+	}
+
+	if message == string(data) {
+		return nil
+	}
+
+	return os.WriteFile(filename, []byte(message), 0644)
+}
+
+func (g *GitClient) parseTrailers(message string) ([]Trailer, error) {
+	cmd := exec.Command("git", "interpret-trailers", "--parse")
+	cmd.Dir = g.repoPath
+	cmd.Stdin = strings.NewReader(message)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse trailers: %w", err)
+	}
+
+	var trailers []Trailer
+	for _, line := range strings.Split(string(output), "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if found {
+			trailers = append(trailers, Trailer{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value)})
+		}
+	}
+
+	return trailers, nil
+}
+
+// UnpushedCommits returns the commits on the current branch that no remote
+// branch contains, oldest first, leaving out those reachable from base when it
+// is set. Without remote branches every commit would count as unpushed, so
+// base is then required.
+func (g *GitClient) UnpushedCommits(base string) ([]string, error) {
+	args := []string{"rev-list", "--reverse", "--topo-order", "HEAD", "--not", "--remotes"}
+	if base != "" {
+		args = append(args, base)
+	} else if hasRemotes, err := g.HasRemoteBranches(); err != nil {
+		return nil, err
+	} else if !hasRemotes {
+		return nil, fmt.Errorf("no remote branches to tell unpushed commits from, so a base commit is required")
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unpushed commits: %w", err)
+	}
+
+	return strings.Fields(string(output)), nil
+}
+
+// HasRemoteBranches reports whether the repository has any remote-tracking
+// branch.
+func (g *GitClient) HasRemoteBranches() (bool, error) {
+	cmd := exec.Command("git", "for-each-ref", "--count=1", "--format=%(refname)", "refs/remotes")
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to list remote branches: %w", err)
+	}
+
+	return strings.TrimSpace(string(output)) != "", nil
+}
+
+func (g *GitClient) IsReachableFromRemote(sha string) (bool, error) {
+	cmd := exec.Command("git", "for-each-ref", "--contains", sha, "--format=%(refname)", "refs/remotes")
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to check remote branches for %s: %w", sha, err)
+	}
+
+	return strings.TrimSpace(string(output)) != "", nil
+}
+
+// RewriteWithTrailers rewrites the unpushed commits on the current branch
+// after base, as listed by UnpushedCommits, appending the given trailers to the
+// commits keyed by SHA. Trees and author information are preserved. It returns
+// a map from old to new SHAs for the commits whose message changed.
+//
+// It refuses to run while a rebase, merge, cherry-pick or revert is in
+// progress, and to rewrite signed commits, whose signatures would be lost,
+// unless dropSignatures is set.
+func (g *GitClient) RewriteWithTrailers(trailers map[string][]Trailer, base string, dropSignatures bool) (map[string]string, error) {
+	if err := g.checkNoOperationInProgress(); err != nil {
+		return nil, err
+	}
+
+	unpushed, err := g.UnpushedCommits(base)
+	if err != nil {
+		return nil, err
+	}
+
+	unpushedSet := make(map[string]bool, len(unpushed))
+	for _, sha := range unpushed {
+		unpushedSet[sha] = true
+	}
+
+	for sha := range trailers {
+		if unpushedSet[sha] {
+			continue
+		}
+		reachable, err := g.IsReachableFromRemote(sha)
+		if err != nil {
+			return nil, err
+		}
+		if reachable {
+			return nil, fmt.Errorf("refusing to rewrite %s: commit is reachable from a remote branch", sha)
+		}
+		return nil, fmt.Errorf("refusing to rewrite %s: commit is not an ancestor of HEAD", sha)
+	}
+
+	head, err := g.ResolveCommit("HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	rewritten := make(map[string]string)
+	mapped := make(map[string]string)
+	for _, sha := range unpushed {
+		info, err := g.readCommitInfo(sha)
+		if err != nil {
+			return nil, err
+		}
+
+		message := info.message
+		if commitTrailers, exists := trailers[sha]; exists {
+			message, err = g.AppendTrailers(info.message, commitTrailers)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		parentsChanged := false
+		for i, parent := range info.parents {
+			if newParent, exists := mapped[parent]; exists {
+				info.parents[i] = newParent
+				parentsChanged = true
+			}
+		}
+
+		if message == info.message && !parentsChanged {
+			continue
+		}
+		if info.signed && !dropSignatures {
+			return nil, fmt.Errorf("refusing to rewrite %s: commit is signed and rewriting drops its signature", sha)
+		}
+
+		newSHA, err := g.commitTree(info, message)
+		if err != nil {
+			return nil, err
+		}
+		mapped[sha] = newSHA
+		if message != info.message {
+			rewritten[sha] = newSHA
+		}
+	}
+
+	newHead, changed := mapped[head]
+	if !changed {
+		return rewritten, nil
+	}
+	if err := g.updateHead(newHead, head); err != nil {
+		return nil, err
+	}
+
+	return rewritten, nil
+}
+
+// AmendWithTrailers appends trailers to HEAD alone, like git commit --amend,
+// and returns its new SHA, or "" when HEAD already has them. It needs no
+// remote branches to tell unpushed commits from, but refuses a HEAD that a
+// remote branch contains, and otherwise refuses like RewriteWithTrailers.
+func (g *GitClient) AmendWithTrailers(trailers []Trailer, dropSignatures bool) (string, error) {
+	if err := g.checkNoOperationInProgress(); err != nil {
+		return "", err
+	}
+
+	head, err := g.ResolveCommit("HEAD")
+	if err != nil {
+		return "", err
+	}
+	if reachable, err := g.IsReachableFromRemote(head); err != nil {
+		return "", err
+	} else if reachable {
+		return "", fmt.Errorf("refusing to amend %s: commit is reachable from a remote branch", head)
+	}
+
+	info, err := g.readCommitInfo(head)
+	if err != nil {
+		return "", err
+	}
+	message, err := g.AppendTrailers(info.message, trailers)
+	if err != nil {
+		return "", err
+	}
+	if message == info.message {
+		return "", nil
+	}
+	if info.signed && !dropSignatures {
+		return "", fmt.Errorf("refusing to amend %s: commit is signed and rewriting drops its signature", head)
+	}
+
+	newHead, err := g.commitTree(info, message)
+	if err != nil {
+		return "", err
+	}
+	if err := g.updateHead(newHead, head); err != nil {
+		return "", err
+	}
+
+	return newHead, nil
+}
+
+func (g *GitClient) updateHead(newHead, oldHead string) error {
+	cmd := exec.Command("git", "update-ref", "-m", "git-snap: add trailers", "HEAD", newHead, oldHead)
+	cmd.Dir = g.repoPath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to update HEAD: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func (g *GitClient) readCommitInfo(sha string) (*commitInfo, error) {
+	cmd := exec.Command("git", "log", "-1", "--date=raw", "--format=%T%n%P%n%an%n%ae%n%ad%n%B", sha)
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit %s: %w", sha, err)
+	}
+
+	parts := strings.SplitN(string(output), "\n", 6)
+	if len(parts) < 6 {
+		return nil, fmt.Errorf("unexpected commit format for %s", sha)
+	}
+
+	signed, err := g.isSigned(sha)
+	if err != nil {
+		return nil, err
+	}
+
+	return &commitInfo{
+		tree:        parts[0],
+		parents:     strings.Fields(parts[1]),
+		authorName:  parts[2],
+		authorEmail: parts[3],
+		authorDate:  parts[4],
+		message:     strings.TrimSuffix(parts[5], "\n"),
+		signed:      signed,
+	}, nil
+}
+
+// isSigned reports whether a commit carries a GPG, SSH or X.509 signature
+// header.
+func (g *GitClient) isSigned(sha string) (bool, error) {
+	cmd := exec.Command("git", "cat-file", "commit", sha)
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to read commit %s: %w", sha, err)
+	}
+
+	header, _, _ := strings.Cut(string(output), "\n\n")
+	for _, line := range strings.Split(header, "\n") {
+		if strings.HasPrefix(line, "gpgsig ") || strings.HasPrefix(line, "gpgsig-sha256 ") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// OperationInProgress returns the name of the rebase, merge, cherry-pick or
+// revert in progress in the repository, or "" when there is none.
+func (g *GitClient) OperationInProgress() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--absolute-git-dir")
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find git directory: %w", err)
+	}
+	gitDir := strings.TrimSpace(string(output))
+
+	for _, marker := range []struct{ path, operation string }{
+		{"rebase-merge", "rebase"},
+		{"rebase-apply", "rebase"},
+		{"MERGE_HEAD", "merge"},
+		{"CHERRY_PICK_HEAD", "cherry-pick"},
+		{"REVERT_HEAD", "revert"},
+	} {
+		if _, err := os.Stat(filepath.Join(gitDir, marker.path)); err == nil {
+			return marker.operation, nil
+		}
+	}
+	return "", nil
+}
+
+func (g *GitClient) checkNoOperationInProgress() error {
+	operation, err := g.OperationInProgress()
+	if err != nil {
+		return err
+	}
+	if operation != "" {
+		return fmt.Errorf("refusing to rewrite commits: a %s is in progress", operation)
+	}
+	return nil
+}
+
+func (g *GitClient) commitTree(info *commitInfo, message string) (string, error) {
+	args := []string{"commit-tree", info.tree}
+	for _, parent := range info.parents {
+		args = append(args, "-p", parent)
+	}
+	args = append(args, "-F", "-")
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.repoPath
+	cmd.Stdin = strings.NewReader(message)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME="+info.authorName,
+		"GIT_AUTHOR_EMAIL="+info.authorEmail,
+		"GIT_AUTHOR_DATE="+info.authorDate,
+	)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to create commit: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
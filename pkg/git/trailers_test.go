@@ -0,0 +1,184 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+func TestTrailersForResults(t *testing.T) {
+	results := []types.CorrelationResult{
+		{Event: types.SnapEvent{ID: "inference_002"}, Commit: types.EnrichedCommit{SHA: "abc123"}, Score: 0.5},
+		{Event: types.SnapEvent{ID: "inference_001"}, Commit: types.EnrichedCommit{SHA: "abc123"}, Score: 0.912},
+		{Event: types.SnapEvent{ID: "inference_001"}, Commit: types.EnrichedCommit{SHA: "abc123"}, Score: 0.4},
+	}
+
+	trailers := TrailersForResults(results)["abc123"]
+
+	if len(trailers) != 4 {
+		t.Fatalf("Expected 4 trailers, got %d", len(trailers))
+	}
+
+	expected := []Trailer{
+		{Key: EventTrailer, Value: "inference_001"},
+		{Key: ScoreTrailer, Value: "0.91"},
+		{Key: EventTrailer, Value: "inference_002"},
+		{Key: ScoreTrailer, Value: "0.50"},
+	}
+	for i, trailer := range expected {
+		if trailers[i] != trailer {
+			t.Errorf("Expected trailer %d to be %+v, got %+v", i, trailer, trailers[i])
+		}
+	}
+}
+
+func TestAppendTrailersIsIdempotent(t *testing.T) {
+	client := NewGitClient(t.TempDir())
+	trailers := []Trailer{
+		{Key: EventTrailer, Value: "inference_001"},
+		{Key: ScoreTrailer, Value: "0.91"},
+	}
+
+	message, err := client.AppendTrailers("Add feature\n\nLonger description.\n", trailers)
+	if err != nil {
+		t.Fatalf("Failed to append trailers: %v", err)
+	}
+
+	if !strings.HasSuffix(message, "\n\nSnap-Event: inference_001\nSnap-Score: 0.91\n") {
+		t.Errorf("Unexpected message with trailers: %q", message)
+	}
+
+	again, err := client.AppendTrailers(message, trailers)
+	if err != nil {
+		t.Fatalf("Failed to append trailers: %v", err)
+	}
+
+	if again != message {
+		t.Errorf("Expected appending the same trailers twice to be a no-op, got %q", again)
+	}
+}
+
+func TestOperationInProgress(t *testing.T) {
+	dir := t.TempDir()
+	if output, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Skipf("git init failed: %v: %s", err, output)
+	}
+	client := NewGitClient(dir)
+
+	operation, err := client.OperationInProgress()
+	if err != nil || operation != "" {
+		t.Fatalf("Expected no operation in progress, got %q (%v)", operation, err)
+	}
+
+	if err := os.Mkdir(filepath.Join(dir, ".git", "rebase-merge"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if operation, _ := client.OperationInProgress(); operation != "rebase" {
+		t.Errorf("Expected a rebase in progress, got %q", operation)
+	}
+	if _, err := client.RewriteWithTrailers(nil, "", false); err == nil || !strings.Contains(err.Error(), "rebase") {
+		t.Errorf("Expected rewriting to be refused during a rebase, got %v", err)
+	}
+}
+
+func TestIsSigned(t *testing.T) {
+	dir := t.TempDir()
+	if output, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Skipf("git init failed: %v: %s", err, output)
+	}
+	client := NewGitClient(dir)
+
+	writeCommit := func(headers string) string {
+		tree := gitOutput(t, dir, "", "mktree")
+		content := "tree " + tree + "\nauthor A <a@x> 1700000000 +0000\ncommitter A <a@x> 1700000000 +0000\n" +
+			headers + "\nMessage\n"
+		return gitOutput(t, dir, content, "hash-object", "-t", "commit", "-w", "--stdin")
+	}
+
+	unsigned := writeCommit("")
+	signed := writeCommit("gpgsig -----BEGIN SSH SIGNATURE-----\n U1NIU0lH\n -----END SSH SIGNATURE-----\n")
+
+	if isSigned, err := client.isSigned(unsigned); err != nil || isSigned {
+		t.Errorf("Expected %s to be unsigned, got %t (%v)", unsigned, isSigned, err)
+	}
+	if isSigned, err := client.isSigned(signed); err != nil || !isSigned {
+		t.Errorf("Expected %s to be signed, got %t (%v)", signed, isSigned, err)
+	}
+}
+
+func gitOutput(t *testing.T, dir, stdin string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(stdin)
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git %s failed: %v", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func TestTrailersForCommit(t *testing.T) {
+	results := []types.CorrelationResult{
+		{Event: types.SnapEvent{ID: "inference_002"}, Score: 0.5},
+		{Event: types.SnapEvent{Timestamp: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)}, Score: 0.8},
+		{Event: types.SnapEvent{ID: "inference_001"}, Score: 0.912},
+	}
+
+	trailers := TrailersForCommit(results)
+
+	if len(trailers) != 6 || trailers[0].Value != "inference_001" || trailers[2].Value != "@2024-01-15T10:30:00Z" || trailers[4].Value != "inference_002" {
+		t.Errorf("Expected trailers for inference_001, the event without ID, then inference_002, got %+v", trailers)
+	}
+	if results[0].Event.ID != "inference_002" {
+		t.Error("Expected the results to be left in order")
+	}
+}
+
+func TestUnpushedCommitsRequiresBaseWithoutRemotes(t *testing.T) {
+	dir := t.TempDir()
+	if output, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Skipf("git init failed: %v: %s", err, output)
+	}
+	client := NewGitClient(dir)
+	gitOutput(t, dir, "", "config", "user.name", "Dev")
+	gitOutput(t, dir, "", "config", "user.email", "dev@example.com")
+	commit := func(message string) string {
+		gitOutput(t, dir, "", "commit", "-q", "--allow-empty", "-m", message)
+		return gitOutput(t, dir, "", "rev-parse", "HEAD")
+	}
+	first := commit("First")
+	second := commit("Second")
+
+	if _, err := client.UnpushedCommits(""); err == nil || !strings.Contains(err.Error(), "base") {
+		t.Errorf("Expected a base to be required without remote branches, got %v", err)
+	}
+	if _, err := client.RewriteWithTrailers(map[string][]Trailer{}, "", false); err == nil {
+		t.Error("Expected rewriting without remote branches or a base to be refused")
+	}
+
+	unpushed, err := client.UnpushedCommits(first)
+	if err != nil || len(unpushed) != 1 || unpushed[0] != second {
+		t.Errorf("Expected only %s after the base, got %v (%v)", second, unpushed, err)
+	}
+
+	trailers := []Trailer{{Key: EventTrailer, Value: "inference_001"}, {Key: ScoreTrailer, Value: "0.91"}}
+	amended, err := client.AmendWithTrailers(trailers, false)
+	if err != nil {
+		t.Fatalf("Failed to amend HEAD: %v", err)
+	}
+	if message := gitOutput(t, dir, "", "log", "-1", "--format=%B", amended); !strings.HasSuffix(message, "Snap-Event: inference_001\nSnap-Score: 0.91") {
+		t.Errorf("Expected HEAD to carry the trailers, got %q", message)
+	}
+	if parent := gitOutput(t, dir, "", "rev-parse", "HEAD^"); parent != first {
+		t.Errorf("Expected the parent to be left alone, got %s", parent)
+	}
+	if again, err := client.AmendWithTrailers(trailers, false); err != nil || again != "" {
+		t.Errorf("Expected amending with the same trailers to be a no-op, got %q (%v)", again, err)
+	}
+}
@@ -0,0 +1,148 @@
+package hooks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	PrepareCommitMsg = "prepare-commit-msg"
+	PostCommit       = "post-commit"
+
+	ModeTrailers = "trailers"
+	ModeNotes    = "notes"
+	ModeBoth     = "both"
+
+	Marker        = "# git-snap: managed hook"
+	ChainedSuffix = ".pre-git-snap"
+)
+
+type Options struct {
+	Executable string
+	Config     string
+	Spool      string
+	Mode       string
+	Threshold  float64
+	NotesRef   string
+}
+
+func HooksForMode(mode string) ([]string, error) {
+	switch mode {
+	case ModeTrailers:
+		return []string{PrepareCommitMsg}, nil
+	case ModeNotes:
+		return []string{PostCommit}, nil
+	case ModeBoth:
+		return []string{PrepareCommitMsg, PostCommit}, nil
+	default:
+		return nil, fmt.Errorf("unsupported hook mode: %s", mode)
+	}
+}
+
+func Script(hook string, opts Options) string {
+	args := []string{
+		shellQuote(opts.Executable), "hooks", "run", hook,
+		"--config", shellQuote(opts.Config),
+		"--spool", shellQuote(opts.Spool),
+		"--threshold", strconv.FormatFloat(opts.Threshold, 'f', -1, 64),
+	}
+	if opts.NotesRef != "" {
+		args = append(args, "--notes-ref", shellQuote(opts.NotesRef))
+	}
+
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString(Marker + ", remove with 'git-snap hooks uninstall'\n")
+	b.WriteString("chained=\"$0" + ChainedSuffix + "\"\n")
+	b.WriteString("if [ -x \"$chained\" ]; then\n")
+	b.WriteString("\t\"$chained\" \"$@\" || exit $?\n")
+	b.WriteString("fi\n")
+	b.WriteString(strings.Join(args, " ") + " -- \"$@\" || true\n")
+	return b.String()
+}
+
+func Install(dir string, opts Options) ([]string, error) {
+	names, err := HooksForMode(opts.Mode)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+
+	var installed []string
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+
+		managed, exists, err := isManaged(path)
+		if err != nil {
+			return installed, err
+		}
+
+		if exists && !managed {
+			chained := path + ChainedSuffix
+			if _, err := os.Stat(chained); err == nil {
+				return installed, fmt.Errorf("cannot chain %s: %s already exists", name, chained)
+			}
+			if err := os.Rename(path, chained); err != nil {
+				return installed, fmt.Errorf("failed to chain existing %s hook: %w", name, err)
+			}
+		}
+
+		if err := os.WriteFile(path, []byte(Script(name, opts)), 0755); err != nil {
+			return installed, fmt.Errorf("failed to write %s hook: %w", name, err)
+		}
+		installed = append(installed, name)
+	}
+
+	return installed, nil
+}
+
+func Uninstall(dir string) ([]string, error) {
+	var removed []string
+
+	for _, name := range []string{PrepareCommitMsg, PostCommit} {
+		path := filepath.Join(dir, name)
+
+		managed, _, err := isManaged(path)
+		if err != nil {
+			return removed, err
+		}
+		if !managed {
+			continue
+		}
+
+		if err := os.Remove(path); err != nil {
+			return removed, fmt.Errorf("failed to remove %s hook: %w", name, err)
+		}
+
+		chained := path + ChainedSuffix
+		if _, err := os.Stat(chained); err == nil {
+			if err := os.Rename(chained, path); err != nil {
+				return removed, fmt.Errorf("failed to restore chained %s hook: %w", name, err)
+			}
+		}
+		removed = append(removed, name)
+	}
+
+	return removed, nil
+}
+
+func isManaged(path string) (managed bool, exists bool, err error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return strings.Contains(string(data), Marker), true, nil
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
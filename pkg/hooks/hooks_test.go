@@ -0,0 +1,106 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testOptions(mode string) Options {
+	return Options{
+		Executable: "/usr/local/bin/git-snap",
+		Config:     "ai-inference",
+		Spool:      "/home/john/.git-snap/spool",
+		Mode:       mode,
+		Threshold:  0.5,
+	}
+}
+
+func TestHooksForMode(t *testing.T) {
+	testCases := []struct {
+		mode     string
+		expected []string
+	}{
+		{ModeTrailers, []string{PrepareCommitMsg}},
+		{ModeNotes, []string{PostCommit}},
+		{ModeBoth, []string{PrepareCommitMsg, PostCommit}},
+	}
+
+	for _, tc := range testCases {
+		hooks, err := HooksForMode(tc.mode)
+		if err != nil {
+			t.Fatalf("HooksForMode(%s) returned error: %v", tc.mode, err)
+		}
+		if strings.Join(hooks, ",") != strings.Join(tc.expected, ",") {
+			t.Errorf("HooksForMode(%s) = %v, want %v", tc.mode, hooks, tc.expected)
+		}
+	}
+
+	if _, err := HooksForMode("invalid"); err == nil {
+		t.Errorf("Expected error for invalid mode")
+	}
+}
+
+func TestScriptQuotesArguments(t *testing.T) {
+	opts := testOptions(ModeTrailers)
+	opts.Spool = "/tmp/it's here"
+
+	script := Script(PrepareCommitMsg, opts)
+
+	if !strings.HasPrefix(script, "#!/bin/sh\n"+Marker) {
+		t.Errorf("Expected script to start with shebang and marker, got %q", script)
+	}
+
+	if !strings.Contains(script, `--spool '/tmp/it'\''s here'`) {
+		t.Errorf("Expected spool path to be shell quoted, got %q", script)
+	}
+
+	if !strings.Contains(script, `hooks run prepare-commit-msg`) {
+		t.Errorf("Expected script to run prepare-commit-msg, got %q", script)
+	}
+}
+
+func TestInstallChainsAndUninstallRestores(t *testing.T) {
+	dir := t.TempDir()
+	existing := "#!/bin/sh\necho existing\n"
+	hookPath := filepath.Join(dir, PrepareCommitMsg)
+
+	if err := os.WriteFile(hookPath, []byte(existing), 0755); err != nil {
+		t.Fatalf("Failed to write existing hook: %v", err)
+	}
+
+	installed, err := Install(dir, testOptions(ModeBoth))
+	if err != nil {
+		t.Fatalf("Failed to install hooks: %v", err)
+	}
+	if len(installed) != 2 {
+		t.Errorf("Expected 2 installed hooks, got %v", installed)
+	}
+
+	chained, err := os.ReadFile(hookPath + ChainedSuffix)
+	if err != nil || string(chained) != existing {
+		t.Fatalf("Expected existing hook to be chained, got %q (%v)", chained, err)
+	}
+
+	if _, err := Install(dir, testOptions(ModeBoth)); err != nil {
+		t.Fatalf("Expected reinstall to succeed, got %v", err)
+	}
+
+	removed, err := Uninstall(dir)
+	if err != nil {
+		t.Fatalf("Failed to uninstall hooks: %v", err)
+	}
+	if len(removed) != 2 {
+		t.Errorf("Expected 2 removed hooks, got %v", removed)
+	}
+
+	restored, err := os.ReadFile(hookPath)
+	if err != nil || string(restored) != existing {
+		t.Errorf("Expected existing hook to be restored, got %q (%v)", restored, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, PostCommit)); !os.IsNotExist(err) {
+		t.Errorf("Expected post-commit hook to be removed")
+	}
+}
@@ -0,0 +1,338 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/attribution"
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+const (
+	// ContentType is the Prometheus text exposition format, which the
+	// node_exporter textfile collector reads.
+	ContentType = "text/plain; version=0.0.4; charset=utf-8"
+	// OpenMetricsContentType is served to scrapers that accept it.
+	OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// Run is the outcome of one correlation run. Results are those at or above
+// the threshold; Err is set when the run failed.
+type Run struct {
+	Config      string
+	Results     []types.CorrelationResult
+	EventsRead  int
+	ParseErrors int
+	TypeKey     string
+	Started     time.Time
+	Duration    time.Duration
+	Err         error
+}
+
+// Registry holds correlation metrics. Counters accumulate over runs, while
+// the correlated event and commit counts describe the last successful run of
+// each config, since every run correlates its whole time window again.
+type Registry struct {
+	mu       sync.Mutex
+	counters map[string]map[string]float64
+	lastRuns map[string]*runSnapshot
+	// loaded holds gauge samples read by Load, kept for configs that have
+	// not run since.
+	loaded map[string]map[string]float64
+}
+
+type runSnapshot struct {
+	events     map[string]int
+	commits    map[string]int
+	eventsRead int
+	duration   time.Duration
+	finished   time.Time
+}
+
+type family struct {
+	name string
+	kind string
+	help string
+}
+
+var (
+	runsTotal        = family{"git_snap_runs_total", "counter", "Correlation runs started."}
+	runErrorsTotal   = family{"git_snap_run_errors_total", "counter", "Correlation runs that failed."}
+	parseErrorsTotal = family{"git_snap_parse_errors_total", "counter", "Malformed events skipped while reading."}
+	eventsRead       = family{"git_snap_events_read", "gauge", "Events read by the last successful run."}
+	correlatedEvents = family{"git_snap_correlated_events", "gauge", "Events correlated with at least one commit in the last successful run."}
+	correlatedCommit = family{"git_snap_correlated_commits", "gauge", "Commits correlated with at least one event in the last successful run."}
+	runDuration      = family{"git_snap_last_run_duration_seconds", "gauge", "Duration of the last successful run."}
+	runTimestamp     = family{"git_snap_last_run_timestamp_seconds", "gauge", "Unix time the last successful run finished."}
+)
+
+var (
+	counterFamilies = []family{runsTotal, runErrorsTotal, parseErrorsTotal}
+	gaugeFamilies   = []family{eventsRead, correlatedEvents, correlatedCommit, runDuration, runTimestamp}
+)
+
+func NewRegistry() *Registry {
+	return &Registry{
+		counters: make(map[string]map[string]float64),
+		lastRuns: make(map[string]*runSnapshot),
+		loaded:   make(map[string]map[string]float64),
+	}
+}
+
+func (r *Registry) Record(run Run) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	config := labels("config", run.Config)
+	r.add(runsTotal, config, 1)
+	r.add(parseErrorsTotal, config, float64(run.ParseErrors))
+	if run.Err != nil {
+		r.add(runErrorsTotal, config, 1)
+		return
+	}
+	r.add(runErrorsTotal, config, 0)
+
+	typeKey := run.TypeKey
+	seenEvents := make(map[string]bool)
+	seenCommits := make(map[string]bool)
+	snapshot := &runSnapshot{
+		events:     make(map[string]int),
+		commits:    make(map[string]int),
+		eventsRead: run.EventsRead,
+		duration:   run.Duration,
+		finished:   run.Started.Add(run.Duration),
+	}
+	for _, result := range run.Results {
+		eventType := attribution.UntypedEvent
+		if value, exists := result.Event.Attributes[typeKey]; exists && value != nil {
+			eventType = fmt.Sprintf("%v", value)
+		}
+		repository := result.Commit.Repository
+		author := result.Commit.AuthorEmail
+		if author == "" {
+			author = result.Commit.Author
+		}
+		key := labels("config", run.Config, "repository", repository, "author", author, "event_type", eventType)
+
+		if event := key + "\x00" + result.Event.Key(); !seenEvents[event] {
+			seenEvents[event] = true
+			snapshot.events[key]++
+		}
+		if commit := key + "\x00" + result.Commit.SHA; !seenCommits[commit] {
+			seenCommits[commit] = true
+			snapshot.commits[key]++
+		}
+	}
+	r.lastRuns[run.Config] = snapshot
+}
+
+func (r *Registry) add(f family, key string, value float64) {
+	if r.counters[f.name] == nil {
+		r.counters[f.name] = make(map[string]float64)
+	}
+	r.counters[f.name][key] += value
+}
+
+// Write writes every metric in the Prometheus text format, or in OpenMetrics
+// when openMetrics is set.
+func (r *Registry) Write(w io.Writer, openMetrics bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, f := range counterFamilies {
+		writeFamily(bw, f, r.counters[f.name], openMetrics)
+	}
+
+	configs := make([]string, 0, len(r.lastRuns))
+	for config := range r.lastRuns {
+		configs = append(configs, config)
+	}
+	sort.Strings(configs)
+
+	gauges := make(map[string]map[string]float64, len(gaugeFamilies))
+	for _, f := range gaugeFamilies {
+		gauges[f.name] = make(map[string]float64)
+		for key, value := range r.loaded[f.name] {
+			if _, ran := r.lastRuns[labelValue(key, "config")]; !ran {
+				gauges[f.name][key] = value
+			}
+		}
+	}
+	for _, config := range configs {
+		snapshot := r.lastRuns[config]
+		key := labels("config", config)
+		gauges[eventsRead.name][key] = float64(snapshot.eventsRead)
+		gauges[runDuration.name][key] = snapshot.duration.Seconds()
+		gauges[runTimestamp.name][key] = float64(snapshot.finished.UnixMilli()) / 1000
+		for key, count := range snapshot.events {
+			gauges[correlatedEvents.name][key] = float64(count)
+		}
+		for key, count := range snapshot.commits {
+			gauges[correlatedCommit.name][key] = float64(count)
+		}
+	}
+	for _, f := range gaugeFamilies {
+		writeFamily(bw, f, gauges[f.name], openMetrics)
+	}
+
+	if openMetrics {
+		fmt.Fprintln(bw, "# EOF")
+	}
+	return bw.Flush()
+}
+
+func writeFamily(w io.Writer, f family, samples map[string]float64, openMetrics bool) {
+	if len(samples) == 0 {
+		return
+	}
+
+	// OpenMetrics names a counter family without its _total suffix.
+	name := f.name
+	if openMetrics && f.kind == "counter" {
+		name = strings.TrimSuffix(name, "_total")
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n", name, f.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, f.kind)
+
+	keys := make([]string, 0, len(samples))
+	for key := range samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s{%s} %s\n", f.name, key, strconv.FormatFloat(samples[key], 'g', -1, 64))
+	}
+}
+
+// WriteFile writes the metrics for the node_exporter textfile collector. The
+// file is replaced atomically so the collector never reads a partial file.
+func (r *Registry) WriteFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := r.Write(tmp, false); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	return nil
+}
+
+// Load reads a metrics file written earlier. Its counters are added to, so
+// that they keep increasing across the separate runs that rewrite the file,
+// and its gauges are kept for configs that do not run. A missing file is not
+// an error.
+func (r *Registry) Load(path string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read metrics: %w", err)
+	}
+	defer file.Close()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		name, rest, found := strings.Cut(scanner.Text(), "{")
+		if !found {
+			continue
+		}
+		key, value, found := strings.Cut(rest, "} ")
+		if !found {
+			continue
+		}
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+
+		for _, f := range counterFamilies {
+			if f.name == name {
+				r.add(f, key, number)
+			}
+		}
+		for _, f := range gaugeFamilies {
+			if f.name == name {
+				if r.loaded[name] == nil {
+					r.loaded[name] = make(map[string]float64)
+				}
+				r.loaded[name][key] = number
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// labels formats name/value pairs as a label set, escaping values as the
+// exposition formats require.
+func labels(pairs ...string) string {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, pairs[i], escape.Replace(pairs[i+1])))
+	}
+	return strings.Join(parts, ",")
+}
+
+// ServeHTTP serves the metrics to scrapers, in OpenMetrics when the scraper
+// accepts it.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	openMetrics := strings.Contains(req.Header.Get("Accept"), "application/openmetrics-text")
+	if openMetrics {
+		w.Header().Set("Content-Type", OpenMetricsContentType)
+	} else {
+		w.Header().Set("Content-Type", ContentType)
+	}
+	r.Write(w, openMetrics)
+}
+
+// labelValue returns the value of a label in a label set written by labels.
+func labelValue(key, name string) string {
+	_, rest, found := strings.Cut(","+key, ","+name+`="`)
+	if !found {
+		return ""
+	}
+	var value strings.Builder
+	for i := 0; i < len(rest); i++ {
+		switch rest[i] {
+		case '"':
+			return value.String()
+		case '\\':
+			i++
+			if i < len(rest) && rest[i] == 'n' {
+				value.WriteByte('\n')
+			} else if i < len(rest) {
+				value.WriteByte(rest[i])
+			}
+		default:
+			value.WriteByte(rest[i])
+		}
+	}
+	return value.String()
+}
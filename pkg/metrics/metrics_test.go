@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+func testRun() Run {
+	api := types.EnrichedCommit{SHA: "abc123", AuthorEmail: "john@example.com", Repository: "api"}
+	generated := types.SnapEvent{ID: "evt-1", Attributes: map[string]interface{}{"request_type": "code_generation"}}
+	untyped := types.SnapEvent{ID: "evt-2"}
+
+	return Run{
+		Config: "ai-inference",
+		Results: []types.CorrelationResult{
+			{Event: generated, Commit: api},
+			{Event: generated, Commit: types.EnrichedCommit{SHA: "def456", AuthorEmail: "john@example.com", Repository: "api"}},
+			{Event: untyped, Commit: api},
+		},
+		EventsRead:  5,
+		ParseErrors: 2,
+		TypeKey:     "request_type",
+		Started:     time.Unix(1700000000, 0),
+		Duration:    1500 * time.Millisecond,
+	}
+}
+
+func TestRegistryWrite(t *testing.T) {
+	registry := NewRegistry()
+	registry.Record(testRun())
+	registry.Record(Run{Config: "ai-inference", Err: fmt.Errorf("no repository")})
+
+	var buf bytes.Buffer
+	if err := registry.Write(&buf, false); err != nil {
+		t.Fatalf("Failed to write metrics: %v", err)
+	}
+	text := buf.String()
+
+	for _, expected := range []string{
+		"# TYPE git_snap_runs_total counter\ngit_snap_runs_total{config=\"ai-inference\"} 2\n",
+		"git_snap_run_errors_total{config=\"ai-inference\"} 1\n",
+		"git_snap_parse_errors_total{config=\"ai-inference\"} 2\n",
+		"git_snap_events_read{config=\"ai-inference\"} 5\n",
+		`git_snap_correlated_events{config="ai-inference",repository="api",author="john@example.com",event_type="code_generation"} 1` + "\n",
+		`git_snap_correlated_events{config="ai-inference",repository="api",author="john@example.com",event_type="untyped"} 1` + "\n",
+		`git_snap_correlated_commits{config="ai-inference",repository="api",author="john@example.com",event_type="code_generation"} 2` + "\n",
+		"git_snap_last_run_duration_seconds{config=\"ai-inference\"} 1.5\n",
+		"git_snap_last_run_timestamp_seconds{config=\"ai-inference\"} 1.7000000015e+09\n",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", expected, text)
+		}
+	}
+	if strings.Contains(text, "# EOF") {
+		t.Errorf("Expected no EOF marker in the text format")
+	}
+
+	buf.Reset()
+	if err := registry.Write(&buf, true); err != nil {
+		t.Fatalf("Failed to write metrics: %v", err)
+	}
+	if text := buf.String(); !strings.Contains(text, "# TYPE git_snap_runs counter\ngit_snap_runs_total{") || !strings.HasSuffix(text, "# EOF\n") {
+		t.Errorf("Unexpected OpenMetrics output:\n%s", text)
+	}
+}
+
+func TestRegistryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "git-snap.prom")
+
+	first := NewRegistry()
+	if err := first.Load(path); err != nil {
+		t.Fatalf("Expected a missing file to be ignored, got %v", err)
+	}
+	first.Record(testRun())
+	if err := first.WriteFile(path); err != nil {
+		t.Fatalf("Failed to write metrics file: %v", err)
+	}
+
+	second := NewRegistry()
+	if err := second.Load(path); err != nil {
+		t.Fatalf("Failed to load metrics file: %v", err)
+	}
+	second.Record(Run{Config: "ai-inference", ParseErrors: 1, Err: fmt.Errorf("no repository")})
+	if err := second.WriteFile(path); err != nil {
+		t.Fatalf("Failed to write metrics file: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read metrics file: %v", err)
+	}
+	text := string(data)
+	for _, expected := range []string{
+		"git_snap_runs_total{config=\"ai-inference\"} 2\n",
+		"git_snap_parse_errors_total{config=\"ai-inference\"} 3\n",
+		"git_snap_events_read{config=\"ai-inference\"} 5\n",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected metrics file to contain %q, got:\n%s", expected, text)
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("Expected only the metrics file to remain, got %v", entries)
+	}
+}
+
+func TestRegistryServeHTTP(t *testing.T) {
+	registry := NewRegistry()
+	registry.Record(testRun())
+
+	recorder := httptest.NewRecorder()
+	registry.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	if recorder.Header().Get("Content-Type") != ContentType || !strings.Contains(recorder.Body.String(), "git_snap_runs_total") {
+		t.Errorf("Unexpected response: %s\n%s", recorder.Header().Get("Content-Type"), recorder.Body.String())
+	}
+
+	request := httptest.NewRequest("GET", "/metrics", nil)
+	request.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	recorder = httptest.NewRecorder()
+	registry.ServeHTTP(recorder, request)
+	if recorder.Header().Get("Content-Type") != OpenMetricsContentType || !strings.HasSuffix(recorder.Body.String(), "# EOF\n") {
+		t.Errorf("Expected OpenMetrics when accepted, got %s", recorder.Header().Get("Content-Type"))
+	}
+}
+
+func TestLabelValue(t *testing.T) {
+	key := labels("config", `a"b\c`, "author", "x")
+	if value := labelValue(key, "config"); value != `a"b\c` {
+		t.Errorf("Expected escaped value to round-trip, got %q", value)
+	}
+	if value := labelValue(key, "author"); value != "x" {
+		t.Errorf("Expected x, got %q", value)
+	}
+}
@@ -0,0 +1,225 @@
+package notes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/git"
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+const NoteVersion = 1
+
+type CommitNote struct {
+	Version      int               `json:"version"`
+	Correlations []NoteCorrelation `json:"correlations"`
+}
+
+type NoteCorrelation struct {
+	EventID         string                 `json:"event_id"`
+	EventTimestamp  time.Time              `json:"event_timestamp"`
+	Attributes      map[string]interface{} `json:"attributes,omitempty"`
+	Config          string                 `json:"config,omitempty"`
+	Score           float64                `json:"score"`
+	Matches         map[string]bool        `json:"matches,omitempty"`
+	TimeDelta       string                 `json:"time_delta"`
+	LineAttribution float64                `json:"line_attribution,omitempty"`
+}
+
+type Store struct {
+	client *git.GitClient
+	ref    string
+}
+
+func NewStore(client *git.GitClient, ref string) *Store {
+	if ref == "" {
+		ref = git.DefaultNotesRef
+	}
+	return &Store{client: client, ref: ref}
+}
+
+func FromResults(results []types.CorrelationResult, configName string) map[string]CommitNote {
+	notes := make(map[string]CommitNote)
+
+	for _, result := range results {
+		note := notes[result.Commit.SHA]
+		note.Version = NoteVersion
+		note.Correlations = append(note.Correlations, NoteCorrelation{
+			EventID:         result.Event.ID,
+			EventTimestamp:  result.Event.Timestamp,
+			Attributes:      result.Event.Attributes,
+			Config:          configName,
+			Score:           result.Score,
+			Matches:         result.Matches,
+			TimeDelta:       result.TimeDelta.String(),
+			LineAttribution: result.LineAttribution,
+		})
+		notes[result.Commit.SHA] = note
+	}
+
+	for sha, note := range notes {
+		notes[sha] = Merge(CommitNote{}, note)
+	}
+
+	return notes
+}
+
+func Merge(existing, incoming CommitNote) CommitNote {
+	byKey := make(map[string]NoteCorrelation)
+	for _, correlation := range existing.Correlations {
+		byKey[correlation.key()] = correlation
+	}
+	for _, correlation := range incoming.Correlations {
+		byKey[correlation.key()] = correlation
+	}
+
+	merged := CommitNote{
+		Version:      NoteVersion,
+		Correlations: make([]NoteCorrelation, 0, len(byKey)),
+	}
+	for _, correlation := range byKey {
+		merged.Correlations = append(merged.Correlations, correlation)
+	}
+
+	sort.Slice(merged.Correlations, func(i, j int) bool {
+		a, b := merged.Correlations[i], merged.Correlations[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.key() < b.key()
+	})
+
+	return merged
+}
+
+// key identifies the event by its ID, or by its timestamp when it has none,
+// so that events without an ID are kept apart like in the output envelope.
+func (c NoteCorrelation) key() string {
+	event := types.SnapEvent{ID: c.EventID, Timestamp: c.EventTimestamp}
+	return c.Config + "\x00" + event.Key()
+}
+
+func (s *Store) Read(sha string) (*CommitNote, error) {
+	content, exists, err := s.client.ReadNote(s.ref, sha)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	return decodeNote(sha, content)
+}
+
+func (s *Store) ReadAll() (map[string]CommitNote, error) {
+	blobs, err := s.client.ListNotes(s.ref)
+	if err != nil {
+		return nil, err
+	}
+
+	notes := make(map[string]CommitNote, len(blobs))
+	for sha, blob := range blobs {
+		content, err := s.client.ReadBlob(blob)
+		if err != nil {
+			return nil, err
+		}
+
+		note, err := decodeNote(sha, content)
+		if err != nil {
+			return nil, err
+		}
+		notes[sha] = *note
+	}
+
+	return notes, nil
+}
+
+func (s *Store) Write(notes map[string]CommitNote) (int, error) {
+	existing, err := s.ReadAll()
+	if err != nil {
+		return 0, err
+	}
+
+	shas := make([]string, 0, len(notes))
+	for sha := range notes {
+		shas = append(shas, sha)
+	}
+	sort.Strings(shas)
+
+	written := 0
+	for _, sha := range shas {
+		current, hasCurrent := existing[sha]
+		merged := Merge(current, notes[sha])
+
+		content, err := json.MarshalIndent(merged, "", "  ")
+		if err != nil {
+			return written, fmt.Errorf("failed to encode note for %s: %w", sha, err)
+		}
+
+		if hasCurrent && sameNote(current, content) {
+			continue
+		}
+
+		if err := s.client.WriteNote(s.ref, sha, string(content)+"\n"); err != nil {
+			return written, err
+		}
+		written++
+	}
+
+	return written, nil
+}
+
+func ToResults(notes map[string]CommitNote) []types.CorrelationResult {
+	var results []types.CorrelationResult
+
+	for sha, note := range notes {
+		for _, correlation := range note.Correlations {
+			timeDelta, _ := time.ParseDuration(correlation.TimeDelta)
+			results = append(results, types.CorrelationResult{
+				Event: types.SnapEvent{
+					ID:         correlation.EventID,
+					Timestamp:  correlation.EventTimestamp,
+					Attributes: correlation.Attributes,
+				},
+				Commit:          types.EnrichedCommit{SHA: sha},
+				Score:           correlation.Score,
+				Matches:         correlation.Matches,
+				TimeDelta:       timeDelta,
+				LineAttribution: correlation.LineAttribution,
+			})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Commit.SHA < results[j].Commit.SHA
+	})
+
+	return results
+}
+
+func decodeNote(sha, content string) (*CommitNote, error) {
+	var note CommitNote
+	if err := json.Unmarshal([]byte(content), &note); err != nil {
+		return nil, fmt.Errorf("invalid git-snap note on %s: %w", sha, err)
+	}
+	return &note, nil
+}
+
+// sameNote compares through a JSON round trip so that notes read from git and
+// notes built in memory compare equal regardless of numeric attribute types.
+func sameNote(current CommitNote, content []byte) bool {
+	var incoming CommitNote
+	if err := json.Unmarshal(content, &incoming); err != nil {
+		return false
+	}
+
+	a, errA := json.Marshal(current)
+	b, errB := json.Marshal(incoming)
+	return errA == nil && errB == nil && bytes.Equal(a, b)
+}
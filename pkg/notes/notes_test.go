@@ -0,0 +1,133 @@
+package notes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+func TestFromResults(t *testing.T) {
+	results := []types.CorrelationResult{
+		{
+			Event:     types.SnapEvent{ID: "event1"},
+			Commit:    types.EnrichedCommit{SHA: "abc123"},
+			Score:     0.6,
+			TimeDelta: 5 * time.Minute,
+		},
+		{
+			Event:     types.SnapEvent{ID: "event2"},
+			Commit:    types.EnrichedCommit{SHA: "abc123"},
+			Score:     0.9,
+			TimeDelta: time.Minute,
+		},
+		{
+			Event:  types.SnapEvent{ID: "event3"},
+			Commit: types.EnrichedCommit{SHA: "def456"},
+			Score:  0.7,
+		},
+	}
+
+	notes := FromResults(results, "ai-inference")
+
+	if len(notes) != 2 {
+		t.Fatalf("Expected 2 notes, got %d", len(notes))
+	}
+
+	note := notes["abc123"]
+	if note.Version != NoteVersion {
+		t.Errorf("Expected note version %d, got %d", NoteVersion, note.Version)
+	}
+
+	if len(note.Correlations) != 2 || note.Correlations[0].EventID != "event2" {
+		t.Errorf("Expected correlations sorted by score, got %+v", note.Correlations)
+	}
+
+	if note.Correlations[1].TimeDelta != "5m0s" {
+		t.Errorf("Expected time delta to be '5m0s', got %s", note.Correlations[1].TimeDelta)
+	}
+
+	if note.Correlations[0].Config != "ai-inference" {
+		t.Errorf("Expected config to be 'ai-inference', got %s", note.Correlations[0].Config)
+	}
+}
+
+func TestFromResultsKeepsEventsWithoutID(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	commit := types.EnrichedCommit{SHA: "abc123"}
+	results := []types.CorrelationResult{
+		{Event: types.SnapEvent{Timestamp: start}, Commit: commit, Score: 0.6},
+		{Event: types.SnapEvent{Timestamp: start.Add(time.Minute)}, Commit: commit, Score: 0.9},
+	}
+
+	note := FromResults(results, "ai-inference")["abc123"]
+	if len(note.Correlations) != 2 {
+		t.Errorf("Expected both events without ID to be kept, got %+v", note.Correlations)
+	}
+
+	merged := Merge(note, FromResults(results[:1], "ai-inference")["abc123"])
+	if len(merged.Correlations) != 2 {
+		t.Errorf("Expected merging the same event again to keep 2 correlations, got %+v", merged.Correlations)
+	}
+}
+
+func TestMergeIsIdempotent(t *testing.T) {
+	existing := CommitNote{
+		Version: NoteVersion,
+		Correlations: []NoteCorrelation{
+			{EventID: "event1", Score: 0.5},
+			{EventID: "event2", Score: 0.8},
+		},
+	}
+
+	incoming := CommitNote{
+		Version: NoteVersion,
+		Correlations: []NoteCorrelation{
+			{EventID: "event1", Score: 0.9},
+			{EventID: "event3", Score: 0.7},
+		},
+	}
+
+	merged := Merge(existing, incoming)
+	if len(merged.Correlations) != 3 {
+		t.Fatalf("Expected 3 correlations, got %d", len(merged.Correlations))
+	}
+
+	if merged.Correlations[0].EventID != "event1" || merged.Correlations[0].Score != 0.9 {
+		t.Errorf("Expected incoming correlation to replace existing, got %+v", merged.Correlations[0])
+	}
+
+	again := Merge(merged, incoming)
+	if len(again.Correlations) != len(merged.Correlations) {
+		t.Fatalf("Expected merge to be idempotent, got %d correlations", len(again.Correlations))
+	}
+	for i := range again.Correlations {
+		if again.Correlations[i].EventID != merged.Correlations[i].EventID {
+			t.Errorf("Expected identical order after re-merge, got %s at %d", again.Correlations[i].EventID, i)
+		}
+	}
+}
+
+func TestToResults(t *testing.T) {
+	notes := map[string]CommitNote{
+		"abc123": {
+			Version: NoteVersion,
+			Correlations: []NoteCorrelation{
+				{EventID: "event1", Score: 0.9, TimeDelta: "2m0s"},
+			},
+		},
+	}
+
+	results := ToResults(notes)
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+
+	if results[0].Commit.SHA != "abc123" || results[0].Event.ID != "event1" {
+		t.Errorf("Unexpected result: %+v", results[0])
+	}
+
+	if results[0].TimeDelta != 2*time.Minute {
+		t.Errorf("Expected time delta to be 2m, got %v", results[0].TimeDelta)
+	}
+}
@@ -0,0 +1,204 @@
+package output
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/correlation"
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+const DefaultColumns = "score,event.id,commit.sha,delta,commit.author,match.*"
+
+// Column is one field of a correlation result in tabular output.
+type Column struct {
+	Name  string
+	value func(types.CorrelationResult) interface{}
+}
+
+func (c Column) Value(result types.CorrelationResult) interface{} {
+	return c.value(result)
+}
+
+// ParseColumns resolves a comma-separated column list. Recognised names are:
+//
+//	score, delta, delta_seconds
+//	event.id, event.timestamp, event.<attribute>, metadata.<key>
+//	commit.<field>, e.g. commit.sha, commit.author_email, commit.files
+//	match.<event key>, and match.* for every rule present in results
+func ParseColumns(spec string, results []types.CorrelationResult) ([]Column, error) {
+	var columns []Column
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if name == "match.*" {
+			for _, key := range matchKeys(results) {
+				columns = append(columns, matchColumn(key))
+			}
+			continue
+		}
+
+		column, err := parseColumn(name)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no output columns selected")
+	}
+	return columns, nil
+}
+
+// ValidateColumns checks a column list before any results are available.
+// match.* is accepted as is, since its columns depend on the results.
+func ValidateColumns(spec string) error {
+	selected := false
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		selected = true
+		if name == "match.*" {
+			continue
+		}
+		if _, err := parseColumn(name); err != nil {
+			return err
+		}
+	}
+
+	if !selected {
+		return fmt.Errorf("no output columns selected")
+	}
+	return nil
+}
+
+func parseColumn(name string) (Column, error) {
+	column := Column{Name: name}
+	prefix, key, _ := strings.Cut(name, ".")
+
+	switch {
+	case name == "score":
+		column.value = func(r types.CorrelationResult) interface{} { return r.Score }
+	case name == "delta":
+		column.value = func(r types.CorrelationResult) interface{} { return r.TimeDelta.Round(time.Second).String() }
+	case name == "delta_seconds":
+		column.value = func(r types.CorrelationResult) interface{} { return r.TimeDelta.Seconds() }
+	case name == "event.id":
+		column.value = func(r types.CorrelationResult) interface{} { return r.Event.ID }
+	case name == "event.timestamp":
+		column.value = func(r types.CorrelationResult) interface{} { return r.Event.Timestamp.Format(time.RFC3339) }
+	case prefix == "event" && key != "":
+		column.value = func(r types.CorrelationResult) interface{} { return r.Event.Attributes[key] }
+	case prefix == "metadata" && key != "":
+		column.value = func(r types.CorrelationResult) interface{} { return r.Event.Metadata[key] }
+	case prefix == "commit" && key != "":
+		if !isCommitField(key) {
+			return Column{}, fmt.Errorf("unknown column %s: commit fields are timestamp, %s", name, strings.Join(correlation.CommitKeys, ", "))
+		}
+		column.value = func(r types.CorrelationResult) interface{} { return CommitField(r.Commit, key) }
+	case prefix == "match" && key != "":
+		return matchColumn(key), nil
+	default:
+		return Column{}, fmt.Errorf("unknown column %s", name)
+	}
+
+	return column, nil
+}
+
+func matchColumn(key string) Column {
+	return Column{
+		Name: "match." + key,
+		value: func(r types.CorrelationResult) interface{} {
+			if matched, exists := r.Matches[key]; exists {
+				return matched
+			}
+			return nil
+		},
+	}
+}
+
+func matchKeys(results []types.CorrelationResult) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, result := range results {
+		for key := range result.Matches {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isCommitField(key string) bool {
+	if key == "timestamp" {
+		return true
+	}
+	for _, field := range correlation.CommitKeys {
+		if field == key {
+			return true
+		}
+	}
+	return false
+}
+
+// CommitField returns a commit field by its rule key, keeping numbers
+// numeric. A missing PR number is nil.
+func CommitField(commit types.EnrichedCommit, key string) interface{} {
+	switch key {
+	case "sha":
+		return commit.SHA
+	case "author":
+		return commit.Author
+	case "author_email":
+		return commit.AuthorEmail
+	case "committer":
+		return commit.Committer
+	case "timestamp":
+		return commit.Timestamp.Format(time.RFC3339)
+	case "message":
+		return commit.Message
+	case "repository":
+		return commit.Repository
+	case "branch":
+		return commit.Branch
+	case "pr_number":
+		if commit.PRNumber != nil {
+			return *commit.PRNumber
+		}
+		return nil
+	case "additions":
+		return commit.Additions
+	case "deletions":
+		return commit.Deletions
+	case "files":
+		return strings.Join(commit.Files, ",")
+	default:
+		return nil
+	}
+}
+
+// formatValue renders a column value as text: nil is empty and floats are
+// rounded to six decimals without trailing zeros.
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.6f", v), "0"), ".")
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,523 @@
+package output
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/stats"
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+// SchemaVersion is the version of the JSON output envelope. It changes only
+// when a field is removed or changes meaning; new optional fields keep it.
+const SchemaVersion = 1
+
+// Schema is the JSON Schema of the envelope, also published as
+// pkg/output/schema/output.v1.schema.json.
+//
+//go:embed schema/output.v1.schema.json
+var Schema []byte
+
+// Envelope is the JSON output of correlate. Events and commits are listed
+// once and results refer to them by event ID and commit SHA. Every part has
+// its own type here, so the output does not depend on the layout of the
+// internal types.
+type Envelope struct {
+	SchemaVersion       int                 `json:"schema_version"`
+	Run                 RunInfo             `json:"run"`
+	Config              ConfigSnapshot      `json:"config"`
+	Events              []EnvelopeEvent     `json:"events"`
+	Commits             []EnvelopeCommit    `json:"commits"`
+	Results             []EnvelopeResult    `json:"results"`
+	Groups              []EnvelopeGroup     `json:"groups,omitempty"`
+	UnmatchedEvents     []EnvelopeUnmatched `json:"unmatched_events,omitempty"`
+	UncorrelatedCommits []string            `json:"uncorrelated_commits,omitempty"`
+	Summary             *EnvelopeSummary    `json:"summary,omitempty"`
+}
+
+type RunInfo struct {
+	Tool       string    `json:"tool"`
+	Version    string    `json:"version"`
+	StartedAt  time.Time `json:"started_at"`
+	Repository string    `json:"repository"`
+	ConfigName string    `json:"config_name"`
+	Threshold  float64   `json:"threshold"`
+	Since      time.Time `json:"since"`
+	Sources    []string  `json:"sources"`
+}
+
+type ConfigSnapshot struct {
+	TimeWindowSeconds float64            `json:"time_window_seconds"`
+	AttributeRules    []RuleSnapshot     `json:"attribute_rules"`
+	ScoreWeights      map[string]float64 `json:"score_weights"`
+}
+
+type RuleSnapshot struct {
+	EventKey  string `json:"event_key"`
+	CommitKey string `json:"commit_key"`
+	MatchType string `json:"match_type"`
+	Required  bool   `json:"required"`
+}
+
+// EnvelopeEvent is an event. Events without an ID are listed under @ and
+// their UTC timestamp, followed by #2, #3 and so on when distinct events share
+// a timestamp, so every event can be referenced.
+type EnvelopeEvent struct {
+	ID         string                 `json:"id"`
+	Timestamp  time.Time              `json:"timestamp"`
+	Attributes map[string]interface{} `json:"attributes"`
+	Metadata   map[string]interface{} `json:"metadata"`
+}
+
+type EnvelopeCommit struct {
+	SHA         string    `json:"sha"`
+	Author      string    `json:"author"`
+	AuthorEmail string    `json:"author_email"`
+	Committer   string    `json:"committer"`
+	Timestamp   time.Time `json:"timestamp"`
+	Message     string    `json:"message"`
+	Files       []string  `json:"files"`
+	Branch      string    `json:"branch"`
+	Repository  string    `json:"repository"`
+	PRNumber    *int      `json:"pr_number"`
+	Additions   int       `json:"additions"`
+	Deletions   int       `json:"deletions"`
+	Parents     []string  `json:"parents"`
+}
+
+type EnvelopeResult struct {
+	EventID          string              `json:"event_id"`
+	CommitSHA        string              `json:"commit_sha"`
+	Score            float64             `json:"score"`
+	TimeDeltaSeconds float64             `json:"time_delta_seconds"`
+	Matches          map[string]bool     `json:"matches"`
+	HunkMatches      []EnvelopeHunkMatch `json:"hunk_matches,omitempty"`
+	AttributedLines  int                 `json:"attributed_lines,omitempty"`
+	LineAttribution  float64             `json:"line_attribution,omitempty"`
+}
+
+// EnvelopeHunkMatch is a diff hunk whose added lines were attributed to the
+// event of a result.
+type EnvelopeHunkMatch struct {
+	File      string `json:"file"`
+	NewStart  int    `json:"new_start"`
+	NewLines  int    `json:"new_lines"`
+	MatchedBy string `json:"matched_by"`
+}
+
+type EnvelopeUnmatched struct {
+	EventID     string   `json:"event_id"`
+	Reason      string   `json:"reason"`
+	Candidates  int      `json:"candidates"`
+	FailedRules []string `json:"failed_rules,omitempty"`
+	BestScore   float64  `json:"best_score,omitempty"`
+}
+
+// EnvelopeGroup aggregates the results of a commit, event or author. It
+// refers to its events and commits by key, like results do.
+type EnvelopeGroup struct {
+	Key          string             `json:"key"`
+	Author       string             `json:"author,omitempty"`
+	AuthorEmail  string             `json:"author_email,omitempty"`
+	Correlations int                `json:"correlations"`
+	BestScore    float64            `json:"best_score"`
+	MeanScore    float64            `json:"mean_score"`
+	Events       []string           `json:"events"`
+	Commits      []string           `json:"commits"`
+	EventTypes   []string           `json:"event_types"`
+	Totals       map[string]float64 `json:"totals"`
+}
+
+// EnvelopeSummary holds the summary statistics of a run.
+type EnvelopeSummary struct {
+	Events            int                  `json:"events"`
+	MatchedEvents     int                  `json:"matched_events"`
+	MatchRate         float64              `json:"match_rate"`
+	Commits           int                  `json:"commits"`
+	CorrelatedCommits int                  `json:"correlated_commits"`
+	CommitCoverage    float64              `json:"commit_coverage"`
+	Correlations      int                  `json:"correlations"`
+	Score             EnvelopeDistribution `json:"score"`
+	TimeDelta         EnvelopeDistribution `json:"time_delta_seconds"`
+	Rules             []EnvelopeRuleStats  `json:"rules"`
+	Authors           []EnvelopeCoverage   `json:"authors"`
+	Repositories      []EnvelopeCoverage   `json:"repositories"`
+	Ambiguity         EnvelopeAmbiguity    `json:"ambiguity"`
+}
+
+type EnvelopeDistribution struct {
+	Min  float64 `json:"min"`
+	P25  float64 `json:"p25"`
+	P50  float64 `json:"p50"`
+	P75  float64 `json:"p75"`
+	P90  float64 `json:"p90"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+	Mean float64 `json:"mean"`
+}
+
+type EnvelopeRuleStats struct {
+	Rule      string  `json:"rule"`
+	Evaluated int     `json:"evaluated"`
+	Matched   int     `json:"matched"`
+	HitRate   float64 `json:"hit_rate"`
+}
+
+type EnvelopeCoverage struct {
+	Name              string  `json:"name"`
+	Commits           int     `json:"commits"`
+	CorrelatedCommits int     `json:"correlated_commits"`
+	Coverage          float64 `json:"coverage"`
+	Events            int     `json:"events"`
+	Correlations      int     `json:"correlations"`
+}
+
+type EnvelopeAmbiguity struct {
+	Margin float64  `json:"margin"`
+	Events int      `json:"events"`
+	Rate   float64  `json:"rate"`
+	IDs    []string `json:"event_ids"`
+}
+
+// EnvelopeOptions carries the optional parts of an envelope.
+type EnvelopeOptions struct {
+	Groups              []Group
+	UnmatchedEvents     []types.UnmatchedEvent
+	UncorrelatedCommits []types.EnrichedCommit
+	Summary             *stats.Stats
+}
+
+func NewEnvelope(run RunInfo, config types.SnapConfig, results []types.CorrelationResult, opts EnvelopeOptions) Envelope {
+	if run.Tool == "" {
+		run.Tool = "git-snap"
+	}
+	if run.Sources == nil {
+		run.Sources = []string{}
+	}
+
+	envelope := Envelope{
+		SchemaVersion: SchemaVersion,
+		Run:           run,
+		Config:        snapshotConfig(config),
+		Events:        []EnvelopeEvent{},
+		Commits:       []EnvelopeCommit{},
+		Results:       make([]EnvelopeResult, len(results)),
+		Summary:       newEnvelopeSummary(opts.Summary),
+	}
+
+	// An event without an ID appears in every result it correlated with, so
+	// it is recognised by its content and listed once.
+	seenEvents := make(map[string]bool)
+	keysByContent := make(map[string]string)
+	addEvent := func(event types.SnapEvent) string {
+		id := event.ID
+		if id == "" {
+			content := eventContent(event)
+			if key, seen := keysByContent[content]; seen {
+				return key
+			}
+			id = event.Key()
+			for n := 2; seenEvents[id]; n++ {
+				id = fmt.Sprintf("%s#%d", event.Key(), n)
+			}
+			keysByContent[content] = id
+		}
+		if !seenEvents[id] {
+			seenEvents[id] = true
+			envelope.Events = append(envelope.Events, EnvelopeEvent{
+				ID:         id,
+				Timestamp:  event.Timestamp,
+				Attributes: emptyIfNil(event.Attributes),
+				Metadata:   emptyIfNil(event.Metadata),
+			})
+		}
+		return id
+	}
+	seenCommits := make(map[string]bool)
+	addCommit := func(commit types.EnrichedCommit) string {
+		if !seenCommits[commit.SHA] {
+			seenCommits[commit.SHA] = true
+			envelope.Commits = append(envelope.Commits, newEnvelopeCommit(commit))
+		}
+		return commit.SHA
+	}
+
+	for i, result := range results {
+		matches := result.Matches
+		if matches == nil {
+			matches = map[string]bool{}
+		}
+		envelope.Results[i] = EnvelopeResult{
+			EventID:          addEvent(result.Event),
+			CommitSHA:        addCommit(result.Commit),
+			Score:            result.Score,
+			TimeDeltaSeconds: result.TimeDelta.Seconds(),
+			Matches:          matches,
+			HunkMatches:      newEnvelopeHunkMatches(result.HunkMatches),
+			AttributedLines:  result.AttributedLines,
+			LineAttribution:  result.LineAttribution,
+		}
+	}
+
+	for _, unmatched := range opts.UnmatchedEvents {
+		envelope.UnmatchedEvents = append(envelope.UnmatchedEvents, EnvelopeUnmatched{
+			EventID:     addEvent(unmatched.Event),
+			Reason:      unmatched.Reason,
+			Candidates:  unmatched.Candidates,
+			FailedRules: unmatched.FailedRules,
+			BestScore:   unmatched.BestScore,
+		})
+	}
+	for _, commit := range opts.UncorrelatedCommits {
+		envelope.UncorrelatedCommits = append(envelope.UncorrelatedCommits, addCommit(commit))
+	}
+
+	for _, group := range opts.Groups {
+		envelope.Groups = append(envelope.Groups, EnvelopeGroup{
+			Key:          group.Key,
+			Author:       group.Author,
+			AuthorEmail:  group.AuthorEmail,
+			Correlations: group.Correlations,
+			BestScore:    group.BestScore,
+			MeanScore:    group.MeanScore,
+			Events:       group.Events,
+			Commits:      group.Commits,
+			EventTypes:   group.EventTypes,
+			Totals:       group.Totals,
+		})
+	}
+
+	return envelope
+}
+
+// Correlations rebuilds the results of an envelope with their events and
+// commits, as the commands reading stored results expect them.
+func (e Envelope) Correlations() []types.CorrelationResult {
+	events, commits := e.eventsByID(), e.commitsBySHA()
+
+	results := make([]types.CorrelationResult, len(e.Results))
+	for i, result := range e.Results {
+		results[i] = types.CorrelationResult{
+			Event:           events[result.EventID],
+			Commit:          commits[result.CommitSHA],
+			Score:           result.Score,
+			Matches:         result.Matches,
+			TimeDelta:       time.Duration(result.TimeDeltaSeconds * float64(time.Second)),
+			HunkMatches:     hunkMatches(result.HunkMatches),
+			AttributedLines: result.AttributedLines,
+			LineAttribution: result.LineAttribution,
+		}
+	}
+	return results
+}
+
+// Unmatched rebuilds the unmatched events of an envelope.
+func (e Envelope) Unmatched() []types.UnmatchedEvent {
+	events := e.eventsByID()
+
+	unmatched := make([]types.UnmatchedEvent, len(e.UnmatchedEvents))
+	for i, event := range e.UnmatchedEvents {
+		unmatched[i] = types.UnmatchedEvent{
+			Event:       events[event.EventID],
+			Reason:      event.Reason,
+			Candidates:  event.Candidates,
+			FailedRules: event.FailedRules,
+			BestScore:   event.BestScore,
+		}
+	}
+	return unmatched
+}
+
+// Uncorrelated rebuilds the uncorrelated commits of an envelope.
+func (e Envelope) Uncorrelated() []types.EnrichedCommit {
+	commits := e.commitsBySHA()
+
+	uncorrelated := make([]types.EnrichedCommit, len(e.UncorrelatedCommits))
+	for i, sha := range e.UncorrelatedCommits {
+		uncorrelated[i] = commits[sha]
+	}
+	return uncorrelated
+}
+
+// eventsByID restores events by their key. An event listed under a key
+// derived from its timestamp had no ID, and gets none back.
+func (e Envelope) eventsByID() map[string]types.SnapEvent {
+	events := make(map[string]types.SnapEvent, len(e.Events))
+	for _, event := range e.Events {
+		id := event.ID
+		if isTimestampKey(id, event.Timestamp) {
+			id = ""
+		}
+		events[event.ID] = types.SnapEvent{
+			ID:         id,
+			Timestamp:  event.Timestamp,
+			Attributes: event.Attributes,
+			Metadata:   event.Metadata,
+		}
+	}
+	return events
+}
+
+// isTimestampKey reports whether id is the key NewEnvelope gives an event
+// without an ID at timestamp: @ and the timestamp, optionally followed by #n.
+func isTimestampKey(id string, timestamp time.Time) bool {
+	rest, found := strings.CutPrefix(id, (types.SnapEvent{Timestamp: timestamp}).Key())
+	if !found {
+		return false
+	}
+	if rest == "" {
+		return true
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(rest, "#"))
+	return strings.HasPrefix(rest, "#") && err == nil && n >= 2
+}
+
+// eventContent identifies an event without an ID by its timestamp,
+// attributes and metadata.
+func eventContent(event types.SnapEvent) string {
+	content, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Sprintf("%#v", event)
+	}
+	return string(content)
+}
+
+func (e Envelope) commitsBySHA() map[string]types.EnrichedCommit {
+	commits := make(map[string]types.EnrichedCommit, len(e.Commits))
+	for _, commit := range e.Commits {
+		commits[commit.SHA] = types.EnrichedCommit{
+			SHA:         commit.SHA,
+			Author:      commit.Author,
+			AuthorEmail: commit.AuthorEmail,
+			Committer:   commit.Committer,
+			Timestamp:   commit.Timestamp,
+			Message:     commit.Message,
+			Files:       commit.Files,
+			Branch:      commit.Branch,
+			Repository:  commit.Repository,
+			PRNumber:    commit.PRNumber,
+			Additions:   commit.Additions,
+			Deletions:   commit.Deletions,
+			Parents:     commit.Parents,
+		}
+	}
+	return commits
+}
+
+func snapshotConfig(config types.SnapConfig) ConfigSnapshot {
+	snapshot := ConfigSnapshot{
+		TimeWindowSeconds: config.TimeWindow.Seconds(),
+		AttributeRules:    make([]RuleSnapshot, len(config.AttributeRules)),
+		ScoreWeights:      make(map[string]float64, len(config.ScoreWeights)),
+	}
+	for i, rule := range config.AttributeRules {
+		snapshot.AttributeRules[i] = RuleSnapshot{
+			EventKey:  rule.EventKey,
+			CommitKey: rule.CommitKey,
+			MatchType: rule.MatchType.String(),
+			Required:  rule.Required,
+		}
+	}
+	for name, weight := range config.ScoreWeights {
+		snapshot.ScoreWeights[name] = weight
+	}
+	return snapshot
+}
+
+func newEnvelopeCommit(commit types.EnrichedCommit) EnvelopeCommit {
+	files := commit.Files
+	if files == nil {
+		files = []string{}
+	}
+	parents := commit.Parents
+	if parents == nil {
+		parents = []string{}
+	}
+	return EnvelopeCommit{
+		SHA:         commit.SHA,
+		Author:      commit.Author,
+		AuthorEmail: commit.AuthorEmail,
+		Committer:   commit.Committer,
+		Timestamp:   commit.Timestamp,
+		Message:     commit.Message,
+		Files:       files,
+		Branch:      commit.Branch,
+		Repository:  commit.Repository,
+		PRNumber:    commit.PRNumber,
+		Additions:   commit.Additions,
+		Deletions:   commit.Deletions,
+		Parents:     parents,
+	}
+}
+
+func newEnvelopeHunkMatches(matches []types.HunkMatch) []EnvelopeHunkMatch {
+	if matches == nil {
+		return nil
+	}
+	converted := make([]EnvelopeHunkMatch, len(matches))
+	for i, match := range matches {
+		converted[i] = EnvelopeHunkMatch(match)
+	}
+	return converted
+}
+
+func hunkMatches(matches []EnvelopeHunkMatch) []types.HunkMatch {
+	if matches == nil {
+		return nil
+	}
+	converted := make([]types.HunkMatch, len(matches))
+	for i, match := range matches {
+		converted[i] = types.HunkMatch(match)
+	}
+	return converted
+}
+
+func newEnvelopeSummary(summary *stats.Stats) *EnvelopeSummary {
+	if summary == nil {
+		return nil
+	}
+
+	converted := &EnvelopeSummary{
+		Events:            summary.Events,
+		MatchedEvents:     summary.MatchedEvents,
+		MatchRate:         summary.MatchRate,
+		Commits:           summary.Commits,
+		CorrelatedCommits: summary.CorrelatedCommits,
+		CommitCoverage:    summary.CommitCoverage,
+		Correlations:      summary.Correlations,
+		Score:             EnvelopeDistribution(summary.Score),
+		TimeDelta:         EnvelopeDistribution(summary.TimeDelta),
+		Authors:           newEnvelopeCoverage(summary.Authors),
+		Repositories:      newEnvelopeCoverage(summary.Repositories),
+		Ambiguity:         EnvelopeAmbiguity(summary.Ambiguity),
+	}
+	if summary.Rules != nil {
+		converted.Rules = make([]EnvelopeRuleStats, len(summary.Rules))
+		for i, rule := range summary.Rules {
+			converted.Rules[i] = EnvelopeRuleStats(rule)
+		}
+	}
+	return converted
+}
+
+func newEnvelopeCoverage(coverages []stats.Coverage) []EnvelopeCoverage {
+	if coverages == nil {
+		return nil
+	}
+	converted := make([]EnvelopeCoverage, len(coverages))
+	for i, coverage := range coverages {
+		converted[i] = EnvelopeCoverage(coverage)
+	}
+	return converted
+}
+
+func emptyIfNil(values map[string]interface{}) map[string]interface{} {
+	if values == nil {
+		return map[string]interface{}{}
+	}
+	return values
+}
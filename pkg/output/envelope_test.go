@@ -0,0 +1,309 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/stats"
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func testEnvelope() Envelope {
+	results := tabularResults()
+	results[0].HunkMatches = []types.HunkMatch{{File: "parser.go", NewStart: 10, NewLines: 4, MatchedBy: "path"}}
+	results[0].AttributedLines = 4
+	results[0].LineAttribution = 0.5
+	// evt-1 also correlates with 789abc, so both are listed once.
+	results = append(results, types.CorrelationResult{
+		Event:     results[0].Event,
+		Commit:    results[1].Commit,
+		Score:     0.25,
+		Matches:   map[string]bool{"user_id": false, "repository": false},
+		TimeDelta: 30 * time.Minute,
+	})
+
+	pr := 42
+	uncorrelated := types.EnrichedCommit{
+		SHA:       "fedcba987654",
+		Author:    "John Doe",
+		Timestamp: time.Date(2024, 1, 15, 8, 0, 0, 0, time.UTC),
+		PRNumber:  &pr,
+		Parents:   []string{"abc123def456"},
+	}
+	unmatched := []types.UnmatchedEvent{{
+		Event:       types.SnapEvent{Timestamp: time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)},
+		Reason:      "required_rule_failed",
+		Candidates:  2,
+		FailedRules: []string{"user_id"},
+	}}
+
+	config := types.SnapConfig{
+		TimeWindow: 4 * time.Hour,
+		AttributeRules: []types.AttributeRule{
+			{EventKey: "user_id", CommitKey: "author", MatchType: types.FUZZY, Required: true},
+			{EventKey: "repository", CommitKey: "repository", MatchType: types.EXACT},
+		},
+		ScoreWeights: map[string]float64{"temporal": 0.4, "attribute": 0.6},
+	}
+	groups, _ := GroupResults(results, GroupByCommit, "request_type")
+	summary := stats.Compute(stats.Input{
+		Results:   results,
+		Commits:   []types.EnrichedCommit{results[0].Commit, results[1].Commit, uncorrelated},
+		Unmatched: unmatched,
+		Events:    3,
+	}, stats.DefaultAmbiguityMargin)
+
+	run := RunInfo{
+		Version:    "v1.2.3",
+		StartedAt:  time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC),
+		Repository: "/src/app",
+		ConfigName: "default",
+		Threshold:  0.1,
+		Since:      time.Date(2024, 1, 8, 11, 0, 0, 0, time.UTC),
+		Sources:    []string{"events.jsonl"},
+	}
+	return NewEnvelope(run, config, results, EnvelopeOptions{
+		Groups:              groups,
+		UnmatchedEvents:     unmatched,
+		UncorrelatedCommits: []types.EnrichedCommit{uncorrelated},
+		Summary:             &summary,
+	})
+}
+
+func TestEnvelopeGolden(t *testing.T) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(testEnvelope()); err != nil {
+		t.Fatalf("Failed to encode envelope: %v", err)
+	}
+
+	golden := filepath.Join("testdata", "envelope.golden.json")
+	if *update {
+		if err := os.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+	}
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("Failed to read golden file (run go test -update to create it): %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("Envelope does not match %s; if the change is intended, run go test ./pkg/output -update\n%s", golden, buf.String())
+	}
+
+	var schema, document interface{}
+	if err := json.Unmarshal(Schema, &schema); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	if err := json.Unmarshal(expected, &document); err != nil {
+		t.Fatalf("Failed to parse golden file: %v", err)
+	}
+	if err := validateSchema(schema.(map[string]interface{}), schema, document, "$"); err != nil {
+		t.Errorf("Golden file does not match schema: %v", err)
+	}
+
+	delete(document.(map[string]interface{})["results"].([]interface{})[0].(map[string]interface{}), "event_id")
+	if err := validateSchema(schema.(map[string]interface{}), schema, document, "$"); err == nil {
+		t.Error("Expected a result without event_id to be rejected by the schema")
+	}
+}
+
+func TestEnvelopeReferences(t *testing.T) {
+	envelope := testEnvelope()
+
+	if len(envelope.Events) != 3 || len(envelope.Commits) != 3 {
+		t.Fatalf("Expected 3 events and 3 commits listed once, got %d and %d", len(envelope.Events), len(envelope.Commits))
+	}
+	if envelope.UnmatchedEvents[0].EventID != "@2024-01-15T12:00:00Z" {
+		t.Errorf("Expected event without ID referenced by timestamp, got %q", envelope.UnmatchedEvents[0].EventID)
+	}
+	shas := make(map[string]bool)
+	for _, commit := range envelope.Commits {
+		shas[commit.SHA] = true
+	}
+	for _, group := range envelope.Groups {
+		if !shas[group.Key] {
+			t.Errorf("Expected group %s to refer to a listed commit", group.Key)
+		}
+	}
+
+	// The envelope round trips to the results it was built from.
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatalf("Failed to marshal envelope: %v", err)
+	}
+	var decoded Envelope
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal envelope: %v", err)
+	}
+	results := decoded.Correlations()
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	if results[0].Event.ID != "evt-1" || results[0].Commit.Author != "John Doe" || results[0].TimeDelta != 90*time.Second {
+		t.Errorf("Unexpected first result: %+v", results[0])
+	}
+	if results[2].Commit.SHA != "789abc" || results[2].Commit.Author != "Jane, Smith" {
+		t.Errorf("Expected shared commit resolved, got %+v", results[2].Commit)
+	}
+	if !reflect.DeepEqual(results[0].HunkMatches, []types.HunkMatch{{File: "parser.go", NewStart: 10, NewLines: 4, MatchedBy: "path"}}) {
+		t.Errorf("Expected hunk matches kept, got %+v", results[0].HunkMatches)
+	}
+
+	unmatched := decoded.Unmatched()
+	if len(unmatched) != 1 || unmatched[0].Event.ID != "" || unmatched[0].Reason != "required_rule_failed" {
+		t.Errorf("Expected unmatched event without ID, got %+v", unmatched)
+	}
+	uncorrelated := decoded.Uncorrelated()
+	if len(uncorrelated) != 1 || uncorrelated[0].PRNumber == nil || *uncorrelated[0].PRNumber != 42 {
+		t.Errorf("Expected uncorrelated commit with PR 42, got %+v", uncorrelated)
+	}
+}
+
+func TestEnvelopeEventsWithoutIDSharingATimestamp(t *testing.T) {
+	at := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	first := types.SnapEvent{Timestamp: at, Attributes: map[string]interface{}{"user_id": "john.doe"}}
+	second := types.SnapEvent{Timestamp: at, Attributes: map[string]interface{}{"user_id": "jane.smith"}}
+	commit := types.EnrichedCommit{SHA: "abc123", Timestamp: at.Add(time.Minute)}
+	other := types.EnrichedCommit{SHA: "def456", Timestamp: at.Add(2 * time.Minute)}
+
+	envelope := NewEnvelope(RunInfo{}, types.SnapConfig{}, []types.CorrelationResult{
+		{Event: first, Commit: commit, Score: 0.9},
+		{Event: second, Commit: commit, Score: 0.8},
+		{Event: first, Commit: other, Score: 0.4},
+	}, EnvelopeOptions{})
+
+	if len(envelope.Events) != 2 {
+		t.Fatalf("Expected 2 distinct events, got %d", len(envelope.Events))
+	}
+	ids := []string{envelope.Results[0].EventID, envelope.Results[1].EventID, envelope.Results[2].EventID}
+	expected := []string{"@2024-01-15T12:00:00Z", "@2024-01-15T12:00:00Z#2", "@2024-01-15T12:00:00Z"}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("Expected event references %v, got %v", expected, ids)
+	}
+
+	results := envelope.Correlations()
+	if results[1].Event.ID != "" || results[1].Event.Attributes["user_id"] != "jane.smith" {
+		t.Errorf("Expected the second event restored without an ID, got %+v", results[1].Event)
+	}
+	if results[2].Event.Attributes["user_id"] != "john.doe" {
+		t.Errorf("Expected the first event shared by two results, got %+v", results[2].Event)
+	}
+}
+
+// validateSchema checks value against the subset of JSON Schema the output
+// schema uses: $ref, type, const, enum, required, properties,
+// additionalProperties and items.
+func validateSchema(root map[string]interface{}, node, value interface{}, path string) error {
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		target := interface{}(root)
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			target = target.(map[string]interface{})[part]
+		}
+		if target == nil {
+			return fmt.Errorf("%s: unresolved $ref %s", path, ref)
+		}
+		return validateSchema(root, target, value, path)
+	}
+
+	if expected, ok := schema["type"]; ok && !matchesType(expected, value) {
+		return fmt.Errorf("%s: expected %v, got %T", path, expected, value)
+	}
+	if expected, ok := schema["const"]; ok && !reflect.DeepEqual(expected, value) {
+		return fmt.Errorf("%s: expected %v, got %v", path, expected, value)
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			found = found || reflect.DeepEqual(allowed, value)
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", path, value, enum)
+		}
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				if _, ok := value[name.(string)]; !ok {
+					return fmt.Errorf("%s: missing %s", path, name)
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, ok := properties[name]
+			if !ok {
+				property = schema["additionalProperties"]
+			}
+			if err := validateSchema(root, property, value[name], path+"."+name); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			if err := validateSchema(root, schema["items"], item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func matchesType(expected, value interface{}) bool {
+	if names, ok := expected.([]interface{}); ok {
+		for _, name := range names {
+			if matchesType(name, value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch expected {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == float64(int64(number))
+	case "null":
+		return value == nil
+	}
+	return false
+}
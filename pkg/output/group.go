@@ -0,0 +1,225 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+const (
+	GroupByCommit = "commit"
+	GroupByEvent  = "event"
+	GroupByAuthor = "author"
+)
+
+// Group aggregates the correlations of one commit, event or author. Totals
+// sums every numeric event attribute, e.g. tokens_used, counting each event
+// once even when it correlated with several commits in the group.
+type Group struct {
+	Key          string                `json:"key"`
+	Commit       *types.EnrichedCommit `json:"commit,omitempty"`
+	Event        *types.SnapEvent      `json:"event,omitempty"`
+	Author       string                `json:"author,omitempty"`
+	AuthorEmail  string                `json:"author_email,omitempty"`
+	Correlations int                   `json:"correlations"`
+	BestScore    float64               `json:"best_score"`
+	MeanScore    float64               `json:"mean_score"`
+	Events       []string              `json:"events"`
+	Commits      []string              `json:"commits"`
+	EventTypes   []string              `json:"event_types"`
+	Totals       map[string]float64    `json:"totals"`
+}
+
+func ValidateGroupBy(by string) error {
+	switch by {
+	case "", GroupByCommit, GroupByEvent, GroupByAuthor:
+		return nil
+	default:
+		return fmt.Errorf("invalid group-by %q: expected commit, event or author", by)
+	}
+}
+
+// GroupResults aggregates results by commit, event or author, ordered by best
+// score and then key. Event types are the distinct values of the typeKey
+// attribute.
+func GroupResults(results []types.CorrelationResult, by, typeKey string) ([]Group, error) {
+	if err := ValidateGroupBy(by); err != nil {
+		return nil, err
+	}
+
+	type accumulator struct {
+		group      *Group
+		totalScore float64
+		events     map[string]bool
+		commits    map[string]bool
+		eventTypes map[string]bool
+	}
+
+	byKey := make(map[string]*accumulator)
+	var order []string
+	for _, result := range results {
+		key := groupKey(result, by)
+
+		acc, exists := byKey[key]
+		if !exists {
+			acc = &accumulator{
+				group:      &Group{Key: key, Totals: make(map[string]float64)},
+				events:     make(map[string]bool),
+				commits:    make(map[string]bool),
+				eventTypes: make(map[string]bool),
+			}
+			switch by {
+			case GroupByCommit:
+				acc.group.Commit = &result.Commit
+			case GroupByEvent:
+				acc.group.Event = &result.Event
+			case GroupByAuthor:
+				acc.group.Author = result.Commit.Author
+				acc.group.AuthorEmail = result.Commit.AuthorEmail
+			}
+			byKey[key] = acc
+			order = append(order, key)
+		}
+
+		group := acc.group
+		group.Correlations++
+		group.BestScore = max(group.BestScore, result.Score)
+		acc.totalScore += result.Score
+
+		if !acc.commits[result.Commit.SHA] {
+			acc.commits[result.Commit.SHA] = true
+			group.Commits = append(group.Commits, result.Commit.SHA)
+		}
+
+		eventKey := result.Event.Key()
+		if acc.events[eventKey] {
+			continue
+		}
+		acc.events[eventKey] = true
+		group.Events = append(group.Events, eventKey)
+
+		if value, exists := result.Event.Attributes[typeKey]; exists && value != nil {
+			acc.eventTypes[fmt.Sprintf("%v", value)] = true
+		}
+		for attribute, value := range result.Event.Attributes {
+			if number, ok := numericValue(value); ok {
+				group.Totals[attribute] += number
+			}
+		}
+	}
+
+	groups := make([]Group, 0, len(order))
+	for _, key := range order {
+		acc := byKey[key]
+		group := *acc.group
+		group.MeanScore = acc.totalScore / float64(group.Correlations)
+		group.EventTypes = make([]string, 0, len(acc.eventTypes))
+		for eventType := range acc.eventTypes {
+			group.EventTypes = append(group.EventTypes, eventType)
+		}
+		sort.Strings(group.EventTypes)
+		groups = append(groups, group)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].BestScore != groups[j].BestScore {
+			return groups[i].BestScore > groups[j].BestScore
+		}
+		return groups[i].Key < groups[j].Key
+	})
+
+	return groups, nil
+}
+
+func groupKey(result types.CorrelationResult, by string) string {
+	switch by {
+	case GroupByEvent:
+		return result.Event.Key()
+	case GroupByAuthor:
+		if result.Commit.AuthorEmail != "" {
+			return result.Commit.AuthorEmail
+		}
+		return result.Commit.Author
+	default:
+		return result.Commit.SHA
+	}
+}
+
+// GroupTable lays groups out for csv, tsv, markdown and jsonl output, with one
+// total.<attribute> column per numeric attribute.
+func GroupTable(groups []Group, by string) Table {
+	var header []string
+	switch by {
+	case GroupByEvent:
+		header = []string{"event.id", "event.timestamp"}
+	case GroupByAuthor:
+		header = []string{"commit.author_email", "commit.author"}
+	default:
+		header = []string{"commit.sha", "commit.author", "commit.timestamp", "commit.message"}
+	}
+	header = append(header, "correlations", "best_score", "mean_score", "events", "commits", "event_types")
+
+	attributes := totalAttributes(groups)
+	for _, attribute := range attributes {
+		header = append(header, "total."+attribute)
+	}
+
+	table := Table{Header: header, Rows: make([][]interface{}, len(groups))}
+	for i, group := range groups {
+		var row []interface{}
+		switch by {
+		case GroupByEvent:
+			row = []interface{}{group.Key, group.Event.Timestamp.Format(time.RFC3339)}
+		case GroupByAuthor:
+			row = []interface{}{group.AuthorEmail, group.Author}
+		default:
+			message, _, _ := strings.Cut(group.Commit.Message, "\n")
+			row = []interface{}{group.Key, group.Commit.Author, group.Commit.Timestamp.Format(time.RFC3339), message}
+		}
+		row = append(row, group.Correlations, group.BestScore, group.MeanScore,
+			len(group.Events), len(group.Commits), strings.Join(group.EventTypes, ","))
+		for _, attribute := range attributes {
+			row = append(row, group.Totals[attribute])
+		}
+		table.Rows[i] = row
+	}
+	return table
+}
+
+func totalAttributes(groups []Group) []string {
+	seen := make(map[string]bool)
+	var attributes []string
+	for _, group := range groups {
+		for attribute := range group.Totals {
+			if !seen[attribute] {
+				seen[attribute] = true
+				attributes = append(attributes, attribute)
+			}
+		}
+	}
+	sort.Strings(attributes)
+	return attributes
+}
+
+func numericValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		number, err := v.Float64()
+		return number, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,85 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+func TestGroupResults(t *testing.T) {
+	results := tabularResults()
+	results[0].Event.Attributes["request_type"] = "code_generation"
+	results = append(results, types.CorrelationResult{
+		Event: types.SnapEvent{
+			ID:         "evt-3",
+			Timestamp:  results[0].Event.Timestamp.Add(time.Minute),
+			Attributes: map[string]interface{}{"tokens_used": 300.5, "request_type": "debugging", "user_id": "john.doe"},
+		},
+		Commit: results[0].Commit,
+		Score:  0.625,
+	}, types.CorrelationResult{
+		Event:  results[0].Event,
+		Commit: results[1].Commit,
+		Score:  0.25,
+	})
+
+	groups, err := GroupResults(results, GroupByCommit, "request_type")
+	if err != nil {
+		t.Fatalf("Failed to group results: %v", err)
+	}
+	if len(groups) != 2 || groups[0].Key != "abc123def456" {
+		t.Fatalf("Expected abc123def456 first of 2 groups, got %+v", groups)
+	}
+	commit := groups[0]
+	if commit.Correlations != 2 || commit.BestScore != 0.875 || commit.MeanScore != 0.75 {
+		t.Errorf("Unexpected scores: %+v", commit)
+	}
+	if commit.Totals["tokens_used"] != 1500.5 || len(commit.Events) != 2 {
+		t.Errorf("Expected tokens_used summed over 2 events, got %v over %v", commit.Totals, commit.Events)
+	}
+	if len(commit.EventTypes) != 2 || commit.EventTypes[0] != "code_generation" || commit.EventTypes[1] != "debugging" {
+		t.Errorf("Unexpected event types: %v", commit.EventTypes)
+	}
+
+	groups, err = GroupResults(results, GroupByEvent, "request_type")
+	if err != nil {
+		t.Fatalf("Failed to group results: %v", err)
+	}
+	if len(groups) != 3 || groups[0].Key != "evt-1" || len(groups[0].Commits) != 2 || groups[0].Totals["tokens_used"] != 1200 {
+		t.Errorf("Expected evt-1 with 2 commits and its tokens counted once, got %+v", groups[0])
+	}
+
+	groups, err = GroupResults(results, GroupByAuthor, "request_type")
+	if err != nil {
+		t.Fatalf("Failed to group results: %v", err)
+	}
+	if len(groups) != 2 || groups[0].Author != "John Doe" || groups[1].Author != "Jane, Smith" {
+		t.Errorf("Expected groups for John Doe and Jane, Smith, got %+v", groups)
+	}
+
+	if _, err := GroupResults(results, "repository", "request_type"); err == nil {
+		t.Errorf("Expected unknown grouping to be rejected")
+	}
+}
+
+func TestGroupTable(t *testing.T) {
+	results := tabularResults()
+	groups, err := GroupResults(results, GroupByCommit, "request_type")
+	if err != nil {
+		t.Fatalf("Failed to group results: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := GroupTable(groups, GroupByCommit).WriteCSV(&buf); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+	expected := `commit.sha,commit.author,commit.timestamp,commit.message,correlations,best_score,mean_score,events,commits,event_types,total.tokens_used
+abc123def456,John Doe,0001-01-01T00:00:00Z,Fix parser,1,0.875,0.875,1,1,,1200
+789abc,"Jane, Smith",0001-01-01T00:00:00Z,,1,0.5,0.5,1,1,,0
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected CSV:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
@@ -0,0 +1,112 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// See pkg/parser/parquet.go for why xitongsys/parquet-go is used.
+
+// parquetRow is the flattened layout of a CorrelationResult in Parquet
+// output. Event attributes and metadata are free-form, so they are stored as
+// JSON strings; everything else is typed.
+type parquetRow struct {
+	EventID         string          `parquet:"name=event_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	EventTimestamp  int64           `parquet:"name=event_timestamp, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	EventAttributes string          `parquet:"name=event_attributes, type=BYTE_ARRAY, convertedtype=UTF8"`
+	EventMetadata   string          `parquet:"name=event_metadata, type=BYTE_ARRAY, convertedtype=UTF8"`
+	CommitSHA       string          `parquet:"name=commit_sha, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Author          string          `parquet:"name=author, type=BYTE_ARRAY, convertedtype=UTF8"`
+	AuthorEmail     string          `parquet:"name=author_email, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Committer       string          `parquet:"name=committer, type=BYTE_ARRAY, convertedtype=UTF8"`
+	CommitTimestamp int64           `parquet:"name=commit_timestamp, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	Message         string          `parquet:"name=message, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Branch          string          `parquet:"name=branch, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Repository      string          `parquet:"name=repository, type=BYTE_ARRAY, convertedtype=UTF8"`
+	PRNumber        *int64          `parquet:"name=pr_number, type=INT64, repetitiontype=OPTIONAL"`
+	Additions       int64           `parquet:"name=additions, type=INT64"`
+	Deletions       int64           `parquet:"name=deletions, type=INT64"`
+	Files           []string        `parquet:"name=files, type=LIST, valuetype=BYTE_ARRAY, valueconvertedtype=UTF8"`
+	Score           float64         `parquet:"name=score, type=DOUBLE"`
+	TimeDeltaMs     int64           `parquet:"name=time_delta_ms, type=INT64"`
+	Matches         map[string]bool `parquet:"name=matches, type=MAP, convertedtype=MAP, keytype=BYTE_ARRAY, keyconvertedtype=UTF8, valuetype=BOOLEAN"`
+	AttributedLines int64           `parquet:"name=attributed_lines, type=INT64"`
+	LineAttribution float64         `parquet:"name=line_attribution, type=DOUBLE"`
+}
+
+// WriteParquet writes one row per correlation result, compressed with
+// Snappy.
+func WriteParquet(w io.Writer, results []types.CorrelationResult) error {
+	pw, err := writer.NewParquetWriterFromWriter(w, new(parquetRow), 1)
+	if err != nil {
+		return fmt.Errorf("failed to create Parquet writer: %w", err)
+	}
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+
+	for _, result := range results {
+		row, err := newParquetRow(result)
+		if err != nil {
+			return err
+		}
+		if err := pw.Write(row); err != nil {
+			return fmt.Errorf("failed to write Parquet row: %w", err)
+		}
+	}
+
+	if err := pw.WriteStop(); err != nil {
+		return fmt.Errorf("failed to finish Parquet output: %w", err)
+	}
+	return nil
+}
+
+func newParquetRow(result types.CorrelationResult) (parquetRow, error) {
+	attributes, err := json.Marshal(result.Event.Attributes)
+	if err != nil {
+		return parquetRow{}, fmt.Errorf("failed to encode attributes of event %s: %w", result.Event.ID, err)
+	}
+	metadata, err := json.Marshal(result.Event.Metadata)
+	if err != nil {
+		return parquetRow{}, fmt.Errorf("failed to encode metadata of event %s: %w", result.Event.ID, err)
+	}
+
+	commit := result.Commit
+	row := parquetRow{
+		EventID:         result.Event.ID,
+		EventTimestamp:  result.Event.Timestamp.UnixMilli(),
+		EventAttributes: string(attributes),
+		EventMetadata:   string(metadata),
+		CommitSHA:       commit.SHA,
+		Author:          commit.Author,
+		AuthorEmail:     commit.AuthorEmail,
+		Committer:       commit.Committer,
+		CommitTimestamp: commit.Timestamp.UnixMilli(),
+		Message:         commit.Message,
+		Branch:          commit.Branch,
+		Repository:      commit.Repository,
+		Additions:       int64(commit.Additions),
+		Deletions:       int64(commit.Deletions),
+		Files:           commit.Files,
+		Score:           result.Score,
+		TimeDeltaMs:     result.TimeDelta.Milliseconds(),
+		Matches:         result.Matches,
+		AttributedLines: int64(result.AttributedLines),
+		LineAttribution: result.LineAttribution,
+	}
+	if commit.PRNumber != nil {
+		prNumber := int64(*commit.PRNumber)
+		row.PRNumber = &prNumber
+	}
+	if row.Files == nil {
+		row.Files = []string{}
+	}
+	if row.Matches == nil {
+		row.Matches = map[string]bool{}
+	}
+
+	return row, nil
+}
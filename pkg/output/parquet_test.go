@@ -0,0 +1,78 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+func TestWriteParquet(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	prNumber := 42
+	results := []types.CorrelationResult{
+		{
+			Event: types.SnapEvent{
+				ID:         "evt-1",
+				Timestamp:  now,
+				Attributes: map[string]interface{}{"user_id": "john.doe", "tokens_used": 1200},
+			},
+			Commit: types.EnrichedCommit{
+				SHA:         "abc123",
+				AuthorEmail: "john.doe@example.com",
+				Timestamp:   now.Add(5 * time.Minute),
+				Files:       []string{"main.go"},
+				PRNumber:    &prNumber,
+			},
+			Score:     0.9,
+			Matches:   map[string]bool{"user_id->author_email": true},
+			TimeDelta: 5 * time.Minute,
+		},
+		{
+			Event:     types.SnapEvent{ID: "evt-2", Timestamp: now},
+			Commit:    types.EnrichedCommit{SHA: "def456", Timestamp: now},
+			Score:     0.5,
+			TimeDelta: 0,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteParquet(&buf, results); err != nil {
+		t.Fatalf("Failed to write Parquet: %v", err)
+	}
+
+	file, err := buffer.NewBufferFile(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Failed to open output: %v", err)
+	}
+	pr, err := reader.NewParquetReader(file, new(parquetRow), 1)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	defer pr.ReadStop()
+
+	rows := make([]parquetRow, pr.GetNumRows())
+	if err := pr.Read(&rows); err != nil {
+		t.Fatalf("Failed to read rows: %v", err)
+	}
+
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(rows))
+	}
+	first := rows[0]
+	if first.EventID != "evt-1" || first.CommitSHA != "abc123" || first.Score != 0.9 || first.TimeDeltaMs != 300000 {
+		t.Errorf("Unexpected first row: %+v", first)
+	}
+	if first.EventTimestamp != now.UnixMilli() || first.EventAttributes != `{"tokens_used":1200,"user_id":"john.doe"}` {
+		t.Errorf("Unexpected event columns: %d %s", first.EventTimestamp, first.EventAttributes)
+	}
+	if first.PRNumber == nil || *first.PRNumber != 42 || len(first.Files) != 1 || !first.Matches["user_id->author_email"] {
+		t.Errorf("Unexpected commit columns: %+v", first)
+	}
+	if rows[1].PRNumber != nil || len(rows[1].Matches) != 0 {
+		t.Errorf("Unexpected second row: %+v", rows[1])
+	}
+}
@@ -0,0 +1,147 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+// Table is tabular output before formatting. Values keep their types so that
+// JSONL output can write numbers and booleans as such.
+type Table struct {
+	Header []string
+	Rows   [][]interface{}
+}
+
+// NewTable selects columns from each result.
+func NewTable(results []types.CorrelationResult, columns []Column) Table {
+	table := Table{Header: columnNames(columns), Rows: make([][]interface{}, len(results))}
+	for i, result := range results {
+		row := make([]interface{}, len(columns))
+		for j, column := range columns {
+			row[j] = column.Value(result)
+		}
+		table.Rows[i] = row
+	}
+	return table
+}
+
+// WriteCSV writes a header row and one row per result.
+func WriteCSV(w io.Writer, results []types.CorrelationResult, columns []Column) error {
+	return NewTable(results, columns).WriteCSV(w)
+}
+
+// WriteTSV writes tab-separated values. TSV has no quoting, so tabs and line
+// breaks inside values are replaced by spaces.
+func WriteTSV(w io.Writer, results []types.CorrelationResult, columns []Column) error {
+	return NewTable(results, columns).WriteTSV(w)
+}
+
+// WriteMarkdown writes a GitHub-flavoured Markdown table, suitable for PR
+// descriptions and comments.
+func WriteMarkdown(w io.Writer, results []types.CorrelationResult, columns []Column) error {
+	return NewTable(results, columns).WriteMarkdown(w)
+}
+
+// WriteJSONL writes one JSON object per result, keyed by column name and
+// keeping the value types.
+func WriteJSONL(w io.Writer, results []types.CorrelationResult, columns []Column) error {
+	return NewTable(results, columns).WriteJSONL(w)
+}
+
+func (t Table) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(t.Header); err != nil {
+		return err
+	}
+	for _, row := range t.Rows {
+		if err := writer.Write(formatRow(row)); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (t Table) WriteTSV(w io.Writer) error {
+	clean := strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ")
+	writeRow := func(values []string) error {
+		for i := range values {
+			values[i] = clean.Replace(values[i])
+		}
+		_, err := fmt.Fprintln(w, strings.Join(values, "\t"))
+		return err
+	}
+
+	if err := writeRow(append([]string(nil), t.Header...)); err != nil {
+		return err
+	}
+	for _, row := range t.Rows {
+		if err := writeRow(formatRow(row)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t Table) WriteMarkdown(w io.Writer) error {
+	escape := strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+	writeRow := func(values []string) error {
+		for i := range values {
+			values[i] = escape.Replace(values[i])
+		}
+		_, err := fmt.Fprintf(w, "| %s |\n", strings.Join(values, " | "))
+		return err
+	}
+
+	if err := writeRow(append([]string(nil), t.Header...)); err != nil {
+		return err
+	}
+	separators := make([]string, len(t.Header))
+	for i := range separators {
+		separators[i] = "---"
+	}
+	if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(separators, " | ")); err != nil {
+		return err
+	}
+	for _, row := range t.Rows {
+		if err := writeRow(formatRow(row)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t Table) WriteJSONL(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	for _, row := range t.Rows {
+		record := make(map[string]interface{}, len(t.Header))
+		for i, name := range t.Header {
+			record[name] = row[i]
+		}
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func columnNames(columns []Column) []string {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+func formatRow(row []interface{}) []string {
+	values := make([]string, len(row))
+	for i, value := range row {
+		values[i] = formatValue(value)
+	}
+	return values
+}
@@ -0,0 +1,118 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+func tabularResults() []types.CorrelationResult {
+	now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	return []types.CorrelationResult{
+		{
+			Event: types.SnapEvent{
+				ID:         "evt-1",
+				Timestamp:  now,
+				Attributes: map[string]interface{}{"user_id": "john.doe", "tokens_used": 1200},
+			},
+			Commit: types.EnrichedCommit{
+				SHA:     "abc123def456",
+				Author:  "John Doe",
+				Message: "Fix parser\n\nHandles a|b",
+			},
+			Score:     0.875,
+			Matches:   map[string]bool{"user_id": true, "repository": false},
+			TimeDelta: 90 * time.Second,
+		},
+		{
+			Event:     types.SnapEvent{ID: "evt-2", Timestamp: now},
+			Commit:    types.EnrichedCommit{SHA: "789abc", Author: "Jane, Smith"},
+			Score:     0.5,
+			TimeDelta: 2 * time.Hour,
+		},
+	}
+}
+
+func TestParseColumns(t *testing.T) {
+	columns, err := ParseColumns(DefaultColumns, tabularResults())
+	if err != nil {
+		t.Fatalf("Failed to parse default columns: %v", err)
+	}
+
+	expected := []string{"score", "event.id", "commit.sha", "delta", "commit.author", "match.repository", "match.user_id"}
+	if names := columnNames(columns); strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected columns %v, got %v", expected, names)
+	}
+
+	for _, spec := range []string{"commit.nope", "bogus", " , "} {
+		if _, err := ParseColumns(spec, nil); err == nil {
+			t.Errorf("Expected %q to be rejected", spec)
+		}
+		if err := ValidateColumns(spec); err == nil {
+			t.Errorf("Expected %q to be rejected before results are read", spec)
+		}
+	}
+	for _, spec := range []string{DefaultColumns, "match.*"} {
+		if err := ValidateColumns(spec); err != nil {
+			t.Errorf("Expected %q to be accepted, got %v", spec, err)
+		}
+	}
+}
+
+func TestTabularFormats(t *testing.T) {
+	results := tabularResults()
+	columns, err := ParseColumns("event.id,score,delta_seconds,event.tokens_used,commit.author,commit.message,match.user_id", results)
+	if err != nil {
+		t.Fatalf("Failed to parse columns: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		write    func(*bytes.Buffer) error
+		expected string
+	}{
+		{
+			name:  "csv",
+			write: func(b *bytes.Buffer) error { return WriteCSV(b, results, columns) },
+			expected: "event.id,score,delta_seconds,event.tokens_used,commit.author,commit.message,match.user_id\n" +
+				"evt-1,0.875,90,1200,John Doe,\"Fix parser\n\nHandles a|b\",true\n" +
+				"evt-2,0.5,7200,,\"Jane, Smith\",,\n",
+		},
+		{
+			name:  "tsv",
+			write: func(b *bytes.Buffer) error { return WriteTSV(b, results, columns) },
+			expected: "event.id\tscore\tdelta_seconds\tevent.tokens_used\tcommit.author\tcommit.message\tmatch.user_id\n" +
+				"evt-1\t0.875\t90\t1200\tJohn Doe\tFix parser  Handles a|b\ttrue\n" +
+				"evt-2\t0.5\t7200\t\tJane, Smith\t\t\n",
+		},
+		{
+			name:  "markdown",
+			write: func(b *bytes.Buffer) error { return WriteMarkdown(b, results, columns) },
+			expected: "| event.id | score | delta_seconds | event.tokens_used | commit.author | commit.message | match.user_id |\n" +
+				"| --- | --- | --- | --- | --- | --- | --- |\n" +
+				"| evt-1 | 0.875 | 90 | 1200 | John Doe | Fix parser<br><br>Handles a\\|b | true |\n" +
+				"| evt-2 | 0.5 | 7200 |  | Jane, Smith |  |  |\n",
+		},
+		{
+			name:  "jsonl",
+			write: func(b *bytes.Buffer) error { return WriteJSONL(b, results, columns) },
+			expected: `{"commit.author":"John Doe","commit.message":"Fix parser\n\nHandles a|b","delta_seconds":90,"event.id":"evt-1","event.tokens_used":1200,"match.user_id":true,"score":0.875}` + "\n" +
+				`{"commit.author":"Jane, Smith","commit.message":"","delta_seconds":7200,"event.id":"evt-2","event.tokens_used":null,"match.user_id":null,"score":0.5}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.write(&buf); err != nil {
+				t.Fatalf("Failed to write %s: %v", tt.name, err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("Unexpected %s output:\n%s\nexpected:\n%s", tt.name, buf.String(), tt.expected)
+			}
+		})
+	}
+}
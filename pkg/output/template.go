@@ -0,0 +1,146 @@
+package output
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/stats"
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+// TemplateData is what user templates are executed with. Events and Commits
+// list each correlated event and commit once, in order of first appearance.
+// Summary is computed from the results alone unless the caller replaces it
+// with statistics that know the events read.
+type TemplateData struct {
+	Results     []types.CorrelationResult
+	Events      []types.SnapEvent
+	Commits     []types.EnrichedCommit
+	Config      types.SnapConfig
+	ConfigName  string
+	Threshold   float64
+	GeneratedAt time.Time
+	Summary     stats.Stats
+}
+
+func NewTemplateData(results []types.CorrelationResult, config types.SnapConfig, configName string, threshold float64) TemplateData {
+	data := TemplateData{
+		Results:     results,
+		Config:      config,
+		ConfigName:  configName,
+		Threshold:   threshold,
+		GeneratedAt: time.Now(),
+		Summary:     stats.Compute(stats.Input{Results: results}, stats.DefaultAmbiguityMargin),
+	}
+
+	seenEvents := make(map[string]bool)
+	seenCommits := make(map[string]bool)
+	for _, result := range results {
+		if key := result.Event.Key(); !seenEvents[key] {
+			seenEvents[key] = true
+			data.Events = append(data.Events, result.Event)
+		}
+		if !seenCommits[result.Commit.SHA] {
+			seenCommits[result.Commit.SHA] = true
+			data.Commits = append(data.Commits, result.Commit)
+		}
+	}
+
+	return data
+}
+
+// TemplateFuncs are the helpers available to user templates.
+var TemplateFuncs = map[string]interface{}{
+	"shortSHA": ShortSHA,
+	"humanize": Humanize,
+	"percent":  Percent,
+}
+
+// Template is a parsed user template, from text/template or html/template.
+type Template interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// ParseTemplate reads and parses the template file at path. Files ending in
+// .html or .htm use html/template, which escapes values for HTML; any other
+// file uses text/template.
+func ParseTemplate(path string) (Template, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+	name := filepath.Base(path)
+
+	var tmpl Template
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		tmpl, err = htmltemplate.New(name).Funcs(htmltemplate.FuncMap(TemplateFuncs)).Parse(string(content))
+	default:
+		tmpl, err = template.New(name).Funcs(template.FuncMap(TemplateFuncs)).Parse(string(content))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// RenderTemplate executes a template parsed by ParseTemplate.
+func RenderTemplate(w io.Writer, tmpl Template, data TemplateData) error {
+	if err := tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+	return nil
+}
+
+func ShortSHA(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
+
+// Humanize formats a duration with its two largest units, e.g. "2h 5m" or
+// "3d 4h".
+func Humanize(d time.Duration) string {
+	if d < 0 {
+		d = -d
+	}
+	if d < time.Second {
+		return "0s"
+	}
+
+	units := []struct {
+		size   time.Duration
+		suffix string
+	}{
+		{24 * time.Hour, "d"},
+		{time.Hour, "h"},
+		{time.Minute, "m"},
+		{time.Second, "s"},
+	}
+
+	var parts []string
+	for _, unit := range units {
+		if d >= unit.size {
+			parts = append(parts, fmt.Sprintf("%d%s", d/unit.size, unit.suffix))
+			d %= unit.size
+		} else if len(parts) > 0 {
+			break
+		}
+		if len(parts) == 2 {
+			break
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Percent formats a fraction as a percentage with one decimal.
+func Percent(fraction float64) string {
+	return fmt.Sprintf("%.1f%%", fraction*100)
+}
@@ -0,0 +1,104 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	results := tabularResults()
+	results = append(results, types.CorrelationResult{
+		Event:     results[0].Event,
+		Commit:    results[1].Commit,
+		Score:     0.25,
+		TimeDelta: 26 * time.Hour,
+	})
+	data := NewTemplateData(results, types.SnapConfig{TimeWindow: time.Hour}, "ai-inference", 0.2)
+
+	if len(data.Events) != 2 || len(data.Commits) != 2 {
+		t.Fatalf("Expected 2 distinct events and commits, got %d and %d", len(data.Events), len(data.Commits))
+	}
+
+	dir := t.TempDir()
+	textPath := filepath.Join(dir, "report.tmpl")
+	text := `{{.ConfigName}}: {{.Summary.Correlations}} correlations, {{.Summary.MatchedEvents}} events, mean {{percent .Summary.Score.Mean}}
+{{range .Results}}{{shortSHA .Commit.SHA}} {{.Event.ID}} {{percent .Score}} {{humanize .TimeDelta}} <{{.Commit.Author}}>
+{{end}}`
+	if err := os.WriteFile(textPath, []byte(text), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := renderTemplateFile(&buf, textPath, data); err != nil {
+		t.Fatalf("Failed to render template: %v", err)
+	}
+	expected := `ai-inference: 3 correlations, 2 events, mean 54.2%
+abc123de evt-1 87.5% 1m 30s <John Doe>
+789abc evt-2 50.0% 2h <Jane, Smith>
+789abc evt-1 25.0% 1d 2h <Jane, Smith>
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+
+	htmlPath := filepath.Join(dir, "report.html")
+	if err := os.WriteFile(htmlPath, []byte(`<p>{{(index .Results 0).Commit.Message}}</p>`), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	data.Results[0].Commit.Message = "<script>"
+	buf.Reset()
+	if err := renderTemplateFile(&buf, htmlPath, data); err != nil {
+		t.Fatalf("Failed to render template: %v", err)
+	}
+	if buf.String() != "<p>&lt;script&gt;</p>" {
+		t.Errorf("Expected HTML escaping, got %s", buf.String())
+	}
+
+	badPath := filepath.Join(dir, "bad.tmpl")
+	if err := os.WriteFile(badPath, []byte(`{{.Missing}}`), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	if err := renderTemplateFile(&buf, badPath, data); err == nil {
+		t.Error("Expected an error for an unknown field")
+	}
+
+	unparsablePath := filepath.Join(dir, "unparsable.tmpl")
+	if err := os.WriteFile(unparsablePath, []byte(`{{range .Results}}`), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	if _, err := ParseTemplate(unparsablePath); err == nil {
+		t.Error("Expected a parse error for an unterminated range")
+	}
+	if _, err := ParseTemplate(filepath.Join(dir, "missing.tmpl")); err == nil {
+		t.Error("Expected an error for a missing template file")
+	}
+}
+
+func renderTemplateFile(buf *bytes.Buffer, path string, data TemplateData) error {
+	tmpl, err := ParseTemplate(path)
+	if err != nil {
+		return err
+	}
+	return RenderTemplate(buf, tmpl, data)
+}
+
+func TestHumanize(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                                "0s",
+		45 * time.Second:                 "45s",
+		90 * time.Minute:                 "1h 30m",
+		time.Hour + 30*time.Second:       "1h",
+		50*time.Hour + 10*time.Minute:    "2d 2h",
+		-(2*time.Minute + 5*time.Second): "2m 5s",
+	}
+	for d, expected := range tests {
+		if got := Humanize(d); got != expected {
+			t.Errorf("Humanize(%v) = %q, expected %q", d, got, expected)
+		}
+	}
+}
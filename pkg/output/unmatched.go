@@ -0,0 +1,50 @@
+package output
+
+import (
+	"strings"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+// UnmatchedTable lists events without a correlation and why. The detail
+// column holds the failed required rules or the best score.
+func UnmatchedTable(events []types.UnmatchedEvent) Table {
+	table := Table{
+		Header: []string{"event.id", "event.timestamp", "reason", "candidates", "detail"},
+		Rows:   make([][]interface{}, len(events)),
+	}
+	for i, unmatched := range events {
+		table.Rows[i] = []interface{}{
+			unmatched.Event.Key(),
+			unmatched.Event.Timestamp.Format(time.RFC3339),
+			unmatched.Reason,
+			unmatched.Candidates,
+			UnmatchedDetail(unmatched),
+		}
+	}
+	return table
+}
+
+func UnmatchedDetail(unmatched types.UnmatchedEvent) string {
+	if len(unmatched.FailedRules) > 0 {
+		return "failed: " + strings.Join(unmatched.FailedRules, ", ")
+	}
+	if unmatched.BestScore > 0 {
+		return "best score " + formatValue(unmatched.BestScore)
+	}
+	return ""
+}
+
+// UncorrelatedTable lists commits that no event correlated with.
+func UncorrelatedTable(commits []types.EnrichedCommit) Table {
+	table := Table{
+		Header: []string{"commit.sha", "commit.timestamp", "commit.author", "commit.message"},
+		Rows:   make([][]interface{}, len(commits)),
+	}
+	for i, commit := range commits {
+		message, _, _ := strings.Cut(commit.Message, "\n")
+		table.Rows[i] = []interface{}{commit.SHA, commit.Timestamp.Format(time.RFC3339), commit.Author, message}
+	}
+	return table
+}
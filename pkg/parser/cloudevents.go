@@ -0,0 +1,128 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+const CloudEventsDataBase64Key = "data_base64"
+
+// cloudEventContext lists the context attributes kept in metadata rather
+// than attributes.
+var cloudEventContext = map[string]bool{
+	"specversion":     true,
+	"datacontenttype": true,
+	"dataschema":      true,
+}
+
+// cloudEventsDecoder reads CloudEvents in the structured JSON mode, either
+// one event per value (a single event or JSONL) or batches as JSON arrays.
+type cloudEventsDecoder struct {
+	decoder    *json.Decoder
+	pending    []json.RawMessage
+	index      int
+	timestamps *timestampParser
+}
+
+func newCloudEventsDecoder(r io.Reader, timestamps *timestampParser) *cloudEventsDecoder {
+	return &cloudEventsDecoder{decoder: json.NewDecoder(r), timestamps: timestamps}
+}
+
+func (d *cloudEventsDecoder) decode() (types.SnapEvent, error) {
+	for len(d.pending) == 0 {
+		var raw json.RawMessage
+		if err := d.decoder.Decode(&raw); err != nil {
+			if err == io.EOF {
+				return types.SnapEvent{}, io.EOF
+			}
+			return types.SnapEvent{}, fmt.Errorf("failed to parse CloudEvents: %w", err)
+		}
+
+		if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+			var batch []json.RawMessage
+			if err := json.Unmarshal(trimmed, &batch); err != nil {
+				return types.SnapEvent{}, fmt.Errorf("failed to parse CloudEvents batch: %w", err)
+			}
+			d.pending = batch
+			continue
+		}
+		d.pending = []json.RawMessage{raw}
+	}
+
+	raw := d.pending[0]
+	d.pending = d.pending[1:]
+	d.index++
+
+	event, err := cloudEventToSnapEvent(raw, d.timestamps)
+	if err != nil {
+		return types.SnapEvent{}, &RecordError{
+			Unit:     "event",
+			Position: d.index,
+			Reason:   fmt.Sprintf("invalid CloudEvent %d: %v", d.index, err),
+			Raw:      string(raw),
+		}
+	}
+
+	return event, nil
+}
+
+func (d *cloudEventsDecoder) position() (string, int) {
+	return "event", d.index
+}
+
+// cloudEventToSnapEvent maps id and time onto the event, and type, source,
+// subject and extension attributes onto its attributes. Fields of an object
+// payload become attributes too, unless they clash with a context attribute;
+// any other payload is kept under "data".
+func cloudEventToSnapEvent(raw json.RawMessage, timestamps *timestampParser) (types.SnapEvent, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return types.SnapEvent{}, err
+	}
+
+	for _, required := range []string{"specversion", "id", "source", "type"} {
+		if value, exists := fields[required]; !exists || value == nil || value == "" {
+			return types.SnapEvent{}, fmt.Errorf("missing required attribute %s", required)
+		}
+	}
+
+	event := types.SnapEvent{
+		ID:         fmt.Sprintf("%v", fields["id"]),
+		Attributes: make(map[string]interface{}),
+		Metadata:   make(map[string]interface{}),
+	}
+
+	timeValue, exists := fields["time"]
+	if !exists || timeValue == nil {
+		return types.SnapEvent{}, fmt.Errorf("missing time attribute")
+	}
+	timestamp, err := timestamps.parse(timeValue)
+	if err != nil {
+		return types.SnapEvent{}, err
+	}
+	event.Timestamp = timestamp
+
+	if data, isObject := fields["data"].(map[string]interface{}); isObject {
+		for key, value := range data {
+			event.Attributes[key] = value
+		}
+	} else if data, exists := fields["data"]; exists && data != nil {
+		event.Attributes["data"] = data
+	}
+
+	for key, value := range fields {
+		switch {
+		case key == "id" || key == "time" || key == "data":
+		case key == CloudEventsDataBase64Key || cloudEventContext[key]:
+			event.Metadata[key] = value
+		default:
+			event.Attributes[key] = value
+		}
+	}
+
+	return event, nil
+}
@@ -0,0 +1,90 @@
+package parser
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCloudEventsStructuredAndBatch(t *testing.T) {
+	data := `{"specversion": "1.0", "id": "deploy-1", "source": "/ci/deployments", "type": "com.example.deployment.finished", "subject": "backend-service", "time": "2024-01-15T10:30:00Z", "datacontenttype": "application/json", "actor": "john.doe@company.com", "data": {"commit_sha": "abc123", "environment": "production", "type": "ignored"}}
+[{"specversion": "1.0", "id": "build-1", "source": "/ci/builds", "type": "com.example.build.started", "time": "2024-01-15T10:35:00Z", "data": "queued"}, {"specversion": "1.0", "id": "build-2", "source": "/ci/builds", "type": "com.example.build.started", "time": "2024-01-15T10:40:00Z", "data_base64": "AQID"}]
+`
+
+	events, err := ReadAll(mustReader(t, data, "cloudevents", ReaderOptions{}))
+	if err != nil {
+		t.Fatalf("Failed to parse CloudEvents: %v", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(events))
+	}
+
+	deploy := events[0]
+	if deploy.ID != "deploy-1" || !deploy.Timestamp.Equal(time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected ID or timestamp: %+v", deploy)
+	}
+	if deploy.Attributes["type"] != "com.example.deployment.finished" || deploy.Attributes["source"] != "/ci/deployments" || deploy.Attributes["subject"] != "backend-service" {
+		t.Errorf("Expected context attributes to take precedence, got %v", deploy.Attributes)
+	}
+	if deploy.Attributes["commit_sha"] != "abc123" || deploy.Attributes["actor"] != "john.doe@company.com" {
+		t.Errorf("Expected data fields and extensions as attributes, got %v", deploy.Attributes)
+	}
+	if deploy.Metadata["specversion"] != "1.0" || deploy.Metadata["datacontenttype"] != "application/json" {
+		t.Errorf("Expected spec attributes in metadata, got %v", deploy.Metadata)
+	}
+
+	if events[1].Attributes["data"] != "queued" {
+		t.Errorf("Expected scalar data under data, got %v", events[1].Attributes)
+	}
+	if events[2].Metadata[CloudEventsDataBase64Key] != "AQID" {
+		t.Errorf("Expected data_base64 in metadata, got %v", events[2].Metadata)
+	}
+}
+
+func TestParseCloudEventsMissingRequiredAttribute(t *testing.T) {
+	data := `[{"specversion": "1.0", "id": "e1", "type": "com.example.build", "time": "2024-01-15T10:35:00Z"}]`
+
+	_, err := ReadAll(mustReader(t, data, "cloudevents", ReaderOptions{}))
+
+	var recordErr *RecordError
+	if !errors.As(err, &recordErr) || recordErr.Position != 1 {
+		t.Errorf("Expected a record error for event 1, got %v", err)
+	}
+}
+
+func TestSniffCloudEvents(t *testing.T) {
+	path := writeTestFile(t, "deployments.json", []byte(`[{"specversion": "1.0", "id": "e1", "source": "/ci", "type": "build", "time": "2024-01-15T10:35:00Z"}]`))
+
+	events, err := ParseEventsFromFile(path)
+	if err != nil {
+		t.Fatalf("Failed to parse CloudEvents file: %v", err)
+	}
+	if len(events) != 1 || events[0].Attributes["source"] != "/ci" {
+		t.Errorf("Expected CloudEvents to be detected, got %v", events)
+	}
+}
+
+func TestSniffCloudEventsRequiresTopLevelKey(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`{"id": "e1", "timestamp": "2024-01-15T10:35:00Z", "attributes": {"note": "specversion"}}`, "jsonl"},
+		{`[{"id": "e1", "attributes": {"specversion": "1.0"}}]`, "json"},
+		{`{"id": "e1", "data": {"nested": [1, 2]}, "specversion": "1.0"}`, "cloudevents"},
+		{`{"resourceSpans": []}`, "otlp"},
+	}
+
+	for _, tc := range testCases {
+		fallback := "jsonl"
+		if strings.HasPrefix(tc.input, "[") {
+			fallback = "json"
+		}
+		if format := sniffJSONFormat(bufio.NewReader(strings.NewReader(tc.input)), fallback); format != tc.expected {
+			t.Errorf("sniffJSONFormat(%s) = %s, want %s", tc.input, format, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	bzip2Magic = []byte("BZh")
+)
+
+var logfmtPrefix = regexp.MustCompile(`^[A-Za-z_][\w.\-]*=`)
+
+var compressionExtensions = map[string]string{
+	".gz":   "gzip",
+	".gzip": "gzip",
+	".zst":  "zstd",
+	".zstd": "zstd",
+	".bz2":  "bzip2",
+}
+
+func decompress(r io.Reader, filename string) (io.Reader, []io.Closer, error) {
+	buffered := bufio.NewReader(r)
+
+	compression := compressionExtensions[strings.ToLower(filepath.Ext(filename))]
+	if compression == "" {
+		magic, _ := buffered.Peek(4)
+		switch {
+		case bytes.HasPrefix(magic, gzipMagic):
+			compression = "gzip"
+		case bytes.HasPrefix(magic, zstdMagic):
+			compression = "zstd"
+		case bytes.HasPrefix(magic, bzip2Magic):
+			compression = "bzip2"
+		}
+	}
+
+	switch compression {
+	case "gzip":
+		reader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open gzip stream: %w", err)
+		}
+		return reader, []io.Closer{reader}, nil
+	case "zstd":
+		reader, err := zstd.NewReader(buffered)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open zstd stream: %w", err)
+		}
+		return reader, []io.Closer{reader.IOReadCloser()}, nil
+	case "bzip2":
+		return bzip2.NewReader(buffered), nil, nil
+	default:
+		return buffered, nil, nil
+	}
+}
+
+func trimCompressionExtension(filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if _, compressed := compressionExtensions[ext]; compressed {
+		return filename[:len(filename)-len(ext)]
+	}
+	return filename
+}
+
+// sniffFormat inspects the start of the input to tell JSON arrays, JSONL,
+// OTLP JSON, CloudEvents, logfmt and CSV apart when neither an explicit format nor a
+// known extension is given.
+func sniffFormat(r *bufio.Reader) (string, error) {
+	peek, err := r.Peek(4096)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+
+	if bytes.HasPrefix(peek, parquetMagic) {
+		return "parquet", nil
+	}
+
+	content := bytes.TrimPrefix(peek, []byte("\xef\xbb\xbf"))
+	content = bytes.TrimLeft(content, " \t\r\n")
+	if len(content) == 0 {
+		return "", fmt.Errorf("unable to determine event format: input is empty")
+	}
+
+	switch content[0] {
+	case '[':
+		return sniffJSONFormat(r, "json"), nil
+	case '{':
+		return sniffJSONFormat(r, "jsonl"), nil
+	}
+
+	firstLine, _, _ := bytes.Cut(content, []byte("\n"))
+	if logfmtPrefix.Match(firstLine) {
+		return "logfmt", nil
+	}
+	if bytes.Contains(firstLine, []byte(",")) {
+		return "csv", nil
+	}
+
+	return "", fmt.Errorf("unable to determine event format; specify it explicitly")
+}
+
+// sniffJSONFormat recognises JSON input that is not in the git-snap event
+// layout: OTLP export requests and CloudEvents, by the top-level keys of the
+// first object. Otherwise it returns fallback.
+func sniffJSONFormat(r *bufio.Reader, fallback string) string {
+	peek, _ := r.Peek(4096)
+	content := bytes.TrimLeft(bytes.TrimPrefix(peek, []byte("\xef\xbb\xbf")), " \t\r\n")
+	if len(content) == 0 {
+		return fallback
+	}
+
+	keys := firstObjectKeys(content)
+	switch {
+	case content[0] == '{' && (keys["resourceSpans"] || keys["resourceLogs"]):
+		return "otlp"
+	case keys["specversion"]:
+		return "cloudevents"
+	default:
+		return fallback
+	}
+}
+
+// firstObjectKeys returns the top-level keys of the first JSON object in
+// content, or of the first element of an array. Content may be cut off, in
+// which case the keys seen before the cut are returned.
+func firstObjectKeys(content []byte) map[string]bool {
+	keys := make(map[string]bool)
+	decoder := json.NewDecoder(bytes.NewReader(content))
+
+	token, err := decoder.Token()
+	if token == json.Delim('[') {
+		token, err = decoder.Token()
+	}
+	if err != nil || token != json.Delim('{') {
+		return keys
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return keys
+		}
+		key, ok := token.(string)
+		if !ok {
+			return keys
+		}
+		keys[key] = true
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return keys
+		}
+	}
+	return keys
+}
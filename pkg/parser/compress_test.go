@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const compressTestJSONL = `{"id": "event1", "timestamp": "2023-01-01T12:00:00Z", "attributes": {"user_id": "john.doe"}}
+{"id": "event2", "timestamp": "2023-01-01T12:05:00Z", "attributes": {"user_id": "jane.smith"}}
+`
+
+func writeTestFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestParseEventsFromCompressedFiles(t *testing.T) {
+	var gz bytes.Buffer
+	gzWriter := gzip.NewWriter(&gz)
+	gzWriter.Write([]byte(compressTestJSONL))
+	gzWriter.Close()
+
+	zstdEncoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("Failed to create zstd encoder: %v", err)
+	}
+	zst := zstdEncoder.EncodeAll([]byte(compressTestJSONL), nil)
+
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{"events.jsonl.gz", gz.Bytes()},
+		{"events.jsonl.zst", zst},
+		{"events-without-extension", gz.Bytes()},
+	}
+
+	for _, tc := range testCases {
+		events, err := ParseEventsFromFile(writeTestFile(t, tc.name, tc.data))
+		if err != nil {
+			t.Errorf("Failed to parse %s: %v", tc.name, err)
+			continue
+		}
+		if len(events) != 2 || events[1].ID != "event2" {
+			t.Errorf("Expected 2 events from %s, got %+v", tc.name, events)
+		}
+	}
+}
+
+func TestSniffFormat(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"[{\"id\": \"event1\"}]", "json"},
+		{"\xef\xbb\xbf\n  [", "json"},
+		{compressTestJSONL, "jsonl"},
+		{"id,timestamp,user_id\nevent1,2023-01-01T12:00:00Z,john.doe\n", "csv"},
+		{"just some text", ""},
+		{"   ", ""},
+	}
+
+	for _, tc := range testCases {
+		format, err := sniffFormat(bufio.NewReader(strings.NewReader(tc.input)))
+		if tc.expected == "" {
+			if err == nil {
+				t.Errorf("Expected sniffFormat(%q) to fail, got %s", tc.input, format)
+			}
+			continue
+		}
+		if err != nil || format != tc.expected {
+			t.Errorf("sniffFormat(%q) = %s, %v, want %s", tc.input, format, err, tc.expected)
+		}
+	}
+}
+
+func TestOpenEventFileExplicitFormat(t *testing.T) {
+	path := writeTestFile(t, "events.txt", []byte(compressTestJSONL))
+
+	reader, err := OpenEventFile(path, ReaderOptions{Format: "jsonl"})
+	if err != nil {
+		t.Fatalf("Failed to open events: %v", err)
+	}
+	defer reader.Close()
+
+	events, err := ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to read events: %v", err)
+	}
+	if len(events) != 2 {
+		t.Errorf("Expected 2 events, got %d", len(events))
+	}
+}
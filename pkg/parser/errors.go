@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	OnErrorFail       = "fail"
+	OnErrorSkip       = "skip"
+	OnErrorQuarantine = "quarantine"
+)
+
+// RecordError describes a single event that could not be parsed. Unit is
+// "line" for JSONL, "row" for CSV and "event" for JSON arrays.
+type RecordError struct {
+	File     string `json:"file"`
+	Unit     string `json:"unit"`
+	Position int    `json:"position"`
+	Reason   string `json:"reason"`
+	Raw      string `json:"raw,omitempty"`
+}
+
+func (e *RecordError) Error() string {
+	return e.Reason
+}
+
+func ValidateErrorMode(mode string) error {
+	switch mode {
+	case OnErrorFail, OnErrorSkip, OnErrorQuarantine:
+		return nil
+	default:
+		return fmt.Errorf("invalid parse error mode %s (expected fail, skip or quarantine)", mode)
+	}
+}
+
+// WriteQuarantine writes rejected records to path as JSONL, one RecordError
+// per line with the original record in raw.
+func WriteQuarantine(path string, errors []RecordError) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create quarantine file: %w", err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	for _, recordErr := range errors {
+		if err := encoder.Encode(recordErr); err != nil {
+			return fmt.Errorf("failed to write quarantine file: %w", err)
+		}
+	}
+
+	return file.Close()
+}
+
+func csvLine(record []string) string {
+	var builder strings.Builder
+	writer := csv.NewWriter(&builder)
+	writer.Write(record)
+	writer.Flush()
+	return strings.TrimSuffix(builder.String(), "\n")
+}
@@ -0,0 +1,148 @@
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEventReaderFailsOnMalformedRecord(t *testing.T) {
+	data := "{\"id\": \"event1\", \"timestamp\": \"2023-01-01T12:00:00Z\"}\n{broken\n"
+
+	_, err := ReadAll(mustReader(t, data, "jsonl", ReaderOptions{}))
+
+	var recordErr *RecordError
+	if !errors.As(err, &recordErr) {
+		t.Fatalf("Expected a RecordError, got %v", err)
+	}
+	if recordErr.Unit != "line" || recordErr.Position != 2 || recordErr.Raw != "{broken" {
+		t.Errorf("Unexpected record error: %+v", recordErr)
+	}
+}
+
+func TestEventReaderSkipsMalformedRecords(t *testing.T) {
+	testCases := []struct {
+		format    string
+		data      string
+		positions []int
+	}{
+		{
+			format:    "jsonl",
+			data:      "{\"id\": \"event1\", \"timestamp\": \"2023-01-01T12:00:00Z\"}\n{broken\n{\"id\": \"event3\", \"timestamp\": \"yesterday\"}\n{\"id\": \"event4\", \"timestamp\": \"2023-01-01T12:05:00Z\"}\n",
+			positions: []int{2, 3},
+		},
+		{
+			format:    "json",
+			data:      `[{"id": "event1", "timestamp": "2023-01-01T12:00:00Z"}, {"id": 2}, {"id": "event3", "timestamp": "2023-01-01T12:05:00Z"}]`,
+			positions: []int{2},
+		},
+		{
+			format:    "csv",
+			data:      "id,timestamp,user_id\nevent1,2023-01-01T12:00:00Z,john\nevent2,2023-01-01T12:05:00Z\nevent3,2023-01-01T12:10:00Z,\"ja\"ne\nevent4,2023-01-01T12:15:00Z,jane\n",
+			positions: []int{3, 4},
+		},
+	}
+
+	for _, tc := range testCases {
+		reader := mustReader(t, tc.data, tc.format, ReaderOptions{OnError: OnErrorSkip})
+
+		events, err := ReadAll(reader)
+		if err != nil {
+			t.Fatalf("%s: expected malformed records to be skipped, got %v", tc.format, err)
+		}
+		if len(events) != 2 {
+			t.Errorf("%s: expected 2 events, got %d", tc.format, len(events))
+		}
+
+		recordErrors := reader.Errors()
+		if len(recordErrors) != len(tc.positions) {
+			t.Fatalf("%s: expected %d errors, got %+v", tc.format, len(tc.positions), recordErrors)
+		}
+		for i, position := range tc.positions {
+			if recordErrors[i].Position != position {
+				t.Errorf("%s: expected error %d at position %d, got %d", tc.format, i, position, recordErrors[i].Position)
+			}
+		}
+	}
+}
+
+func TestMultiEventReaderCollectsErrorsAndQuarantines(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.jsonl")
+	bad := filepath.Join(dir, "bad.jsonl")
+	if err := os.WriteFile(good, []byte("{\"id\": \"a\", \"timestamp\": \"2023-01-01T12:00:00Z\"}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", good, err)
+	}
+	if err := os.WriteFile(bad, []byte("not json\n{\"id\": \"b\", \"timestamp\": \"2023-01-01T12:00:00Z\"}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", bad, err)
+	}
+
+	reader, err := OpenEventFiles([]string{good, bad}, ReaderOptions{OnError: OnErrorQuarantine})
+	if err != nil {
+		t.Fatalf("Failed to open files: %v", err)
+	}
+	defer reader.Close()
+
+	for {
+		if _, err := reader.Read(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if reader.Count() != 2 {
+		t.Errorf("Expected 2 events, got %d", reader.Count())
+	}
+
+	recordErrors := reader.Errors()
+	if len(recordErrors) != 1 || recordErrors[0].File != bad {
+		t.Fatalf("Expected one error in %s, got %+v", bad, recordErrors)
+	}
+
+	quarantine := filepath.Join(dir, "quarantine.jsonl")
+	if err := WriteQuarantine(quarantine, recordErrors); err != nil {
+		t.Fatalf("Failed to write quarantine: %v", err)
+	}
+
+	data, err := os.ReadFile(quarantine)
+	if err != nil {
+		t.Fatalf("Failed to read quarantine: %v", err)
+	}
+
+	var written RecordError
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(data))), &written); err != nil {
+		t.Fatalf("Failed to decode quarantine record: %v", err)
+	}
+	if written.Raw != "not json" || written.Position != 1 {
+		t.Errorf("Unexpected quarantine record: %+v", written)
+	}
+}
+
+func TestSkippedRecordsKeepTheirText(t *testing.T) {
+	data := "id,timestamp,user_id\nevent1,2023-01-01T12:00:00Z,john\nevent2,2023-01-01T12:10:00Z,\"ja\"ne\nevent3,2023-01-01T12:15:00Z,jane\n"
+	reader := mustReader(t, data, "csv", ReaderOptions{OnError: OnErrorSkip})
+	if _, err := ReadAll(reader); err != nil {
+		t.Fatalf("Expected the malformed row to be skipped, got %v", err)
+	}
+	if recordErrors := reader.Errors(); len(recordErrors) != 1 || recordErrors[0].Raw != `event2,2023-01-01T12:10:00Z,"ja"ne` {
+		t.Errorf("Expected the malformed row's text in raw, got %+v", recordErrors)
+	}
+
+	long := `{"id": "event2", "timestamp": "2023-01-01T12:05:00Z", "attributes": {"blob": "` + strings.Repeat("x", 4096) + `"}}`
+	data = `{"id": "event1", "timestamp": "2023-01-01T12:00:00Z"}` + "\n" + long + "\n" + `{"id": "event3", "timestamp": "2023-01-01T12:10:00Z"}` + "\n"
+	reader = mustReader(t, data, "jsonl", ReaderOptions{OnError: OnErrorSkip, MaxLineSize: 1024})
+	events, err := ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Expected the long line to be skipped, got %v", err)
+	}
+	if len(events) != 2 || events[1].ID != "event3" {
+		t.Errorf("Expected event1 and event3, got %+v", events)
+	}
+	if recordErrors := reader.Errors(); len(recordErrors) != 1 || recordErrors[0].Position != 2 {
+		t.Errorf("Expected an error for line 2, got %+v", recordErrors)
+	}
+}
@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+var errLineTooLong = errors.New("line exceeds the maximum line size")
+
+// lineReader reads lines of at most maxSize bytes. Unlike bufio.Scanner, it
+// can continue after a longer line: the line is discarded and reported as
+// errLineTooLong, so that it can be skipped like any other malformed record.
+type lineReader struct {
+	reader  *bufio.Reader
+	maxSize int
+	line    []byte
+}
+
+func newLineReader(r io.Reader, maxSize int) *lineReader {
+	return &lineReader{reader: bufio.NewReaderSize(r, min(64*1024, maxSize)), maxSize: maxSize}
+}
+
+// next returns the next line without its line ending. The slice is only
+// valid until the following call.
+func (l *lineReader) next() ([]byte, error) {
+	l.line = l.line[:0]
+	tooLong := false
+
+	for {
+		chunk, err := l.reader.ReadSlice('\n')
+		if !tooLong {
+			l.line = append(l.line, chunk...)
+			if len(bytes.TrimRight(l.line, "\r\n")) > l.maxSize {
+				tooLong = true
+				l.line = l.line[:0]
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF {
+			if len(chunk) == 0 && len(l.line) == 0 && !tooLong {
+				return nil, io.EOF
+			}
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		break
+	}
+
+	if tooLong {
+		return nil, errLineTooLong
+	}
+	return bytes.TrimSuffix(bytes.TrimSuffix(l.line, []byte("\n")), []byte("\r")), nil
+}
@@ -0,0 +1,193 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+// lineDecoder turns each non-empty line of a text log into a record with
+// parse and maps it like a CSV row.
+type lineDecoder struct {
+	lines  *lineReader
+	mapper *fieldMapper
+	parse  func(line string) (map[string]string, error)
+	line   int
+}
+
+func newLineDecoder(r io.Reader, maxLineSize int, mapper *fieldMapper, parse func(string) (map[string]string, error)) *lineDecoder {
+	mapper.requireTimestamp = true
+	return &lineDecoder{lines: newLineReader(r, maxLineSize), mapper: mapper, parse: parse}
+}
+
+func newRegexDecoder(r io.Reader, maxLineSize int, mapper *fieldMapper) (*lineDecoder, error) {
+	if mapper.mapping.Pattern == "" {
+		return nil, fmt.Errorf("regex format requires field_mapping.pattern")
+	}
+
+	pattern, err := regexp.Compile(mapper.mapping.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid field_mapping.pattern: %w", err)
+	}
+
+	hasNames := false
+	for _, name := range pattern.SubexpNames() {
+		hasNames = hasNames || name != ""
+	}
+	if !hasNames {
+		return nil, fmt.Errorf("field_mapping.pattern has no named capture groups")
+	}
+
+	return newLineDecoder(r, maxLineSize, mapper, func(line string) (map[string]string, error) {
+		return matchNamedGroups(pattern, line)
+	}), nil
+}
+
+func newLogfmtDecoder(r io.Reader, maxLineSize int, mapper *fieldMapper) *lineDecoder {
+	return newLineDecoder(r, maxLineSize, mapper, parseLogfmt)
+}
+
+func (d *lineDecoder) decode() (types.SnapEvent, error) {
+	for {
+		raw, err := d.lines.next()
+		if err == io.EOF {
+			return types.SnapEvent{}, io.EOF
+		}
+		d.line++
+		if err == errLineTooLong {
+			return types.SnapEvent{}, &RecordError{
+				Unit:     "line",
+				Position: d.line,
+				Reason:   fmt.Sprintf("line %d exceeds the maximum line size of %d bytes", d.line, d.lines.maxSize),
+			}
+		}
+		if err != nil {
+			return types.SnapEvent{}, fmt.Errorf("error reading log: %w", err)
+		}
+		line := string(raw)
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		fields, err := d.parse(line)
+		if err != nil {
+			return types.SnapEvent{}, &RecordError{
+				Unit:     "line",
+				Position: d.line,
+				Reason:   fmt.Sprintf("failed to parse line %d: %v", d.line, err),
+				Raw:      line,
+			}
+		}
+
+		event, err := d.mapper.mapRecord(d.mapper.typedFields(fields))
+		if err != nil {
+			return types.SnapEvent{}, &RecordError{
+				Unit:     "line",
+				Position: d.line,
+				Reason:   fmt.Sprintf("invalid event on line %d: %v", d.line, err),
+				Raw:      line,
+			}
+		}
+		return event, nil
+	}
+}
+
+func (d *lineDecoder) position() (string, int) {
+	return "line", d.line
+}
+
+func matchNamedGroups(pattern *regexp.Regexp, line string) (map[string]string, error) {
+	match := pattern.FindStringSubmatch(line)
+	if match == nil {
+		return nil, fmt.Errorf("line does not match pattern")
+	}
+
+	fields := make(map[string]string)
+	for i, name := range pattern.SubexpNames() {
+		if name != "" && i < len(match) {
+			fields[name] = match[i]
+		}
+	}
+	return fields, nil
+}
+
+// parseLogfmt splits a logfmt line into key/value pairs. Values may be
+// double-quoted with backslash escapes; a key without "=" has an empty value.
+func parseLogfmt(line string) (map[string]string, error) {
+	fields := make(map[string]string)
+	i := 0
+
+	for i < len(line) {
+		for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+			i++
+		}
+		if i >= len(line) {
+			break
+		}
+
+		start := i
+		for i < len(line) && line[i] != '=' && line[i] != ' ' && line[i] != '\t' {
+			if line[i] == '"' {
+				return nil, fmt.Errorf("unexpected quote in key at column %d", i+1)
+			}
+			i++
+		}
+		key := line[start:i]
+
+		if i >= len(line) || line[i] != '=' {
+			fields[key] = ""
+			continue
+		}
+		if key == "" {
+			return nil, fmt.Errorf("missing key at column %d", i+1)
+		}
+		i++
+
+		if i < len(line) && line[i] == '"' {
+			var value strings.Builder
+			i++
+			closed := false
+			for i < len(line) {
+				c := line[i]
+				if c == '\\' && i+1 < len(line) {
+					switch line[i+1] {
+					case 'n':
+						value.WriteByte('\n')
+					case 't':
+						value.WriteByte('\t')
+					default:
+						value.WriteByte(line[i+1])
+					}
+					i += 2
+					continue
+				}
+				if c == '"' {
+					closed = true
+					i++
+					break
+				}
+				value.WriteByte(c)
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated quoted value for %s", key)
+			}
+			fields[key] = value.String()
+			continue
+		}
+
+		start = i
+		for i < len(line) && line[i] != ' ' && line[i] != '\t' {
+			i++
+		}
+		fields[key] = line[start:i]
+	}
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no key=value pairs")
+	}
+	return fields, nil
+}
@@ -0,0 +1,116 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+func TestParseLogfmt(t *testing.T) {
+	fields, err := parseLogfmt(`ts=2024-01-15T10:30:00Z level=info msg="request served, \"ok\"" user_id=john.doe tokens=1200 cached`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"ts":      "2024-01-15T10:30:00Z",
+		"level":   "info",
+		"msg":     `request served, "ok"`,
+		"user_id": "john.doe",
+		"tokens":  "1200",
+		"cached":  "",
+	}
+	for key, value := range expected {
+		if fields[key] != value {
+			t.Errorf("Expected %s=%q, got %q", key, value, fields[key])
+		}
+	}
+
+	for _, line := range []string{`msg="unterminated`, `=value`, `   `} {
+		if _, err := parseLogfmt(line); err == nil {
+			t.Errorf("Expected %q to be rejected", line)
+		}
+	}
+}
+
+func TestEventReaderLogfmt(t *testing.T) {
+	data := "ts=2024-01-15T10:30:00Z request_id=r1 user_id=john.doe tokens=1200\n\nts=2024-01-15T10:31:00Z request_id=r2 user_id=jane.smith\n"
+
+	mapping := &types.FieldMapping{ID: "request_id", Timestamp: types.TimestampMapping{Field: "ts"}}
+
+	reader := mustReader(t, data, "", ReaderOptions{Mapping: mapping})
+	events, err := ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to parse logfmt: %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	if events[0].ID != "r1" || events[0].Attributes["tokens"] != 1200 || events[0].Attributes["user_id"] != "john.doe" {
+		t.Errorf("Unexpected first event: %+v", events[0])
+	}
+	if !events[1].Timestamp.Equal(time.Date(2024, 1, 15, 10, 31, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected timestamp: %v", events[1].Timestamp)
+	}
+}
+
+func TestEventReaderRegex(t *testing.T) {
+	data := `10.0.0.1 - john.doe [15/Jan/2024:10:30:00 +0000] "POST /v1/messages HTTP/1.1" 200 512
+not an access log line
+10.0.0.2 - jane.smith [15/Jan/2024:10:31:00 +0000] "GET /v1/models HTTP/1.1" 404 0
+`
+
+	mapping := &types.FieldMapping{
+		Pattern:     `^(?P<client>\S+) - (?P<user_id>\S+) \[(?P<timestamp>[^\]]+)\] "(?P<method>\S+) (?P<path>\S+)[^"]*" (?P<status>\d+) (?P<bytes>\d+)`,
+		Timestamp:   types.TimestampMapping{Format: "%d/%b/%Y:%H:%M:%S %z"},
+		GenerateIDs: true,
+	}
+
+	reader := mustReader(t, data, "", ReaderOptions{Mapping: mapping, OnError: OnErrorSkip})
+	events, err := ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to parse log: %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	if events[0].Attributes["user_id"] != "john.doe" || events[0].Attributes["status"] != 200 || events[0].Attributes["path"] != "/v1/messages" {
+		t.Errorf("Unexpected attributes: %v", events[0].Attributes)
+	}
+	if !events[0].Timestamp.Equal(time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected timestamp: %v", events[0].Timestamp)
+	}
+	if !strings.HasPrefix(events[0].ID, "evt_") {
+		t.Errorf("Expected a generated ID, got %s", events[0].ID)
+	}
+
+	recordErrors := reader.Errors()
+	if len(recordErrors) != 1 || recordErrors[0].Position != 2 {
+		t.Errorf("Expected line 2 to be rejected, got %+v", recordErrors)
+	}
+}
+
+func TestEventReaderRegexRequiresPattern(t *testing.T) {
+	if _, err := NewEventReader(strings.NewReader(""), "regex", ReaderOptions{}); err == nil {
+		t.Error("Expected regex format without a pattern to be rejected")
+	}
+
+	_, err := NewEventReader(strings.NewReader(""), "regex", ReaderOptions{Mapping: &types.FieldMapping{Pattern: `^\S+`}})
+	if err == nil || !strings.Contains(err.Error(), "named capture groups") {
+		t.Errorf("Expected pattern without named groups to be rejected, got %v", err)
+	}
+}
+
+func TestEventReaderLogRequiresTimestamp(t *testing.T) {
+	_, err := ReadAll(mustReader(t, "user_id=john.doe\n", "logfmt", ReaderOptions{}))
+
+	var recordErr *RecordError
+	if !errors.As(err, &recordErr) || !strings.Contains(recordErr.Reason, "missing timestamp") {
+		t.Errorf("Expected a missing timestamp error, got %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+type fieldMapper struct {
+	mapping          types.FieldMapping
+	requireTimestamp bool
+	timestamps       *timestampParser
+	metadata         map[string]bool
+}
+
+func newFieldMapper(mapping *types.FieldMapping) (*fieldMapper, error) {
+	m := types.FieldMapping{}
+	if mapping != nil {
+		m = *mapping
+	}
+	if m.ID == "" {
+		m.ID = "id"
+	}
+	if m.Timestamp.Field == "" {
+		m.Timestamp.Field = "timestamp"
+	}
+
+	timestamps, err := newTimestampParser(m.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fieldMapper{
+		mapping:          m,
+		requireTimestamp: mapping != nil,
+		timestamps:       timestamps,
+		metadata:         toSet(m.Metadata),
+	}, nil
+}
+
+func (f *fieldMapper) mapRecord(record map[string]interface{}) (types.SnapEvent, error) {
+	event := types.SnapEvent{
+		Attributes: make(map[string]interface{}),
+		Metadata:   make(map[string]interface{}),
+	}
+
+	if value, exists := lookupField(record, f.mapping.ID); exists && value != nil {
+		event.ID = fmt.Sprintf("%v", value)
+	}
+
+	if value, exists := lookupField(record, f.mapping.Timestamp.Field); exists && value != nil {
+		timestamp, err := f.timestamps.parse(value)
+		if err != nil {
+			return types.SnapEvent{}, err
+		}
+		event.Timestamp = timestamp
+	} else if f.requireTimestamp {
+		return types.SnapEvent{}, fmt.Errorf("missing timestamp field %s", f.mapping.Timestamp.Field)
+	}
+
+	for _, field := range f.mapping.Metadata {
+		if value, exists := lookupField(record, field); exists {
+			event.Metadata[f.rename(field)] = value
+		}
+	}
+
+	if len(f.mapping.Attributes) > 0 {
+		for _, field := range f.mapping.Attributes {
+			if value, exists := lookupField(record, field); exists {
+				event.Attributes[f.rename(field)] = value
+			}
+		}
+	} else {
+		for key, value := range record {
+			if key == f.mapping.ID || key == f.mapping.Timestamp.Field || f.metadata[key] {
+				continue
+			}
+
+			nested, isMap := value.(map[string]interface{})
+			switch {
+			case key == "attributes" && isMap:
+				for nestedKey, nestedValue := range nested {
+					event.Attributes[f.rename(nestedKey)] = nestedValue
+				}
+			case key == "metadata" && isMap:
+				for nestedKey, nestedValue := range nested {
+					event.Metadata[f.rename(nestedKey)] = nestedValue
+				}
+			default:
+				event.Attributes[f.rename(key)] = value
+			}
+		}
+
+		for field, renamed := range f.mapping.Rename {
+			if _, topLevel := record[field]; topLevel || f.metadata[field] {
+				continue
+			}
+			if value, exists := lookupField(record, field); exists {
+				event.Attributes[renamed] = value
+			}
+		}
+	}
+
+	for key, value := range f.mapping.Constants {
+		event.Attributes[key] = value
+	}
+
+	if event.ID == "" && f.mapping.GenerateIDs {
+		event.ID = generateID(record)
+	}
+
+	return event, nil
+}
+
+// typedFields converts text fields, such as CSV columns or log captures, to
+// numbers and booleans where possible. The ID and timestamp fields are left
+// as text for mapRecord.
+func (f *fieldMapper) typedFields(raw map[string]string) map[string]interface{} {
+	fields := make(map[string]interface{}, len(raw))
+	for key, value := range raw {
+		if key == f.mapping.ID || key == f.mapping.Timestamp.Field {
+			fields[key] = value
+		} else {
+			fields[key] = parseValue(value)
+		}
+	}
+	return fields
+}
+
+func (f *fieldMapper) rename(field string) string {
+	if renamed, exists := f.mapping.Rename[field]; exists {
+		return renamed
+	}
+	return field
+}
+
+// lookupField resolves a field by its exact name first and then as a dotted
+// path into nested objects.
+func lookupField(record map[string]interface{}, field string) (interface{}, bool) {
+	if value, exists := record[field]; exists {
+		return value, true
+	}
+
+	parts := strings.Split(field, ".")
+	if len(parts) == 1 {
+		return nil, false
+	}
+
+	var current interface{} = record
+	for _, part := range parts {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = object[part]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+func generateID(record map[string]interface{}) string {
+	data, err := json.Marshal(record)
+	if err != nil {
+		data = []byte(fmt.Sprintf("%v", record))
+	}
+	sum := sha256.Sum256(data)
+	return "evt_" + hex.EncodeToString(sum[:8])
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
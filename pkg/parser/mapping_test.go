@@ -0,0 +1,116 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+func TestParseEventsWithFieldMapping(t *testing.T) {
+	data := `{"request_id": "req-1", "created_at": "2024-01-15 10:30:00", "user": {"email": "john.doe@company.com"}, "model": "claude-sonnet-4", "session": "s1"}
+{"created_at": "2024-01-15 10:45:00", "user": {"email": "jane.smith@company.com"}, "model": "claude-sonnet-4", "session": "s2"}
+`
+
+	mapping := &types.FieldMapping{
+		ID: "request_id",
+		Timestamp: types.TimestampMapping{
+			Field:    "created_at",
+			Format:   "2006-01-02 15:04:05",
+			Timezone: "America/New_York",
+		},
+		Metadata:    []string{"session"},
+		Rename:      map[string]string{"user.email": "user_id"},
+		Constants:   map[string]interface{}{"source": "gateway"},
+		GenerateIDs: true,
+	}
+
+	reader, err := NewEventReader(strings.NewReader(data), "jsonl", ReaderOptions{Mapping: mapping})
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+
+	events, err := ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to parse mapped events: %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+
+	if events[0].ID != "req-1" {
+		t.Errorf("Expected ID to be 'req-1', got %s", events[0].ID)
+	}
+
+	expected := time.Date(2024, 1, 15, 15, 30, 0, 0, time.UTC)
+	if !events[0].Timestamp.Equal(expected) {
+		t.Errorf("Expected timestamp %v, got %v", expected, events[0].Timestamp.UTC())
+	}
+
+	if events[0].Attributes["user_id"] != "john.doe@company.com" {
+		t.Errorf("Expected renamed user_id attribute, got %v", events[0].Attributes)
+	}
+
+	if events[0].Attributes["source"] != "gateway" {
+		t.Errorf("Expected constant source attribute, got %v", events[0].Attributes["source"])
+	}
+
+	if events[0].Metadata["session"] != "s1" {
+		t.Errorf("Expected session in metadata, got %v", events[0].Metadata)
+	}
+
+	if _, exists := events[0].Attributes["session"]; exists {
+		t.Errorf("Expected session not to be an attribute")
+	}
+
+	if !strings.HasPrefix(events[1].ID, "evt_") {
+		t.Errorf("Expected generated ID, got %q", events[1].ID)
+	}
+}
+
+func TestFieldMappingAttributeAllowList(t *testing.T) {
+	data := "ts,user,model,cost\n2024-01-15T10:30:00Z,john.doe,claude-sonnet-4,0.02\n"
+
+	reader, err := NewEventReader(strings.NewReader(data), "csv", ReaderOptions{
+		Mapping: &types.FieldMapping{
+			Timestamp:  types.TimestampMapping{Field: "ts"},
+			Attributes: []string{"user"},
+			Rename:     map[string]string{"user": "user_id"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+
+	events, err := ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to parse mapped CSV: %v", err)
+	}
+
+	if len(events[0].Attributes) != 1 || events[0].Attributes["user_id"] != "john.doe" {
+		t.Errorf("Expected only user_id attribute, got %v", events[0].Attributes)
+	}
+}
+
+func TestFieldMappingMissingTimestamp(t *testing.T) {
+	reader, err := NewEventReader(strings.NewReader(`[{"id": "event1"}]`), "json", ReaderOptions{
+		Mapping: &types.FieldMapping{Timestamp: types.TimestampMapping{Field: "created_at"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+
+	if _, err := reader.Read(); err == nil || !strings.Contains(err.Error(), "missing timestamp field created_at") {
+		t.Errorf("Expected missing timestamp error, got %v", err)
+	}
+}
+
+func TestGenerateIDIsDeterministic(t *testing.T) {
+	record := map[string]interface{}{"user": "john.doe", "model": "claude-sonnet-4"}
+
+	if generateID(record) != generateID(map[string]interface{}{"model": "claude-sonnet-4", "user": "john.doe"}) {
+		t.Errorf("Expected generated IDs to be independent of key order")
+	}
+}
@@ -0,0 +1,208 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+const SourceFileKey = "source_file"
+
+// MultiEventReader reads events from several files in turn, tagging each
+// event with its source file and skipping events whose ID was already read
+// from an earlier file. Repeated IDs within one file are kept, as they are
+// when reading that file alone.
+type MultiEventReader struct {
+	paths      []string
+	opts       ReaderOptions
+	current    *EventReader
+	currentIdx int
+	seen       map[string]int
+	errors     []RecordError
+	count      int
+	duplicates int
+	outOfRange int
+}
+
+func ExpandEventPaths(patterns []string) ([]string, error) {
+	var paths []string
+	included := make(map[string]bool)
+
+	add := func(path string) {
+		if !included[path] {
+			included[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	for _, pattern := range patterns {
+		if pattern == "-" || strings.HasPrefix(pattern, SQLiteScheme) {
+			add(pattern)
+			continue
+		}
+
+		matches := []string{pattern}
+		if strings.ContainsAny(pattern, "*?[") {
+			globbed, err := filepath.Glob(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid glob %s: %w", pattern, err)
+			}
+			if len(globbed) == 0 {
+				return nil, fmt.Errorf("no files match %s", pattern)
+			}
+			sort.Strings(globbed)
+			matches = globbed
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", match, err)
+			}
+
+			if !info.IsDir() {
+				add(match)
+				continue
+			}
+
+			files, err := eventFilesInDir(match)
+			if err != nil {
+				return nil, err
+			}
+			for _, file := range files {
+				add(file)
+			}
+		}
+	}
+
+	return paths, nil
+}
+
+func eventFilesInDir(dir string) ([]string, error) {
+	var files []string
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(entry.Name(), ".") && path != dir {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.IsDir() && determineFormat(path) != "" {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %w", dir, err)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+func OpenEventFiles(patterns []string, opts ReaderOptions) (*MultiEventReader, error) {
+	paths, err := ExpandEventPaths(patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MultiEventReader{
+		paths: paths,
+		opts:  opts,
+		seen:  make(map[string]int),
+	}, nil
+}
+
+func (m *MultiEventReader) Read() (types.SnapEvent, error) {
+	for {
+		if m.current == nil {
+			if m.currentIdx >= len(m.paths) {
+				return types.SnapEvent{}, io.EOF
+			}
+
+			reader, err := OpenEventFile(m.paths[m.currentIdx], m.opts)
+			if err != nil {
+				return types.SnapEvent{}, err
+			}
+			m.current = reader
+		}
+
+		event, err := m.current.Read()
+		if err == io.EOF {
+			m.errors = append(m.errors, m.current.Errors()...)
+			m.outOfRange += m.current.OutOfRange()
+			m.current.Close()
+			m.current = nil
+			m.currentIdx++
+			continue
+		}
+		if err != nil {
+			return types.SnapEvent{}, fmt.Errorf("%s: %w", m.paths[m.currentIdx], err)
+		}
+
+		if event.ID != "" {
+			if file, seen := m.seen[event.ID]; seen && file != m.currentIdx {
+				m.duplicates++
+				continue
+			}
+			m.seen[event.ID] = m.currentIdx
+		}
+
+		if event.Metadata == nil {
+			event.Metadata = make(map[string]interface{})
+		}
+		event.Metadata[SourceFileKey] = m.paths[m.currentIdx]
+
+		m.count++
+		return event, nil
+	}
+}
+
+func (m *MultiEventReader) Paths() []string {
+	return m.paths
+}
+
+func (m *MultiEventReader) Count() int {
+	return m.count
+}
+
+func (m *MultiEventReader) Duplicates() int {
+	return m.duplicates
+}
+
+// OutOfRange returns the number of events dropped so far for lying outside
+// the Since and Until of the reader options.
+func (m *MultiEventReader) OutOfRange() int {
+	if m.current == nil {
+		return m.outOfRange
+	}
+	return m.outOfRange + m.current.OutOfRange()
+}
+
+// Errors returns the records skipped so far when reading with OnError set to
+// skip or quarantine.
+func (m *MultiEventReader) Errors() []RecordError {
+	if m.current == nil {
+		return m.errors
+	}
+	return append(append([]RecordError(nil), m.errors...), m.current.Errors()...)
+}
+
+func (m *MultiEventReader) Close() error {
+	if m.current != nil {
+		err := m.current.Close()
+		m.current = nil
+		return err
+	}
+	return nil
+}
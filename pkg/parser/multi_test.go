@@ -0,0 +1,164 @@
+package parser
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeMultiTestFiles(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"2024-01-15.jsonl": `{"id": "event1", "timestamp": "2024-01-15T10:00:00Z", "attributes": {"user_id": "john.doe"}}
+{"id": "event2", "timestamp": "2024-01-15T11:00:00Z", "attributes": {"user_id": "jane.smith"}}
+`,
+		"2024-01-16.csv":         "id,timestamp,user_id\nevent2,2024-01-16T10:00:00Z,jane.smith\nevent3,2024-01-16T11:00:00Z,john.doe\n",
+		"nested/2024-01-17.json": `[{"id": "event4", "timestamp": "2024-01-17T10:00:00Z", "attributes": {"user_id": "john.doe"}}]`,
+		"README.md":              "not events",
+		".hidden/events.json":    `[{"id": "hidden"}]`,
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	return dir
+}
+
+func TestExpandEventPaths(t *testing.T) {
+	dir := writeMultiTestFiles(t)
+
+	paths, err := ExpandEventPaths([]string{dir})
+	if err != nil {
+		t.Fatalf("Failed to expand directory: %v", err)
+	}
+	if len(paths) != 3 {
+		t.Errorf("Expected 3 event files in directory, got %v", paths)
+	}
+
+	paths, err = ExpandEventPaths([]string{filepath.Join(dir, "2024-*"), filepath.Join(dir, "2024-01-15.jsonl")})
+	if err != nil {
+		t.Fatalf("Failed to expand glob: %v", err)
+	}
+	if len(paths) != 2 {
+		t.Errorf("Expected 2 unique files from glob, got %v", paths)
+	}
+
+	if _, err := ExpandEventPaths([]string{filepath.Join(dir, "*.parquet")}); err == nil {
+		t.Errorf("Expected error for glob without matches")
+	}
+}
+
+func TestMultiEventReaderMergesAndDeduplicates(t *testing.T) {
+	dir := writeMultiTestFiles(t)
+
+	reader, err := OpenEventFiles([]string{dir}, ReaderOptions{})
+	if err != nil {
+		t.Fatalf("Failed to open event files: %v", err)
+	}
+	defer reader.Close()
+
+	sources := make(map[string]string)
+	for {
+		event, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read events: %v", err)
+		}
+		sources[event.ID] = filepath.Base(event.Metadata[SourceFileKey].(string))
+	}
+
+	if len(sources) != 4 || reader.Count() != 4 {
+		t.Fatalf("Expected 4 unique events, got %v", sources)
+	}
+
+	if reader.Duplicates() != 1 {
+		t.Errorf("Expected 1 duplicate, got %d", reader.Duplicates())
+	}
+
+	if sources["event2"] != "2024-01-15.jsonl" {
+		t.Errorf("Expected first occurrence of event2 to win, got %s", sources["event2"])
+	}
+
+	if sources["event4"] != "2024-01-17.json" {
+		t.Errorf("Expected event4 from nested file, got %s", sources["event4"])
+	}
+}
+
+func TestMultiEventReaderKeepsDuplicatesWithinAFile(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "a.jsonl")
+	second := filepath.Join(dir, "b.jsonl")
+	if err := os.WriteFile(first, []byte(`{"id": "retry", "timestamp": "2024-01-15T10:00:00Z"}
+{"id": "retry", "timestamp": "2024-01-15T10:05:00Z"}
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte(`{"id": "retry", "timestamp": "2024-01-15T10:00:00Z"}
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := OpenEventFiles([]string{first, second}, ReaderOptions{})
+	if err != nil {
+		t.Fatalf("Failed to open event files: %v", err)
+	}
+	defer reader.Close()
+
+	count := 0
+	for {
+		_, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read events: %v", err)
+		}
+		count++
+	}
+	if count != 2 || reader.Duplicates() != 1 {
+		t.Errorf("Expected both events of a.jsonl and the copy in b.jsonl skipped, got %d events and %d duplicates", count, reader.Duplicates())
+	}
+}
+
+func TestMultiEventReaderCountsEventsOutOfRange(t *testing.T) {
+	dir := writeMultiTestFiles(t)
+
+	reader, err := OpenEventFiles([]string{dir}, ReaderOptions{
+		Since: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		Until: time.Date(2024, 1, 16, 10, 30, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Failed to open event files: %v", err)
+	}
+	defer reader.Close()
+
+	for {
+		_, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read events: %v", err)
+		}
+	}
+
+	// event1, event3 and event4 lie outside the range; event2 is read from
+	// the first file and skipped as a duplicate in the second.
+	if reader.Count() != 1 || reader.OutOfRange() != 3 || reader.Duplicates() != 1 {
+		t.Errorf("Expected 1 event, 3 out of range and 1 duplicate, got %d, %d and %d",
+			reader.Count(), reader.OutOfRange(), reader.Duplicates())
+	}
+}
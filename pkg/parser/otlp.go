@@ -0,0 +1,346 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+const (
+	OTLPSignalKey   = "otel.signal"
+	OTLPTraceIDKey  = "trace_id"
+	OTLPSpanIDKey   = "span_id"
+	OTLPParentIDKey = "parent_span_id"
+	OTLPScopeKey    = "otel.scope"
+)
+
+var spanKinds = map[int]string{
+	1: "internal",
+	2: "server",
+	3: "client",
+	4: "producer",
+	5: "consumer",
+}
+
+// The otlp* types mirror the OTLP ExportTraceServiceRequest and
+// ExportLogsServiceRequest messages. They are filled from the protobuf JSON
+// encoding or from the binary encoding in otlp_proto.go.
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	ResourceLogs  []otlpResourceLogs  `json:"resourceLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId"`
+	Name              string         `json:"name"`
+	Kind              otlpEnum       `json:"kind"`
+	StartTimeUnixNano otlpUint64     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   otlpUint64     `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         otlpUint64     `json:"timeUnixNano"`
+	ObservedTimeUnixNano otlpUint64     `json:"observedTimeUnixNano"`
+	SeverityText         string         `json:"severityText"`
+	Body                 *otlpAnyValue  `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes"`
+	TraceID              string         `json:"traceId"`
+	SpanID               string         `json:"spanId"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string         `json:"stringValue"`
+	BoolValue   *bool           `json:"boolValue"`
+	IntValue    *otlpInt64      `json:"intValue"`
+	DoubleValue *float64        `json:"doubleValue"`
+	ArrayValue  *otlpArrayValue `json:"arrayValue"`
+	KvlistValue *otlpKvlist     `json:"kvlistValue"`
+	BytesValue  *string         `json:"bytesValue"`
+}
+
+type otlpArrayValue struct {
+	Values []otlpAnyValue `json:"values"`
+}
+
+type otlpKvlist struct {
+	Values []otlpKeyValue `json:"values"`
+}
+
+// otlpUint64 and otlpInt64 accept both the string encoding the OTLP JSON
+// spec requires for 64-bit integers and plain numbers.
+type otlpUint64 uint64
+
+func (u *otlpUint64) UnmarshalJSON(data []byte) error {
+	value, err := strconv.ParseUint(strings.Trim(string(data), `"`), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid OTLP timestamp %s", data)
+	}
+	*u = otlpUint64(value)
+	return nil
+}
+
+type otlpInt64 int64
+
+func (i *otlpInt64) UnmarshalJSON(data []byte) error {
+	value, err := strconv.ParseInt(strings.Trim(string(data), `"`), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid OTLP integer %s", data)
+	}
+	*i = otlpInt64(value)
+	return nil
+}
+
+// otlpEnum accepts span kinds as numbers or as SPAN_KIND_* names.
+type otlpEnum int
+
+func (e *otlpEnum) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if value, err := strconv.Atoi(text); err == nil {
+		*e = otlpEnum(value)
+		return nil
+	}
+
+	name := strings.ToLower(strings.TrimPrefix(text, "SPAN_KIND_"))
+	for value, kind := range spanKinds {
+		if kind == name {
+			*e = otlpEnum(value)
+			return nil
+		}
+	}
+	*e = 0
+	return nil
+}
+
+func (v otlpAnyValue) value() interface{} {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return *v.BoolValue
+	case v.IntValue != nil:
+		return int64(*v.IntValue)
+	case v.DoubleValue != nil:
+		return *v.DoubleValue
+	case v.ArrayValue != nil:
+		values := make([]interface{}, len(v.ArrayValue.Values))
+		for i, item := range v.ArrayValue.Values {
+			values[i] = item.value()
+		}
+		return values
+	case v.KvlistValue != nil:
+		return attributeMap(v.KvlistValue.Values)
+	case v.BytesValue != nil:
+		return *v.BytesValue
+	default:
+		return nil
+	}
+}
+
+func attributeMap(attributes []otlpKeyValue) map[string]interface{} {
+	values := make(map[string]interface{}, len(attributes))
+	for _, attribute := range attributes {
+		values[attribute.Key] = attribute.Value.value()
+	}
+	return values
+}
+
+func unixNano(nanos otlpUint64) time.Time {
+	return time.Unix(0, int64(nanos)).UTC()
+}
+
+// events flattens a request into one event per span and log record. Resource
+// attributes are copied onto every event and overridden by span or log
+// attributes of the same name.
+func (r *otlpRequest) events() []types.SnapEvent {
+	var events []types.SnapEvent
+
+	for _, resourceSpans := range r.ResourceSpans {
+		resource := attributeMap(resourceSpans.Resource.Attributes)
+		for _, scopeSpans := range resourceSpans.ScopeSpans {
+			for _, span := range scopeSpans.Spans {
+				events = append(events, spanEvent(span, scopeSpans.Scope, resource))
+			}
+		}
+	}
+
+	for _, resourceLogs := range r.ResourceLogs {
+		resource := attributeMap(resourceLogs.Resource.Attributes)
+		for _, scopeLogs := range resourceLogs.ScopeLogs {
+			for _, record := range scopeLogs.LogRecords {
+				events = append(events, logEvent(record, scopeLogs.Scope, resource))
+			}
+		}
+	}
+
+	return events
+}
+
+func spanEvent(span otlpSpan, scope otlpScope, resource map[string]interface{}) types.SnapEvent {
+	event := types.SnapEvent{
+		ID:         span.SpanID,
+		Timestamp:  unixNano(span.StartTimeUnixNano),
+		Attributes: make(map[string]interface{}, len(resource)+len(span.Attributes)+2),
+		Metadata: map[string]interface{}{
+			OTLPSignalKey:  "span",
+			OTLPTraceIDKey: span.TraceID,
+			OTLPSpanIDKey:  span.SpanID,
+		},
+	}
+
+	for key, value := range resource {
+		event.Attributes[key] = value
+	}
+	for key, value := range attributeMap(span.Attributes) {
+		event.Attributes[key] = value
+	}
+	event.Attributes["span.name"] = span.Name
+	if kind, known := spanKinds[int(span.Kind)]; known {
+		event.Attributes["span.kind"] = kind
+	}
+
+	if span.ParentSpanID != "" {
+		event.Metadata[OTLPParentIDKey] = span.ParentSpanID
+	}
+	if span.EndTimeUnixNano >= span.StartTimeUnixNano && span.EndTimeUnixNano > 0 {
+		event.Metadata["duration_ms"] = float64(span.EndTimeUnixNano-span.StartTimeUnixNano) / float64(time.Millisecond)
+	}
+	if scope.Name != "" {
+		event.Metadata[OTLPScopeKey] = scope.Name
+	}
+
+	return event
+}
+
+func logEvent(record otlpLogRecord, scope otlpScope, resource map[string]interface{}) types.SnapEvent {
+	timestamp := record.TimeUnixNano
+	if timestamp == 0 {
+		timestamp = record.ObservedTimeUnixNano
+	}
+
+	event := types.SnapEvent{
+		Timestamp:  unixNano(timestamp),
+		Attributes: make(map[string]interface{}, len(resource)+len(record.Attributes)+2),
+		Metadata: map[string]interface{}{
+			OTLPSignalKey: "log",
+		},
+	}
+
+	for key, value := range resource {
+		event.Attributes[key] = value
+	}
+	for key, value := range attributeMap(record.Attributes) {
+		event.Attributes[key] = value
+	}
+	if record.Body != nil {
+		event.Attributes["log.body"] = record.Body.value()
+	}
+	if record.SeverityText != "" {
+		event.Attributes["log.severity"] = record.SeverityText
+	}
+
+	if record.TraceID != "" {
+		event.Metadata[OTLPTraceIDKey] = record.TraceID
+	}
+	if record.SpanID != "" {
+		event.Metadata[OTLPSpanIDKey] = record.SpanID
+	}
+	if scope.Name != "" {
+		event.Metadata[OTLPScopeKey] = scope.Name
+	}
+
+	event.ID = generateID(map[string]interface{}{
+		"timestamp":  int64(timestamp),
+		"attributes": event.Attributes,
+		"metadata":   event.Metadata,
+	})
+
+	return event
+}
+
+// otlpDecoder reads a sequence of export requests and emits their spans and
+// log records one at a time.
+type otlpDecoder struct {
+	next    func() (*otlpRequest, error)
+	pending []types.SnapEvent
+	request int
+}
+
+func (d *otlpDecoder) decode() (types.SnapEvent, error) {
+	for len(d.pending) == 0 {
+		request, err := d.next()
+		if err != nil {
+			return types.SnapEvent{}, err
+		}
+		d.request++
+		d.pending = request.events()
+	}
+
+	event := d.pending[0]
+	d.pending = d.pending[1:]
+	return event, nil
+}
+
+func (d *otlpDecoder) position() (string, int) {
+	return "request", d.request
+}
+
+// newOTLPJSONDecoder reads the OTLP JSON encoding as written by the collector
+// file exporter: one export request per line, or a single request.
+func newOTLPJSONDecoder(r io.Reader) *otlpDecoder {
+	decoder := json.NewDecoder(r)
+	request := 0
+
+	return &otlpDecoder{next: func() (*otlpRequest, error) {
+		var parsed otlpRequest
+		if err := decoder.Decode(&parsed); err != nil {
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("failed to parse OTLP request %d: %w", request+1, err)
+		}
+		request++
+		return &parsed, nil
+	}}
+}
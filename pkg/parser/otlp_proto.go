@@ -0,0 +1,404 @@
+package parser
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// maxOTLPMessageSize bounds the length prefix read from a protobuf dump so a
+// corrupt or non-OTLP file fails instead of allocating gigabytes.
+const maxOTLPMessageSize = 256 * 1024 * 1024
+
+// newOTLPProtoDecoder reads binary OTLP export requests as written by the
+// collector file exporter in proto mode: each message is preceded by its
+// length as a 4-byte big-endian integer.
+func newOTLPProtoDecoder(r io.Reader) *otlpDecoder {
+	request := 0
+
+	return &otlpDecoder{next: func() (*otlpRequest, error) {
+		var size [4]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("failed to read OTLP message %d: %w", request+1, err)
+		}
+		request++
+
+		length := binary.BigEndian.Uint32(size[:])
+		if length > maxOTLPMessageSize {
+			return nil, fmt.Errorf("OTLP message %d is %d bytes, which exceeds the maximum of %d", request, length, maxOTLPMessageSize)
+		}
+
+		message := make([]byte, length)
+		if _, err := io.ReadFull(r, message); err != nil {
+			return nil, fmt.Errorf("failed to read OTLP message %d: %w", request, err)
+		}
+
+		parsed, err := parseOTLPRequest(message)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse OTLP message %d: %w", request, err)
+		}
+		return parsed, nil
+	}}
+}
+
+type protoField struct {
+	number  int
+	wire    int
+	varint  uint64
+	fixed   uint64
+	payload []byte
+}
+
+func walkProto(data []byte, visit func(field protoField) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return fmt.Errorf("invalid field key")
+		}
+		data = data[n:]
+
+		field := protoField{number: int(key >> 3), wire: int(key & 7)}
+		switch field.wire {
+		case wireVarint:
+			value, n := binary.Uvarint(data)
+			if n <= 0 {
+				return fmt.Errorf("invalid varint in field %d", field.number)
+			}
+			field.varint = value
+			data = data[n:]
+		case wireFixed64:
+			if len(data) < 8 {
+				return fmt.Errorf("truncated field %d", field.number)
+			}
+			field.fixed = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return fmt.Errorf("truncated field %d", field.number)
+			}
+			field.payload = data[n : n+int(length)]
+			data = data[n+int(length):]
+		case wireFixed32:
+			if len(data) < 4 {
+				return fmt.Errorf("truncated field %d", field.number)
+			}
+			field.fixed = uint64(binary.LittleEndian.Uint32(data))
+			data = data[4:]
+		default:
+			return fmt.Errorf("unsupported wire type %d in field %d", field.wire, field.number)
+		}
+
+		if err := visit(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseOTLPRequest decodes either request type. ExportTraceServiceRequest
+// and ExportLogsServiceRequest both keep their resource entries in field 1
+// and nest them identically, so the binary encoding does not name the
+// signal; it is told apart by the first fields of the innermost records,
+// which always differ in wire type: a span starts with its trace and span
+// IDs (bytes), a log record with its timestamp (fixed64) and severity
+// number (varint). Zero values are omitted from the encoding, so observed
+// time (fixed64 field 11) also marks a log record.
+func parseOTLPRequest(data []byte) (*otlpRequest, error) {
+	request := &otlpRequest{}
+
+	isSpans, err := requestHasSpans(data)
+	if err != nil {
+		return nil, err
+	}
+
+	err = walkProto(data, func(field protoField) error {
+		if field.number != 1 || field.wire != wireBytes {
+			return nil
+		}
+
+		if isSpans {
+			resourceSpans, err := parseResourceSpans(field.payload)
+			if err != nil {
+				return err
+			}
+			request.ResourceSpans = append(request.ResourceSpans, resourceSpans)
+			return nil
+		}
+
+		resourceLogs, err := parseResourceLogs(field.payload)
+		if err != nil {
+			return err
+		}
+		request.ResourceLogs = append(request.ResourceLogs, resourceLogs)
+		return nil
+	})
+
+	return request, err
+}
+
+// errSignalFound stops the walk in requestHasSpans once a record decides it.
+var errSignalFound = errors.New("signal found")
+
+func requestHasSpans(data []byte) (bool, error) {
+	isSpans := false
+	err := walkProto(data, func(resource protoField) error {
+		if resource.number != 1 || resource.wire != wireBytes {
+			return nil
+		}
+		return walkProto(resource.payload, func(scope protoField) error {
+			if scope.number != 2 || scope.wire != wireBytes {
+				return nil
+			}
+			return walkProto(scope.payload, func(record protoField) error {
+				if record.number != 2 || record.wire != wireBytes {
+					return nil
+				}
+				return walkProto(record.payload, func(field protoField) error {
+					switch {
+					case (field.number == 1 || field.number == 2) && field.wire == wireBytes,
+						(field.number == 7 || field.number == 8) && field.wire == wireFixed64:
+						isSpans = true
+						return errSignalFound
+					case (field.number == 1 || field.number == 11) && field.wire == wireFixed64,
+						field.number == 2 && field.wire == wireVarint:
+						return errSignalFound
+					}
+					return nil
+				})
+			})
+		})
+	})
+	if err == errSignalFound {
+		err = nil
+	}
+	return isSpans, err
+}
+
+func parseResourceSpans(data []byte) (otlpResourceSpans, error) {
+	var resourceSpans otlpResourceSpans
+
+	err := walkProto(data, func(field protoField) error {
+		switch {
+		case field.number == 1 && field.wire == wireBytes:
+			resource, err := parseResource(field.payload)
+			resourceSpans.Resource = resource
+			return err
+		case field.number == 2 && field.wire == wireBytes:
+			var scopeSpans otlpScopeSpans
+			err := walkProto(field.payload, func(field protoField) error {
+				switch {
+				case field.number == 1 && field.wire == wireBytes:
+					scope, err := parseScope(field.payload)
+					scopeSpans.Scope = scope
+					return err
+				case field.number == 2 && field.wire == wireBytes:
+					span, err := parseSpan(field.payload)
+					scopeSpans.Spans = append(scopeSpans.Spans, span)
+					return err
+				}
+				return nil
+			})
+			resourceSpans.ScopeSpans = append(resourceSpans.ScopeSpans, scopeSpans)
+			return err
+		}
+		return nil
+	})
+
+	return resourceSpans, err
+}
+
+func parseResourceLogs(data []byte) (otlpResourceLogs, error) {
+	var resourceLogs otlpResourceLogs
+
+	err := walkProto(data, func(field protoField) error {
+		switch {
+		case field.number == 1 && field.wire == wireBytes:
+			resource, err := parseResource(field.payload)
+			resourceLogs.Resource = resource
+			return err
+		case field.number == 2 && field.wire == wireBytes:
+			var scopeLogs otlpScopeLogs
+			err := walkProto(field.payload, func(field protoField) error {
+				switch {
+				case field.number == 1 && field.wire == wireBytes:
+					scope, err := parseScope(field.payload)
+					scopeLogs.Scope = scope
+					return err
+				case field.number == 2 && field.wire == wireBytes:
+					record, err := parseLogRecord(field.payload)
+					scopeLogs.LogRecords = append(scopeLogs.LogRecords, record)
+					return err
+				}
+				return nil
+			})
+			resourceLogs.ScopeLogs = append(resourceLogs.ScopeLogs, scopeLogs)
+			return err
+		}
+		return nil
+	})
+
+	return resourceLogs, err
+}
+
+func parseResource(data []byte) (otlpResource, error) {
+	var resource otlpResource
+	err := walkProto(data, func(field protoField) error {
+		if field.number == 1 && field.wire == wireBytes {
+			attribute, err := parseKeyValue(field.payload)
+			resource.Attributes = append(resource.Attributes, attribute)
+			return err
+		}
+		return nil
+	})
+	return resource, err
+}
+
+func parseScope(data []byte) (otlpScope, error) {
+	var scope otlpScope
+	err := walkProto(data, func(field protoField) error {
+		switch {
+		case field.number == 1 && field.wire == wireBytes:
+			scope.Name = string(field.payload)
+		case field.number == 2 && field.wire == wireBytes:
+			scope.Version = string(field.payload)
+		}
+		return nil
+	})
+	return scope, err
+}
+
+func parseSpan(data []byte) (otlpSpan, error) {
+	var span otlpSpan
+	err := walkProto(data, func(field protoField) error {
+		switch {
+		case field.number == 1 && field.wire == wireBytes:
+			span.TraceID = hex.EncodeToString(field.payload)
+		case field.number == 2 && field.wire == wireBytes:
+			span.SpanID = hex.EncodeToString(field.payload)
+		case field.number == 4 && field.wire == wireBytes:
+			span.ParentSpanID = hex.EncodeToString(field.payload)
+		case field.number == 5 && field.wire == wireBytes:
+			span.Name = string(field.payload)
+		case field.number == 6 && field.wire == wireVarint:
+			span.Kind = otlpEnum(field.varint)
+		case field.number == 7 && field.wire == wireFixed64:
+			span.StartTimeUnixNano = otlpUint64(field.fixed)
+		case field.number == 8 && field.wire == wireFixed64:
+			span.EndTimeUnixNano = otlpUint64(field.fixed)
+		case field.number == 9 && field.wire == wireBytes:
+			attribute, err := parseKeyValue(field.payload)
+			span.Attributes = append(span.Attributes, attribute)
+			return err
+		}
+		return nil
+	})
+	return span, err
+}
+
+func parseLogRecord(data []byte) (otlpLogRecord, error) {
+	var record otlpLogRecord
+	err := walkProto(data, func(field protoField) error {
+		switch {
+		case field.number == 1 && field.wire == wireFixed64:
+			record.TimeUnixNano = otlpUint64(field.fixed)
+		case field.number == 11 && field.wire == wireFixed64:
+			record.ObservedTimeUnixNano = otlpUint64(field.fixed)
+		case field.number == 3 && field.wire == wireBytes:
+			record.SeverityText = string(field.payload)
+		case field.number == 5 && field.wire == wireBytes:
+			body, err := parseAnyValue(field.payload)
+			record.Body = &body
+			return err
+		case field.number == 6 && field.wire == wireBytes:
+			attribute, err := parseKeyValue(field.payload)
+			record.Attributes = append(record.Attributes, attribute)
+			return err
+		case field.number == 9 && field.wire == wireBytes:
+			record.TraceID = hex.EncodeToString(field.payload)
+		case field.number == 10 && field.wire == wireBytes:
+			record.SpanID = hex.EncodeToString(field.payload)
+		}
+		return nil
+	})
+	return record, err
+}
+
+func parseKeyValue(data []byte) (otlpKeyValue, error) {
+	var keyValue otlpKeyValue
+	err := walkProto(data, func(field protoField) error {
+		switch {
+		case field.number == 1 && field.wire == wireBytes:
+			keyValue.Key = string(field.payload)
+		case field.number == 2 && field.wire == wireBytes:
+			value, err := parseAnyValue(field.payload)
+			keyValue.Value = value
+			return err
+		}
+		return nil
+	})
+	return keyValue, err
+}
+
+func parseAnyValue(data []byte) (otlpAnyValue, error) {
+	var value otlpAnyValue
+	err := walkProto(data, func(field protoField) error {
+		switch {
+		case field.number == 1 && field.wire == wireBytes:
+			text := string(field.payload)
+			value.StringValue = &text
+		case field.number == 2 && field.wire == wireVarint:
+			flag := field.varint != 0
+			value.BoolValue = &flag
+		case field.number == 3 && field.wire == wireVarint:
+			integer := otlpInt64(int64(field.varint))
+			value.IntValue = &integer
+		case field.number == 4 && field.wire == wireFixed64:
+			double := math.Float64frombits(field.fixed)
+			value.DoubleValue = &double
+		case field.number == 5 && field.wire == wireBytes:
+			array := &otlpArrayValue{}
+			err := walkProto(field.payload, func(item protoField) error {
+				if item.number != 1 || item.wire != wireBytes {
+					return nil
+				}
+				element, err := parseAnyValue(item.payload)
+				array.Values = append(array.Values, element)
+				return err
+			})
+			value.ArrayValue = array
+			return err
+		case field.number == 6 && field.wire == wireBytes:
+			list := &otlpKvlist{}
+			err := walkProto(field.payload, func(item protoField) error {
+				if item.number != 1 || item.wire != wireBytes {
+					return nil
+				}
+				entry, err := parseKeyValue(item.payload)
+				list.Values = append(list.Values, entry)
+				return err
+			})
+			value.KvlistValue = list
+			return err
+		case field.number == 7 && field.wire == wireBytes:
+			encoded := base64.StdEncoding.EncodeToString(field.payload)
+			value.BytesValue = &encoded
+		}
+		return nil
+	})
+	return value, err
+}
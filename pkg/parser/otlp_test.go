@@ -0,0 +1,187 @@
+package parser
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+const otlpTracesJSON = `{"resourceSpans": [{"resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "ai-gateway"}}, {"key": "user_id", "value": {"stringValue": "default"}}]}, "scopeSpans": [{"scope": {"name": "gateway"}, "spans": [{"traceId": "5b8efff798038103d269b633813fc60c", "spanId": "eee19b7ec3c1b174", "parentSpanId": "eee19b7ec3c1b173", "name": "inference", "kind": 3, "startTimeUnixNano": "1705314600000000000", "endTimeUnixNano": "1705314601500000000", "attributes": [{"key": "user_id", "value": {"stringValue": "john.doe@company.com"}}, {"key": "tokens", "value": {"intValue": "1200"}}, {"key": "cached", "value": {"boolValue": true}}]}]}]}]}
+{"resourceLogs": [{"resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "ai-gateway"}}]}, "scopeLogs": [{"logRecords": [{"timeUnixNano": "1705314660000000000", "severityText": "INFO", "body": {"stringValue": "completion served"}, "traceId": "5b8efff798038103d269b633813fc60c", "attributes": [{"key": "model", "value": {"stringValue": "claude-sonnet-4"}}]}]}]}]}
+`
+
+func TestParseOTLPJSON(t *testing.T) {
+	reader := mustReader(t, otlpTracesJSON, "", ReaderOptions{})
+	events, err := ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to parse OTLP JSON: %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+
+	span := events[0]
+	if span.ID != "eee19b7ec3c1b174" {
+		t.Errorf("Expected span ID as event ID, got %s", span.ID)
+	}
+	if !span.Timestamp.Equal(time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)) {
+		t.Errorf("Expected span start time, got %v", span.Timestamp)
+	}
+	if span.Attributes["user_id"] != "john.doe@company.com" {
+		t.Errorf("Expected span attributes to override resource attributes, got %v", span.Attributes["user_id"])
+	}
+	if span.Attributes["service.name"] != "ai-gateway" || span.Attributes["tokens"] != int64(1200) || span.Attributes["cached"] != true {
+		t.Errorf("Unexpected span attributes: %v", span.Attributes)
+	}
+	if span.Attributes["span.name"] != "inference" || span.Attributes["span.kind"] != "client" {
+		t.Errorf("Expected span name and kind attributes, got %v", span.Attributes)
+	}
+	if span.Metadata[OTLPTraceIDKey] != "5b8efff798038103d269b633813fc60c" || span.Metadata[OTLPParentIDKey] != "eee19b7ec3c1b173" {
+		t.Errorf("Expected trace IDs in metadata, got %v", span.Metadata)
+	}
+	if span.Metadata["duration_ms"] != 1500.0 {
+		t.Errorf("Expected duration of 1500ms, got %v", span.Metadata["duration_ms"])
+	}
+
+	log := events[1]
+	if log.Metadata[OTLPSignalKey] != "log" || log.Attributes["log.body"] != "completion served" || log.Attributes["model"] != "claude-sonnet-4" {
+		t.Errorf("Unexpected log event: %+v", log)
+	}
+	if !strings.HasPrefix(log.ID, "evt_") {
+		t.Errorf("Expected a generated ID for the log record, got %s", log.ID)
+	}
+}
+
+func TestParseOTLPProto(t *testing.T) {
+	traceID, _ := hex.DecodeString("5b8efff798038103d269b633813fc60c")
+	spanID, _ := hex.DecodeString("eee19b7ec3c1b174")
+	start := uint64(time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC).UnixNano())
+
+	keyValue := func(key string, value []byte) []byte {
+		return protoBytes(protoBytes(nil, 1, []byte(key)), 2, value)
+	}
+
+	span := protoBytes(nil, 1, traceID)
+	span = protoBytes(span, 2, spanID)
+	span = protoBytes(span, 5, []byte("inference"))
+	span = protoVarint(span, 6, 2)
+	span = protoFixed64(span, 7, start)
+	span = protoBytes(span, 9, keyValue("user_id", protoBytes(nil, 1, []byte("john.doe@company.com"))))
+	span = protoBytes(span, 9, keyValue("temperature", protoFixed64(nil, 4, math.Float64bits(0.2))))
+
+	scopeSpans := protoBytes(nil, 2, span)
+	resource := protoBytes(nil, 1, keyValue("service.name", protoBytes(nil, 1, []byte("ai-gateway"))))
+	resourceSpans := protoBytes(protoBytes(nil, 1, resource), 2, scopeSpans)
+	request := protoBytes(nil, 1, resourceSpans)
+
+	record := protoFixed64(nil, 1, start)
+	record = protoBytes(record, 5, protoBytes(nil, 1, []byte("done")))
+	logsRequest := protoBytes(nil, 1, protoBytes(nil, 2, protoBytes(nil, 2, record)))
+
+	var dump []byte
+	for _, message := range [][]byte{request, logsRequest} {
+		dump = binary.BigEndian.AppendUint32(dump, uint32(len(message)))
+		dump = append(dump, message...)
+	}
+
+	events, err := ReadAll(mustReader(t, string(dump), "otlp-proto", ReaderOptions{}))
+	if err != nil {
+		t.Fatalf("Failed to parse OTLP protobuf: %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+
+	if events[0].ID != "eee19b7ec3c1b174" || events[0].Metadata[OTLPTraceIDKey] != "5b8efff798038103d269b633813fc60c" {
+		t.Errorf("Unexpected span IDs: %+v", events[0])
+	}
+	if events[0].Attributes["user_id"] != "john.doe@company.com" || events[0].Attributes["temperature"] != 0.2 || events[0].Attributes["service.name"] != "ai-gateway" {
+		t.Errorf("Unexpected span attributes: %v", events[0].Attributes)
+	}
+	if events[0].Attributes["span.kind"] != "server" || !events[0].Timestamp.Equal(time.Unix(0, int64(start))) {
+		t.Errorf("Unexpected span kind or timestamp: %+v", events[0])
+	}
+	if events[1].Metadata[OTLPSignalKey] != "log" || events[1].Attributes["log.body"] != "done" {
+		t.Errorf("Unexpected log event: %+v", events[1])
+	}
+}
+
+func TestDetectOTLPFromJSONExtension(t *testing.T) {
+	path := writeTestFile(t, "traces.json", []byte(otlpTracesJSON))
+
+	events, err := ParseEventsFromFile(path)
+	if err != nil {
+		t.Fatalf("Failed to parse collector export: %v", err)
+	}
+	if len(events) != 2 {
+		t.Errorf("Expected 2 events, got %d", len(events))
+	}
+}
+
+func protoTag(data []byte, number, wire int) []byte {
+	return binary.AppendUvarint(data, uint64(number<<3|wire))
+}
+
+func protoBytes(data []byte, number int, payload []byte) []byte {
+	data = protoTag(data, number, wireBytes)
+	data = binary.AppendUvarint(data, uint64(len(payload)))
+	return append(data, payload...)
+}
+
+func protoVarint(data []byte, number int, value uint64) []byte {
+	return binary.AppendUvarint(protoTag(data, number, wireVarint), value)
+}
+
+func protoFixed64(data []byte, number int, value uint64) []byte {
+	return binary.LittleEndian.AppendUint64(protoTag(data, number, wireFixed64), value)
+}
+
+func TestParseOTLPProtoSpanWithoutStartTime(t *testing.T) {
+	spanID, _ := hex.DecodeString("eee19b7ec3c1b174")
+	traceID, _ := hex.DecodeString("5b8efff798038103d269b633813fc60c")
+
+	span := protoBytes(nil, 1, traceID)
+	span = protoBytes(span, 2, spanID)
+	span = protoBytes(span, 5, []byte("inference"))
+	request := protoBytes(nil, 1, protoBytes(nil, 2, protoBytes(nil, 2, span)))
+
+	parsed, err := parseOTLPRequest(request)
+	if err != nil {
+		t.Fatalf("Failed to parse OTLP protobuf: %v", err)
+	}
+	if len(parsed.ResourceSpans) != 1 || len(parsed.ResourceLogs) != 0 {
+		t.Errorf("Expected a span without start time to be read as a span, got %+v", parsed)
+	}
+
+	record := protoVarint(nil, 2, 9)
+	record = protoBytes(record, 5, protoBytes(nil, 1, []byte("done")))
+	parsed, err = parseOTLPRequest(protoBytes(nil, 1, protoBytes(nil, 2, protoBytes(nil, 2, record))))
+	if err != nil {
+		t.Fatalf("Failed to parse OTLP protobuf: %v", err)
+	}
+	if len(parsed.ResourceLogs) != 1 || len(parsed.ResourceSpans) != 0 {
+		t.Errorf("Expected a log record without timestamp to be read as a log, got %+v", parsed)
+	}
+}
+
+func TestParseOTLPProtoSpanWithoutIDs(t *testing.T) {
+	// Trace and span IDs are optional on the wire; a span's start and end
+	// times are fixed64, where a log record's fields 7 and 8 are not.
+	span := protoBytes(nil, 5, []byte("inference"))
+	span = protoFixed64(span, 7, uint64(time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC).UnixNano()))
+	span = protoFixed64(span, 8, uint64(time.Date(2024, 1, 15, 10, 0, 1, 0, time.UTC).UnixNano()))
+	request := protoBytes(nil, 1, protoBytes(nil, 2, protoBytes(nil, 2, span)))
+
+	parsed, err := parseOTLPRequest(request)
+	if err != nil {
+		t.Fatalf("Failed to parse OTLP protobuf: %v", err)
+	}
+	if len(parsed.ResourceSpans) != 1 || len(parsed.ResourceLogs) != 0 {
+		t.Errorf("Expected a span without IDs to be read as a span, got %+v", parsed)
+	}
+}
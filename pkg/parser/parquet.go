@@ -0,0 +1,362 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+	"github.com/xitongsys/parquet-go/common"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/source"
+	parquettypes "github.com/xitongsys/parquet-go/types"
+)
+
+// Parquet is read and written with xitongsys/parquet-go. It is pure Go, so
+// builds need no cgo, and its column reader exposes the row group statistics
+// used for pruning. The library is no longer actively developed, so its use
+// is confined to this file and pkg/output/parquet.go, keeping a move to a
+// maintained library small.
+var parquetMagic = []byte("PAR1")
+
+// parquetFile adapts an io.ReaderAt to the source.ParquetFile interface.
+// Every Open returns an independent cursor over the same data, which the
+// library uses to read column chunks concurrently.
+type parquetFile struct {
+	*io.SectionReader
+	data io.ReaderAt
+	size int64
+}
+
+func newParquetFile(data io.ReaderAt, size int64) *parquetFile {
+	return &parquetFile{SectionReader: io.NewSectionReader(data, 0, size), data: data, size: size}
+}
+
+func (f *parquetFile) Open(string) (source.ParquetFile, error) {
+	return f.newCursor(), nil
+}
+
+func (f *parquetFile) newCursor() source.ParquetFile {
+	return newParquetFile(f.data, f.size)
+}
+
+func (f *parquetFile) Create(string) (source.ParquetFile, error) {
+	return nil, errors.New("parquet input is read-only")
+}
+
+func (f *parquetFile) Write([]byte) (int, error) {
+	return 0, errors.New("parquet input is read-only")
+}
+
+func (f *parquetFile) Close() error {
+	return nil
+}
+
+type parquetColumn struct {
+	name     string
+	inPath   string
+	element  *parquet.SchemaElement
+	repeated bool
+}
+
+// parquetDecoder reads a Parquet file one row group at a time. Every leaf
+// column becomes a record field named by its dotted path, and records are
+// mapped like CSV rows. Row groups whose timestamp column statistics lie
+// entirely outside [since, until] are skipped without being read.
+type parquetDecoder struct {
+	file      *parquetFile
+	reader    *reader.ParquetReader
+	columns   []parquetColumn
+	mapper    *fieldMapper
+	timestamp int
+	since     time.Time
+	until     time.Time
+	rowGroup  int
+	// rowGroupsRead counts the row groups that were not pruned, and
+	// rowsPruned the rows in those that were.
+	rowGroupsRead int
+	rowsPruned    int
+	pending       []map[string]interface{}
+	row           int
+}
+
+func newParquetDecoder(r io.Reader, mapper *fieldMapper, opts ReaderOptions) (*parquetDecoder, error) {
+	file, err := parquetInput(r)
+	if err != nil {
+		return nil, err
+	}
+
+	columnReader, err := reader.NewParquetColumnReader(file, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Parquet footer: %w", err)
+	}
+
+	schema := columnReader.SchemaHandler
+	root := schema.GetRootExName() + common.PAR_GO_PATH_DELIMITER
+	columns := make([]parquetColumn, 0, len(schema.ValueColumns))
+	for _, inPath := range schema.ValueColumns {
+		name := strings.TrimPrefix(schema.InPathToExPath[inPath], root)
+		maxRepetition, err := schema.MaxRepetitionLevel(common.StrToPath(inPath))
+		if err != nil {
+			return nil, fmt.Errorf("invalid Parquet schema: %w", err)
+		}
+		name = strings.ReplaceAll(name, common.PAR_GO_PATH_DELIMITER, ".")
+		if maxRepetition > 0 {
+			name = strings.TrimSuffix(strings.TrimSuffix(name, ".list.element"), ".list.item")
+		}
+		columns = append(columns, parquetColumn{
+			name:     name,
+			inPath:   inPath,
+			element:  schema.SchemaElements[schema.MapIndex[inPath]],
+			repeated: maxRepetition > 0,
+		})
+	}
+
+	mapper.requireTimestamp = true
+	decoder := &parquetDecoder{
+		file:      file,
+		reader:    columnReader,
+		columns:   columns,
+		mapper:    mapper,
+		timestamp: -1,
+		since:     opts.Since,
+		until:     opts.Until,
+	}
+	for i, column := range columns {
+		if column.name == mapper.mapping.Timestamp.Field && !column.repeated {
+			decoder.timestamp = i
+		}
+	}
+
+	return decoder, nil
+}
+
+// parquetInput reads files in place and buffers any other input, as Parquet
+// needs random access to the footer and column chunks.
+func parquetInput(r io.Reader) (*parquetFile, error) {
+	if file, ok := r.(*os.File); ok {
+		if info, err := file.Stat(); err == nil && info.Mode().IsRegular() {
+			return newParquetFile(file, info.Size()), nil
+		}
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Parquet input: %w", err)
+	}
+	if !bytes.HasPrefix(data, parquetMagic) {
+		return nil, fmt.Errorf("not a Parquet file")
+	}
+	return newParquetFile(bytes.NewReader(data), int64(len(data))), nil
+}
+
+func (d *parquetDecoder) decode() (types.SnapEvent, error) {
+	for len(d.pending) == 0 {
+		rowGroups := d.reader.Footer.GetRowGroups()
+		if d.rowGroup >= len(rowGroups) {
+			return types.SnapEvent{}, io.EOF
+		}
+
+		rowGroup := rowGroups[d.rowGroup]
+		d.rowGroup++
+		if !d.overlaps(rowGroup) {
+			d.row += int(rowGroup.GetNumRows())
+			d.rowsPruned += int(rowGroup.GetNumRows())
+			continue
+		}
+
+		records, err := d.readRowGroup(d.rowGroup-1, rowGroup.GetNumRows())
+		if err != nil {
+			return types.SnapEvent{}, err
+		}
+		d.pending = records
+		d.rowGroupsRead++
+	}
+
+	record := d.pending[0]
+	d.pending = d.pending[1:]
+	d.row++
+
+	event, err := d.mapper.mapRecord(record)
+	if err != nil {
+		return types.SnapEvent{}, &RecordError{
+			Unit:     "row",
+			Position: d.row,
+			Reason:   fmt.Sprintf("invalid event in row %d: %v", d.row, err),
+		}
+	}
+
+	return event, nil
+}
+
+func (d *parquetDecoder) position() (string, int) {
+	return "row", d.row
+}
+
+func (d *parquetDecoder) pruned() int {
+	return d.rowsPruned
+}
+
+func (d *parquetDecoder) readRowGroup(index int, numRows int64) ([]map[string]interface{}, error) {
+	records := make([]map[string]interface{}, numRows)
+	for i := range records {
+		records[i] = make(map[string]interface{}, len(d.columns))
+	}
+
+	for _, column := range d.columns {
+		buffer := &reader.ColumnBufferType{
+			PFile:            d.file.newCursor(),
+			Footer:           d.reader.Footer,
+			SchemaHandler:    d.reader.SchemaHandler,
+			PathStr:          column.inPath,
+			RowGroupIndex:    int64(index),
+			DataTableNumRows: -1,
+		}
+		if err := buffer.NextRowGroup(); err != nil {
+			return nil, fmt.Errorf("failed to read Parquet column %s: %w", column.name, err)
+		}
+		table, _ := buffer.ReadRows(numRows)
+
+		row := -1
+		for i, value := range table.Values {
+			if table.RepetitionLevels[i] == 0 {
+				row++
+				if row >= len(records) {
+					break
+				}
+				if column.repeated {
+					records[row][column.name] = []interface{}{}
+				}
+			}
+			if value == nil {
+				if !column.repeated {
+					records[row][column.name] = nil
+				}
+				continue
+			}
+
+			value = parquetValue(value, column.element)
+			if column.repeated {
+				records[row][column.name] = append(records[row][column.name].([]interface{}), value)
+			} else {
+				records[row][column.name] = value
+			}
+		}
+		if row+1 < len(records) {
+			return nil, fmt.Errorf("failed to read Parquet column %s: expected %d rows, got %d", column.name, numRows, row+1)
+		}
+	}
+
+	return records, nil
+}
+
+// overlaps reports whether a row group may hold events within the time
+// range. Only numeric and timestamp-typed columns are pruned, since the
+// statistics of string columns are ordered lexically rather than by time.
+func (d *parquetDecoder) overlaps(rowGroup *parquet.RowGroup) bool {
+	if d.timestamp < 0 || (d.since.IsZero() && d.until.IsZero()) {
+		return true
+	}
+
+	columns := rowGroup.GetColumns()
+	if d.timestamp >= len(columns) || columns[d.timestamp].GetMetaData() == nil {
+		return true
+	}
+	statistics := columns[d.timestamp].GetMetaData().GetStatistics()
+	if statistics == nil {
+		return true
+	}
+
+	minValue, maxValue := statistics.GetMinValue(), statistics.GetMaxValue()
+	if minValue == nil || maxValue == nil {
+		minValue, maxValue = statistics.GetMin(), statistics.GetMax()
+	}
+
+	element := d.columns[d.timestamp].element
+	earliest, ok := d.statisticTime(minValue, element)
+	if !ok {
+		return true
+	}
+	latest, ok := d.statisticTime(maxValue, element)
+	if !ok {
+		return true
+	}
+
+	if !d.since.IsZero() && latest.Before(d.since) {
+		return false
+	}
+	if !d.until.IsZero() && earliest.After(d.until) {
+		return false
+	}
+	return true
+}
+
+func (d *parquetDecoder) statisticTime(raw []byte, element *parquet.SchemaElement) (time.Time, bool) {
+	var value interface{}
+	switch element.GetType() {
+	case parquet.Type_INT32:
+		if len(raw) != 4 {
+			return time.Time{}, false
+		}
+		value = int32(binary.LittleEndian.Uint32(raw))
+	case parquet.Type_INT64:
+		if len(raw) != 8 {
+			return time.Time{}, false
+		}
+		value = int64(binary.LittleEndian.Uint64(raw))
+	default:
+		return time.Time{}, false
+	}
+
+	value = parquetValue(value, element)
+	if timestamp, ok := value.(time.Time); ok {
+		return timestamp, true
+	}
+	timestamp, err := d.mapper.timestamps.parse(value)
+	return timestamp, err == nil
+}
+
+// parquetValue converts a raw column value to the type used for attributes:
+// timestamps and dates become time.Time, and narrow numbers are widened.
+func parquetValue(value interface{}, element *parquet.SchemaElement) interface{} {
+	logical := element.GetLogicalType()
+	switch v := value.(type) {
+	case int32:
+		if element.GetConvertedType() == parquet.ConvertedType_DATE || (logical != nil && logical.IsSetDATE()) {
+			return time.Unix(int64(v)*86400, 0).UTC()
+		}
+		return int64(v)
+	case int64:
+		switch {
+		case element.GetConvertedType() == parquet.ConvertedType_TIMESTAMP_MILLIS:
+			return parquettypes.TIMESTAMP_MILLISToTime(v, true)
+		case element.GetConvertedType() == parquet.ConvertedType_TIMESTAMP_MICROS:
+			return parquettypes.TIMESTAMP_MICROSToTime(v, true)
+		case logical != nil && logical.IsSetTIMESTAMP():
+			unit := logical.GetTIMESTAMP().GetUnit()
+			switch {
+			case unit.IsSetMILLIS():
+				return parquettypes.TIMESTAMP_MILLISToTime(v, true)
+			case unit.IsSetMICROS():
+				return parquettypes.TIMESTAMP_MICROSToTime(v, true)
+			case unit.IsSetNANOS():
+				return parquettypes.TIMESTAMP_NANOSToTime(v, true)
+			}
+		}
+		return v
+	case float32:
+		return float64(v)
+	case string:
+		if element.GetType() == parquet.Type_INT96 {
+			return parquettypes.INT96ToTime(v)
+		}
+		return v
+	}
+	return value
+}
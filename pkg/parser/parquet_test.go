@@ -0,0 +1,148 @@
+package parser
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+type parquetTestRow struct {
+	RequestID string   `parquet:"name=request_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	CreatedAt int64    `parquet:"name=created_at, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	UserID    *string  `parquet:"name=user_id, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	Tokens    int32    `parquet:"name=tokens, type=INT32"`
+	Cost      float32  `parquet:"name=cost, type=FLOAT"`
+	Tags      []string `parquet:"name=tags, type=LIST, valuetype=BYTE_ARRAY, valueconvertedtype=UTF8"`
+}
+
+// writeParquetEvents writes each batch of rows as its own row group.
+func writeParquetEvents(t *testing.T, batches ...[]parquetTestRow) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	pw, err := writer.NewParquetWriterFromWriter(&buf, new(parquetTestRow), 1)
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	for _, batch := range batches {
+		for _, row := range batch {
+			if err := pw.Write(row); err != nil {
+				t.Fatalf("Failed to write row: %v", err)
+			}
+		}
+		if err := pw.Flush(true); err != nil {
+			t.Fatalf("Failed to flush row group: %v", err)
+		}
+	}
+	if err := pw.WriteStop(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func parquetTestBatches() [][]parquetTestRow {
+	base := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	user := "john.doe"
+	return [][]parquetTestRow{
+		{
+			{RequestID: "r1", CreatedAt: base.UnixMilli(), UserID: &user, Tokens: 1200, Cost: 0.5, Tags: []string{"chat", "code"}},
+			{RequestID: "r2", CreatedAt: base.Add(time.Minute).UnixMilli(), Tokens: 10, Tags: []string{}},
+		},
+		{
+			{RequestID: "r3", CreatedAt: base.Add(48 * time.Hour).UnixMilli(), UserID: &user, Tokens: 5},
+		},
+	}
+}
+
+func TestEventReaderParquet(t *testing.T) {
+	data := writeParquetEvents(t, parquetTestBatches()...)
+	mapping := &types.FieldMapping{ID: "request_id", Timestamp: types.TimestampMapping{Field: "created_at"}}
+
+	reader := mustReader(t, string(data), "", ReaderOptions{Mapping: mapping})
+	events, err := ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to read Parquet: %v", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(events))
+	}
+
+	first := events[0]
+	if first.ID != "r1" || !first.Timestamp.Equal(time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected first event: %+v", first)
+	}
+	if first.Attributes["user_id"] != "john.doe" || first.Attributes["tokens"] != int64(1200) || first.Attributes["cost"] != float64(0.5) {
+		t.Errorf("Unexpected attributes: %+v", first.Attributes)
+	}
+	if tags, ok := first.Attributes["tags"].([]interface{}); !ok || len(tags) != 2 || tags[1] != "code" {
+		t.Errorf("Expected tags [chat code], got %#v", first.Attributes["tags"])
+	}
+	if _, exists := events[1].Attributes["user_id"]; exists && events[1].Attributes["user_id"] != nil {
+		t.Errorf("Expected a null user_id, got %v", events[1].Attributes["user_id"])
+	}
+	if events[2].ID != "r3" {
+		t.Errorf("Expected the second row group to follow, got %+v", events[2])
+	}
+}
+
+func TestEventReaderParquetTimeRange(t *testing.T) {
+	path := writeTestFile(t, "events.parquet", writeParquetEvents(t, parquetTestBatches()...))
+
+	mapping := &types.FieldMapping{ID: "request_id", Timestamp: types.TimestampMapping{Field: "created_at"}}
+	base := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		since    time.Time
+		until    time.Time
+		expected []string
+		groups   int
+	}{
+		{"unbounded", time.Time{}, time.Time{}, []string{"r1", "r2", "r3"}, 2},
+		{"since", base.Add(24 * time.Hour), time.Time{}, []string{"r3"}, 1},
+		{"until", time.Time{}, base.Add(30 * time.Second), []string{"r1"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := OpenEventFile(path, ReaderOptions{Mapping: mapping, Since: tt.since, Until: tt.until})
+			if err != nil {
+				t.Fatalf("Failed to open %s: %v", path, err)
+			}
+			defer reader.Close()
+
+			events, err := ReadAll(reader)
+			if err != nil {
+				t.Fatalf("Failed to read events: %v", err)
+			}
+
+			var ids []string
+			for _, event := range events {
+				ids = append(ids, event.ID)
+			}
+			if len(ids) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, ids)
+			}
+			for i := range ids {
+				if ids[i] != tt.expected[i] {
+					t.Errorf("Expected %v, got %v", tt.expected, ids)
+				}
+			}
+
+			decoder := reader.decoder.(*parquetDecoder)
+			if read := decoder.rowGroupsRead; read != tt.groups {
+				t.Errorf("Expected %d row groups to be read, got %d", tt.groups, read)
+			}
+			if decoder.row != 3 {
+				t.Errorf("Expected row positions to count skipped rows, got %d", decoder.row)
+			}
+			if dropped := reader.OutOfRange(); dropped != 3-len(tt.expected) {
+				t.Errorf("Expected %d events counted as out of range, got %d", 3-len(tt.expected), dropped)
+			}
+		})
+	}
+}
@@ -1,9 +1,20 @@
 package parser
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
 )
 
+func readEvents(data, format string) ([]types.SnapEvent, error) {
+	reader, err := NewEventReader(strings.NewReader(data), format, ReaderOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return ReadAll(reader)
+}
+
 func TestParseJSONEvents(t *testing.T) {
 	jsonData := `[
 		{
@@ -30,7 +41,7 @@ func TestParseJSONEvents(t *testing.T) {
 		}
 	]`
 
-	events, err := parseJSONEvents([]byte(jsonData))
+	events, err := readEvents(jsonData, "json")
 	if err != nil {
 		t.Fatalf("Failed to parse JSON events: %v", err)
 	}
@@ -53,7 +64,7 @@ func TestParseJSONLEvents(t *testing.T) {
 {"id": "event2", "timestamp": "2023-01-01T12:05:00Z", "attributes": {"user_id": "jane.smith"}}
 `
 
-	events, err := parseJSONLEvents([]byte(jsonlData))
+	events, err := readEvents(jsonlData, "jsonl")
 	if err != nil {
 		t.Fatalf("Failed to parse JSONL events: %v", err)
 	}
@@ -76,7 +87,7 @@ func TestParseCSVEvents(t *testing.T) {
 event1,2023-01-01T12:00:00Z,john.doe,code_generation
 event2,2023-01-01T12:05:00Z,jane.smith,code_review`
 
-	events, err := parseCSVEvents([]byte(csvData))
+	events, err := readEvents(csvData, "csv")
 	if err != nil {
 		t.Fatalf("Failed to parse CSV events: %v", err)
 	}
@@ -155,8 +166,13 @@ func TestDetermineFormat(t *testing.T) {
 		{"events.JSON", "json"},
 		{"events.JSONL", "jsonl"},
 		{"events.CSV", "csv"},
-		{"events.txt", "json"},
-		{"events", "json"},
+		{"events.ndjson", "jsonl"},
+		{"events.jsonl.gz", "jsonl"},
+		{"events.csv.zst", "csv"},
+		{"events.json.bz2", "json"},
+		{"events.txt", ""},
+		{"events", ""},
+		{"events.gz", ""},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,587 @@
+package parser
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+const DefaultMaxLineSize = 16 * 1024 * 1024
+
+type ReaderOptions struct {
+	MaxLineSize int
+	Format      string
+	Mapping     *types.FieldMapping
+	Schema      *types.EventSchema
+	OnError     string
+	// Since and Until drop events outside the time range when set, counting
+	// them in OutOfRange. Parquet input also skips row groups that lie
+	// entirely outside it.
+	Since time.Time
+	Until time.Time
+}
+
+type EventReader struct {
+	decoder    eventDecoder
+	validator  *SchemaValidator
+	closers    []io.Closer
+	filename   string
+	skipErrors bool
+	since      time.Time
+	until      time.Time
+	errors     []RecordError
+	count      int
+	outOfRange int
+}
+
+type eventDecoder interface {
+	decode() (types.SnapEvent, error)
+	position() (string, int)
+}
+
+// pruningDecoder is implemented by decoders that skip whole blocks of records
+// outside the time range without decoding them.
+type pruningDecoder interface {
+	pruned() int
+}
+
+func NewEventReader(r io.Reader, format string, opts ReaderOptions) (*EventReader, error) {
+	if opts.MaxLineSize <= 0 {
+		opts.MaxLineSize = DefaultMaxLineSize
+	}
+
+	if format == "" && opts.Mapping != nil && opts.Mapping.Pattern != "" {
+		format = "regex"
+	}
+
+	if format == "" {
+		buffered := bufio.NewReader(r)
+		sniffed, err := sniffFormat(buffered)
+		if err != nil {
+			return nil, err
+		}
+		format = sniffed
+		r = buffered
+	}
+
+	format = strings.ToLower(format)
+
+	// Formats read without a field mapping still honour the timestamp
+	// section of one, such as its timezone.
+	timestamps := defaultTimestampParser
+	if opts.Mapping != nil {
+		var err error
+		timestamps, err = newTimestampParser(opts.Mapping.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var mapper *fieldMapper
+	if opts.Mapping != nil || format == "csv" || format == "logfmt" || format == "regex" || format == "parquet" {
+		var err error
+		mapper, err = newFieldMapper(opts.Mapping)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var decoder eventDecoder
+	switch format {
+	case "json":
+		decoder = newJSONDecoder(r, mapper)
+	case "jsonl":
+		decoder = newJSONLDecoder(r, opts.MaxLineSize, mapper)
+	case "csv":
+		decoder = newCSVDecoder(r, mapper)
+	case "logfmt":
+		decoder = newLogfmtDecoder(r, opts.MaxLineSize, mapper)
+	case "regex":
+		regexDecoder, err := newRegexDecoder(r, opts.MaxLineSize, mapper)
+		if err != nil {
+			return nil, err
+		}
+		decoder = regexDecoder
+	case "cloudevents":
+		decoder = newCloudEventsDecoder(r, timestamps)
+	case "otlp":
+		decoder = newOTLPJSONDecoder(r)
+	case "otlp-proto":
+		decoder = newOTLPProtoDecoder(r)
+	case "parquet":
+		parquetDecoder, err := newParquetDecoder(r, mapper, opts)
+		if err != nil {
+			return nil, err
+		}
+		decoder = parquetDecoder
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+
+	reader, err := newEventReader(decoder, opts)
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := r.(io.Closer); ok {
+		reader.closers = append(reader.closers, closer)
+	}
+
+	return reader, nil
+}
+
+func newEventReader(decoder eventDecoder, opts ReaderOptions) (*EventReader, error) {
+	reader := &EventReader{
+		decoder:    decoder,
+		skipErrors: opts.OnError == OnErrorSkip || opts.OnError == OnErrorQuarantine,
+		since:      opts.Since,
+		until:      opts.Until,
+	}
+	if opts.Schema != nil {
+		validator, err := NewSchemaValidator(opts.Schema)
+		if err != nil {
+			return nil, err
+		}
+		reader.validator = validator
+	}
+	return reader, nil
+}
+
+// OpenEventFile opens an events file for streaming. A filename of "-" reads
+// from stdin, and a sqlite:// URI reads the rows of a query. Gzip, zstd and
+// bzip2 input is decompressed transparently, and the format is taken from
+// opts.Format, the file extension (ignoring any compression suffix) or the
+// content, in that order. Uncompressed Parquet files are read in place.
+func OpenEventFile(filename string, opts ReaderOptions) (*EventReader, error) {
+	if strings.HasPrefix(filename, SQLiteScheme) {
+		return openSQLiteEvents(filename, opts)
+	}
+
+	var file io.ReadCloser = os.Stdin
+	if filename != "-" {
+		opened, err := os.Open(filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		file = opened
+	}
+
+	format := opts.Format
+	if format == "" && filename != "-" {
+		format = determineFormat(filename)
+	}
+
+	var input io.Reader = file
+	var closers []io.Closer
+	if format != "parquet" || compressionExtensions[strings.ToLower(filepath.Ext(filename))] != "" {
+		var err error
+		input, closers, err = decompress(file, filename)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	closers = append(closers, file)
+
+	if opts.Format == "" && filename != "-" {
+		if format == "json" || format == "jsonl" {
+			buffered := bufio.NewReader(input)
+			format = sniffJSONFormat(buffered, format)
+			input = buffered
+		}
+	}
+
+	reader, err := NewEventReader(input, format, opts)
+	if err != nil {
+		closeAll(closers)
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	reader.closers = closers
+	reader.filename = filename
+
+	return reader, nil
+}
+
+// Read returns the next event, or io.EOF once the input is exhausted. Unless
+// OnError is skip or quarantine, a malformed record is returned as a
+// *RecordError; otherwise it is recorded and reading continues.
+func (r *EventReader) Read() (types.SnapEvent, error) {
+	for {
+		event, err := r.decoder.decode()
+		if err == nil && r.validator != nil {
+			err = r.validationError(event)
+		}
+		var recordErr *RecordError
+		if errors.As(err, &recordErr) {
+			recordErr.File = r.filename
+			if r.skipErrors {
+				r.errors = append(r.errors, *recordErr)
+				continue
+			}
+		}
+		if err != nil {
+			return types.SnapEvent{}, err
+		}
+		if !r.inRange(event.Timestamp) {
+			r.outOfRange++
+			continue
+		}
+		r.count++
+		return event, nil
+	}
+}
+
+func (r *EventReader) inRange(timestamp time.Time) bool {
+	if timestamp.IsZero() {
+		return true
+	}
+	return (r.since.IsZero() || !timestamp.Before(r.since)) && (r.until.IsZero() || !timestamp.After(r.until))
+}
+
+func (r *EventReader) validationError(event types.SnapEvent) error {
+	violation := r.validator.Validate(event)
+	if violation == nil {
+		return nil
+	}
+
+	unit, position := r.decoder.position()
+	raw, _ := json.Marshal(event)
+	return &RecordError{
+		Unit:     unit,
+		Position: position,
+		Reason:   fmt.Sprintf("invalid event on %s %d: %v", unit, position, violation),
+		Raw:      string(raw),
+	}
+}
+
+func (r *EventReader) Count() int {
+	return r.count
+}
+
+// OutOfRange returns the number of events dropped so far for lying outside
+// Since and Until, including the rows of skipped Parquet row groups.
+func (r *EventReader) OutOfRange() int {
+	if decoder, ok := r.decoder.(pruningDecoder); ok {
+		return r.outOfRange + decoder.pruned()
+	}
+	return r.outOfRange
+}
+
+func (r *EventReader) Errors() []RecordError {
+	return r.errors
+}
+
+func (r *EventReader) Close() error {
+	return closeAll(r.closers)
+}
+
+func closeAll(closers []io.Closer) error {
+	var firstErr error
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func ReadAll(reader *EventReader) ([]types.SnapEvent, error) {
+	var events []types.SnapEvent
+	for {
+		event, err := reader.Read()
+		if err == io.EOF {
+			return events, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+}
+
+// nativeEvent is the SnapEvent layout with the timestamp left raw, so that
+// epoch numbers and the extra layouts go through the same timestamp parsing
+// as mapped and CSV input.
+type nativeEvent struct {
+	ID         string                 `json:"id"`
+	Timestamp  json.RawMessage        `json:"timestamp"`
+	Attributes map[string]interface{} `json:"attributes"`
+	Metadata   map[string]interface{} `json:"metadata"`
+}
+
+func (n nativeEvent) toEvent() (types.SnapEvent, error) {
+	event := types.SnapEvent{
+		ID:         n.ID,
+		Attributes: n.Attributes,
+		Metadata:   n.Metadata,
+	}
+
+	raw := strings.TrimSpace(string(n.Timestamp))
+	if raw == "" || raw == "null" {
+		return event, nil
+	}
+
+	var value interface{} = json.Number(raw)
+	if strings.HasPrefix(raw, `"`) {
+		var text string
+		if err := json.Unmarshal(n.Timestamp, &text); err != nil {
+			return types.SnapEvent{}, err
+		}
+		value = text
+	}
+
+	timestamp, err := defaultTimestampParser.parse(value)
+	if err != nil {
+		return types.SnapEvent{}, err
+	}
+	event.Timestamp = timestamp
+
+	return event, nil
+}
+
+type jsonDecoder struct {
+	decoder *json.Decoder
+	mapper  *fieldMapper
+	started bool
+	done    bool
+	index   int
+}
+
+func newJSONDecoder(r io.Reader, mapper *fieldMapper) *jsonDecoder {
+	return &jsonDecoder{decoder: json.NewDecoder(r), mapper: mapper}
+}
+
+func (d *jsonDecoder) decode() (types.SnapEvent, error) {
+	if d.done {
+		return types.SnapEvent{}, io.EOF
+	}
+
+	if !d.started {
+		d.started = true
+		token, err := d.decoder.Token()
+		if err == io.EOF {
+			d.done = true
+			return types.SnapEvent{}, io.EOF
+		}
+		if err != nil {
+			return types.SnapEvent{}, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		if token == nil {
+			d.done = true
+			return types.SnapEvent{}, io.EOF
+		}
+		if delim, ok := token.(json.Delim); !ok || delim != '[' {
+			return types.SnapEvent{}, fmt.Errorf("failed to parse JSON: expected an array of events")
+		}
+	}
+
+	if !d.decoder.More() {
+		d.done = true
+		if _, err := d.decoder.Token(); err != nil {
+			return types.SnapEvent{}, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		return types.SnapEvent{}, io.EOF
+	}
+
+	d.index++
+	var raw json.RawMessage
+	if err := d.decoder.Decode(&raw); err != nil {
+		return types.SnapEvent{}, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	event, err := decodeEvent(raw, d.mapper)
+	if err != nil {
+		return types.SnapEvent{}, &RecordError{
+			Unit:     "event",
+			Position: d.index,
+			Reason:   fmt.Sprintf("invalid event %d: %v", d.index, err),
+			Raw:      string(raw),
+		}
+	}
+
+	return event, nil
+}
+
+func (d *jsonDecoder) position() (string, int) {
+	return "event", d.index
+}
+
+func decodeEvent(data []byte, mapper *fieldMapper) (types.SnapEvent, error) {
+	if mapper != nil {
+		var record map[string]interface{}
+		if err := json.Unmarshal(data, &record); err != nil {
+			return types.SnapEvent{}, err
+		}
+		return mapper.mapRecord(record)
+	}
+
+	var native nativeEvent
+	if err := json.Unmarshal(data, &native); err != nil {
+		return types.SnapEvent{}, err
+	}
+	return native.toEvent()
+}
+
+type jsonlDecoder struct {
+	lines  *lineReader
+	mapper *fieldMapper
+	line   int
+}
+
+func newJSONLDecoder(r io.Reader, maxLineSize int, mapper *fieldMapper) *jsonlDecoder {
+	return &jsonlDecoder{lines: newLineReader(r, maxLineSize), mapper: mapper}
+}
+
+func (d *jsonlDecoder) decode() (types.SnapEvent, error) {
+	for {
+		line, err := d.lines.next()
+		if err == io.EOF {
+			return types.SnapEvent{}, io.EOF
+		}
+		d.line++
+		if err == errLineTooLong {
+			return types.SnapEvent{}, &RecordError{
+				Unit:     "line",
+				Position: d.line,
+				Reason:   fmt.Sprintf("JSONL line %d exceeds the maximum line size of %d bytes", d.line, d.lines.maxSize),
+			}
+		}
+		if err != nil {
+			return types.SnapEvent{}, fmt.Errorf("error reading JSONL: %w", err)
+		}
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+
+		event, err := decodeEvent(line, d.mapper)
+		if err != nil {
+			return types.SnapEvent{}, &RecordError{
+				Unit:     "line",
+				Position: d.line,
+				Reason:   fmt.Sprintf("failed to parse JSONL line %d: %v", d.line, err),
+				Raw:      string(line),
+			}
+		}
+		return event, nil
+	}
+}
+
+func (d *jsonlDecoder) position() (string, int) {
+	return "line", d.line
+}
+
+type csvDecoder struct {
+	reader  *csv.Reader
+	input   *recordingReader
+	mapper  *fieldMapper
+	headers []string
+	row     int
+}
+
+func newCSVDecoder(r io.Reader, mapper *fieldMapper) *csvDecoder {
+	input := &recordingReader{reader: r}
+	reader := csv.NewReader(input)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	return &csvDecoder{reader: reader, input: input, mapper: mapper}
+}
+
+// recordingReader keeps what has been read from reader since the last
+// release, so that the text of a CSV row that fails to parse can be
+// quarantined as it was.
+type recordingReader struct {
+	reader io.Reader
+	data   []byte
+	offset int64
+}
+
+func (r *recordingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.data = append(r.data, p[:n]...)
+	return n, err
+}
+
+// release returns the input from the last release up to offset and drops it.
+func (r *recordingReader) release(offset int64) string {
+	end := int(offset - r.offset)
+	if end < 0 || end > len(r.data) {
+		end = len(r.data)
+	}
+	text := string(r.data[:end])
+	r.data = r.data[end:]
+	r.offset += int64(end)
+	return text
+}
+
+func (d *csvDecoder) decode() (types.SnapEvent, error) {
+	if d.headers == nil {
+		headers, err := d.reader.Read()
+		if err == io.EOF {
+			return types.SnapEvent{}, fmt.Errorf("CSV file is empty")
+		}
+		if err != nil {
+			return types.SnapEvent{}, fmt.Errorf("failed to parse CSV: %w", err)
+		}
+		d.headers = append([]string(nil), headers...)
+		d.input.release(d.reader.InputOffset())
+		d.row = 1
+	}
+
+	record, err := d.reader.Read()
+	if err == io.EOF {
+		return types.SnapEvent{}, io.EOF
+	}
+	d.row++
+	raw := strings.TrimRight(d.input.release(d.reader.InputOffset()), "\r\n")
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return types.SnapEvent{}, &RecordError{
+			Unit:     "row",
+			Position: d.row,
+			Reason:   fmt.Sprintf("failed to parse CSV row %d: %v", d.row, parseErr.Err),
+			Raw:      raw,
+		}
+	}
+	if err != nil {
+		return types.SnapEvent{}, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+
+	if len(record) != len(d.headers) {
+		return types.SnapEvent{}, &RecordError{
+			Unit:     "row",
+			Position: d.row,
+			Reason:   fmt.Sprintf("CSV row %d has %d columns, expected %d", d.row, len(record), len(d.headers)),
+			Raw:      csvLine(record),
+		}
+	}
+
+	fields := make(map[string]string, len(record))
+	for j, value := range record {
+		fields[d.headers[j]] = value
+	}
+
+	event, err := d.mapper.mapRecord(d.mapper.typedFields(fields))
+	if err != nil {
+		return types.SnapEvent{}, &RecordError{
+			Unit:     "row",
+			Position: d.row,
+			Reason:   fmt.Sprintf("invalid event in row %d: %v", d.row, err),
+			Raw:      csvLine(record),
+		}
+	}
+
+	return event, nil
+}
+
+func (d *csvDecoder) position() (string, int) {
+	return "row", d.row
+}
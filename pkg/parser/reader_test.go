@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestEventReaderJSONArray(t *testing.T) {
+	data := `[
+		{"id": "event1", "timestamp": "2023-01-01T12:00:00Z", "attributes": {"user_id": "john.doe"}},
+		{"id": "event2", "timestamp": "2023-01-01T12:05:00Z", "attributes": {"user_id": "jane.smith"}}
+	]`
+
+	reader, err := NewEventReader(strings.NewReader(data), "json", ReaderOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+
+	first, err := reader.Read()
+	if err != nil {
+		t.Fatalf("Failed to read first event: %v", err)
+	}
+	if first.ID != "event1" {
+		t.Errorf("Expected first event ID to be 'event1', got %s", first.ID)
+	}
+
+	if _, err := reader.Read(); err != nil {
+		t.Fatalf("Failed to read second event: %v", err)
+	}
+
+	if _, err := reader.Read(); err != io.EOF {
+		t.Errorf("Expected io.EOF after last event, got %v", err)
+	}
+
+	if reader.Count() != 2 {
+		t.Errorf("Expected count to be 2, got %d", reader.Count())
+	}
+}
+
+func TestEventReaderJSONRejectsObject(t *testing.T) {
+	reader, err := NewEventReader(strings.NewReader(`{"id": "event1"}`), "json", ReaderOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+
+	if _, err := reader.Read(); err == nil || err == io.EOF {
+		t.Errorf("Expected error for non-array JSON, got %v", err)
+	}
+}
+
+func TestEventReaderJSONLMaxLineSize(t *testing.T) {
+	long := `{"id": "event2", "timestamp": "2023-01-01T12:05:00Z", "attributes": {"blob": "` + strings.Repeat("x", 200*1024) + `"}}`
+	data := `{"id": "event1", "timestamp": "2023-01-01T12:00:00Z"}` + "\n" + long + "\n"
+
+	events, err := ReadAll(mustReader(t, data, "jsonl", ReaderOptions{}))
+	if err != nil {
+		t.Fatalf("Expected lines over 64KB to parse with the default limit, got %v", err)
+	}
+	if len(events) != 2 {
+		t.Errorf("Expected 2 events, got %d", len(events))
+	}
+
+	_, err = ReadAll(mustReader(t, data, "jsonl", ReaderOptions{MaxLineSize: 1024}))
+	if err == nil || !strings.Contains(err.Error(), "line 2 exceeds") {
+		t.Errorf("Expected line size error on line 2, got %v", err)
+	}
+}
+
+func TestEventReaderCSVRowErrors(t *testing.T) {
+	data := "id,timestamp,user_id\nevent1,2023-01-01T12:00:00Z,john.doe\nevent2,2023-01-01T12:05:00Z\n"
+
+	reader := mustReader(t, data, "csv", ReaderOptions{})
+
+	if _, err := reader.Read(); err != nil {
+		t.Fatalf("Failed to read first row: %v", err)
+	}
+
+	_, err := reader.Read()
+	if err == nil || !strings.Contains(err.Error(), "CSV row 3 has 2 columns") {
+		t.Errorf("Expected column count error for row 3, got %v", err)
+	}
+}
+
+func mustReader(t *testing.T, data, format string, opts ReaderOptions) *EventReader {
+	t.Helper()
+	reader, err := NewEventReader(strings.NewReader(data), format, opts)
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+	return reader
+}
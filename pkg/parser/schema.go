@@ -0,0 +1,225 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+var schemaTypes = map[string]bool{
+	"":        true,
+	"any":     true,
+	"string":  true,
+	"number":  true,
+	"integer": true,
+	"boolean": true,
+	"object":  true,
+	"array":   true,
+}
+
+// SchemaValidator checks mapped events against the event schema declared in
+// a config.
+type SchemaValidator struct {
+	schema   types.EventSchema
+	patterns map[string]*regexp.Regexp
+}
+
+func NewSchemaValidator(schema *types.EventSchema) (*SchemaValidator, error) {
+	validator := &SchemaValidator{
+		schema:   *schema,
+		patterns: make(map[string]*regexp.Regexp),
+	}
+
+	sections := map[string]map[string]types.FieldSchema{
+		"attributes": schema.Attributes,
+		"metadata":   schema.Metadata,
+	}
+	for section, fields := range sections {
+		for name, field := range fields {
+			path := section + "." + name
+			if !schemaTypes[field.Type] {
+				return nil, fmt.Errorf("invalid schema for %s: unknown type %s", path, field.Type)
+			}
+			if field.Pattern != "" {
+				pattern, err := regexp.Compile(field.Pattern)
+				if err != nil {
+					return nil, fmt.Errorf("invalid schema for %s: %w", path, err)
+				}
+				validator.patterns[path] = pattern
+			}
+		}
+	}
+
+	return validator, nil
+}
+
+// Validate returns an error listing every way the event violates the schema,
+// or nil when it conforms.
+func (v *SchemaValidator) Validate(event types.SnapEvent) error {
+	var violations []string
+
+	if v.schema.RequireID && event.ID == "" {
+		violations = append(violations, "id is required")
+	}
+	if event.Timestamp.IsZero() {
+		violations = append(violations, "timestamp is required")
+	}
+
+	violations = append(violations, v.validateFields("attributes", v.schema.Attributes, event.Attributes)...)
+	violations = append(violations, v.validateFields("metadata", v.schema.Metadata, event.Metadata)...)
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return fmt.Errorf("schema violation: %s", strings.Join(violations, "; "))
+}
+
+func (v *SchemaValidator) validateFields(section string, fields map[string]types.FieldSchema, values map[string]interface{}) []string {
+	var violations []string
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		field := fields[name]
+		path := section + "." + name
+
+		value, exists := values[name]
+		if !exists || value == nil {
+			if field.Required {
+				violations = append(violations, path+" is required")
+			}
+			continue
+		}
+
+		violations = append(violations, v.validateValue(path, field, value)...)
+	}
+
+	if v.schema.Strict && section == "attributes" {
+		var undeclared []string
+		for name := range values {
+			if _, declared := fields[name]; !declared {
+				undeclared = append(undeclared, name)
+			}
+		}
+		sort.Strings(undeclared)
+		for _, name := range undeclared {
+			violations = append(violations, fmt.Sprintf("%s.%s is not declared in the schema", section, name))
+		}
+	}
+
+	return violations
+}
+
+func (v *SchemaValidator) validateValue(path string, field types.FieldSchema, value interface{}) []string {
+	if !matchesType(field.Type, value) {
+		return []string{fmt.Sprintf("%s must be %s, got %s", path, withArticle(field.Type), typeName(value))}
+	}
+
+	var violations []string
+
+	if len(field.Enum) > 0 {
+		allowed := false
+		for _, option := range field.Enum {
+			if fmt.Sprintf("%v", option) == fmt.Sprintf("%v", value) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			violations = append(violations, fmt.Sprintf("%s has value %v, which is not one of %v", path, value, field.Enum))
+		}
+	}
+
+	if pattern, exists := v.patterns[path]; exists && !pattern.MatchString(fmt.Sprintf("%v", value)) {
+		violations = append(violations, fmt.Sprintf("%s does not match %s", path, field.Pattern))
+	}
+
+	if number, isNumber := toFloat(value); isNumber {
+		if field.Min != nil && number < *field.Min {
+			violations = append(violations, fmt.Sprintf("%s must be at least %v", path, *field.Min))
+		}
+		if field.Max != nil && number > *field.Max {
+			violations = append(violations, fmt.Sprintf("%s must be at most %v", path, *field.Max))
+		}
+	}
+
+	return violations
+}
+
+func matchesType(schemaType string, value interface{}) bool {
+	switch schemaType {
+	case "", "any":
+		return true
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := toFloat(value)
+		return ok
+	case "integer":
+		number, ok := toFloat(value)
+		return ok && number == math.Trunc(number)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	default:
+		return false
+	}
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		number, err := v.Float64()
+		return number, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	if _, isNumber := toFloat(value); isNumber {
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func withArticle(schemaType string) string {
+	switch schemaType {
+	case "integer", "array", "object":
+		return "an " + schemaType
+	default:
+		return "a " + schemaType
+	}
+}
@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+func TestSchemaValidator(t *testing.T) {
+	minTokens := 0.0
+	schema := &types.EventSchema{
+		RequireID: true,
+		Strict:    true,
+		Attributes: map[string]types.FieldSchema{
+			"user_id": {Type: "string", Required: true, Pattern: "@"},
+			"tokens":  {Type: "integer", Min: &minTokens},
+			"model":   {Type: "string", Enum: []interface{}{"claude-sonnet-4", "claude-opus-4"}},
+		},
+	}
+
+	validator, err := NewSchemaValidator(schema)
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	valid := types.SnapEvent{
+		ID:        "event1",
+		Timestamp: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		Attributes: map[string]interface{}{
+			"user_id": "john.doe@company.com",
+			"tokens":  float64(120),
+			"model":   "claude-sonnet-4",
+		},
+	}
+	if err := validator.Validate(valid); err != nil {
+		t.Errorf("Expected event to be valid, got %v", err)
+	}
+
+	invalid := types.SnapEvent{
+		Timestamp: valid.Timestamp,
+		Attributes: map[string]interface{}{
+			"tokens":  -1,
+			"model":   "other",
+			"unknown": true,
+		},
+	}
+	err = validator.Validate(invalid)
+	if err == nil {
+		t.Fatal("Expected schema violations")
+	}
+
+	for _, expected := range []string{
+		"id is required",
+		"attributes.user_id is required",
+		"attributes.tokens must be at least 0",
+		"attributes.model has value other",
+		"attributes.unknown is not declared",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected violation %q in %v", expected, err)
+		}
+	}
+}
+
+func TestSchemaValidatorRejectsInvalidSchema(t *testing.T) {
+	_, err := NewSchemaValidator(&types.EventSchema{
+		Attributes: map[string]types.FieldSchema{"user_id": {Type: "text"}},
+	})
+	if err == nil {
+		t.Error("Expected unknown type to be rejected")
+	}
+}
+
+func TestEventReaderValidatesSchema(t *testing.T) {
+	data := "{\"id\": \"event1\", \"timestamp\": \"2023-01-01T12:00:00Z\", \"attributes\": {\"user_id\": \"john\"}}\n{\"id\": \"event2\", \"timestamp\": \"2023-01-01T12:05:00Z\", \"attributes\": {\"user_id\": 42}}\n"
+
+	schema := &types.EventSchema{
+		Attributes: map[string]types.FieldSchema{"user_id": {Type: "string", Required: true}},
+	}
+
+	reader := mustReader(t, data, "jsonl", ReaderOptions{Schema: schema, OnError: OnErrorSkip})
+	events, err := ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(events) != 1 || events[0].ID != "event1" {
+		t.Errorf("Expected only event1 to pass validation, got %v", events)
+	}
+
+	recordErrors := reader.Errors()
+	if len(recordErrors) != 1 || recordErrors[0].Position != 2 || !strings.Contains(recordErrors[0].Reason, "must be a string") {
+		t.Errorf("Expected a schema violation on line 2, got %+v", recordErrors)
+	}
+}
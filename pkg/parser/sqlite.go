@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+	_ "modernc.org/sqlite"
+)
+
+const SQLiteScheme = "sqlite://"
+
+// parseSQLiteURI splits sqlite://path?table=events or
+// sqlite://path?query=SELECT ... into the database path and the query to run.
+// query must be the last parameter so that the SQL may contain "&"; it is
+// unescaped when it contains %-escapes.
+func parseSQLiteURI(uri string) (string, string, error) {
+	rest := strings.TrimPrefix(uri, SQLiteScheme)
+	path, params, _ := strings.Cut(rest, "?")
+	if path == "" {
+		return "", "", fmt.Errorf("invalid SQLite source %s: missing database path", uri)
+	}
+
+	for params != "" {
+		if query, found := strings.CutPrefix(params, "query="); found {
+			if strings.Contains(query, "%") {
+				unescaped, err := url.PathUnescape(query)
+				if err != nil {
+					return "", "", fmt.Errorf("invalid SQLite query: %w", err)
+				}
+				query = unescaped
+			}
+			return path, query, nil
+		}
+
+		var param string
+		param, params, _ = strings.Cut(params, "&")
+		if table, found := strings.CutPrefix(param, "table="); found {
+			return path, "SELECT * FROM " + quoteIdentifier(table), nil
+		}
+	}
+
+	return "", "", fmt.Errorf("invalid SQLite source %s: expected a query or table parameter", uri)
+}
+
+// quoteIdentifier quotes name as an SQL identifier, doubling any embedded
+// double quotes.
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+type sqliteDecoder struct {
+	rows    *sql.Rows
+	columns []string
+	mapper  *fieldMapper
+	row     int
+}
+
+func openSQLiteEvents(uri string, opts ReaderOptions) (*EventReader, error) {
+	path, query, err := parseSQLiteURI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	mapper, err := newFieldMapper(opts.Mapping)
+	if err != nil {
+		return nil, err
+	}
+	mapper.requireTimestamp = true
+
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	rows, err := db.Query(query)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to query %s: %w", path, err)
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		db.Close()
+		return nil, fmt.Errorf("failed to query %s: %w", path, err)
+	}
+
+	reader, err := newEventReader(&sqliteDecoder{rows: rows, columns: columns, mapper: mapper}, opts)
+	if err != nil {
+		rows.Close()
+		db.Close()
+		return nil, err
+	}
+	reader.closers = []io.Closer{rows, db}
+	reader.filename = uri
+
+	return reader, nil
+}
+
+func (d *sqliteDecoder) decode() (types.SnapEvent, error) {
+	if !d.rows.Next() {
+		if err := d.rows.Err(); err != nil {
+			return types.SnapEvent{}, fmt.Errorf("failed to read SQLite rows: %w", err)
+		}
+		return types.SnapEvent{}, io.EOF
+	}
+	d.row++
+
+	values := make([]interface{}, len(d.columns))
+	pointers := make([]interface{}, len(d.columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	if err := d.rows.Scan(pointers...); err != nil {
+		return types.SnapEvent{}, fmt.Errorf("failed to read SQLite row %d: %w", d.row, err)
+	}
+
+	record := make(map[string]interface{}, len(d.columns))
+	for i, column := range d.columns {
+		if text, isBytes := values[i].([]byte); isBytes {
+			record[column] = string(text)
+		} else {
+			record[column] = values[i]
+		}
+	}
+
+	event, err := d.mapper.mapRecord(record)
+	if err != nil {
+		return types.SnapEvent{}, &RecordError{
+			Unit:     "row",
+			Position: d.row,
+			Reason:   fmt.Sprintf("invalid event in row %d: %v", d.row, err),
+		}
+	}
+
+	return event, nil
+}
+
+func (d *sqliteDecoder) position() (string, int) {
+	return "row", d.row
+}
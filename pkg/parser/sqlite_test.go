@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+func TestParseSQLiteURI(t *testing.T) {
+	tests := []struct {
+		uri   string
+		path  string
+		query string
+	}{
+		{"sqlite://events.db?table=events", "events.db", `SELECT * FROM "events"`},
+		{`sqlite://events.db?table=my "events"\`, "events.db", `SELECT * FROM "my ""events""\"`},
+		{"sqlite:///tmp/events.db?query=SELECT id, ts FROM logs WHERE a=1&b=2", "/tmp/events.db", "SELECT id, ts FROM logs WHERE a=1&b=2"},
+		{"sqlite://events.db?mode=x&query=SELECT%20*%20FROM%20logs", "events.db", "SELECT * FROM logs"},
+	}
+
+	for _, tt := range tests {
+		path, query, err := parseSQLiteURI(tt.uri)
+		if err != nil {
+			t.Errorf("parseSQLiteURI(%q) failed: %v", tt.uri, err)
+			continue
+		}
+		if path != tt.path || query != tt.query {
+			t.Errorf("parseSQLiteURI(%q) = %q, %q; expected %q, %q", tt.uri, path, query, tt.path, tt.query)
+		}
+	}
+
+	for _, uri := range []string{"sqlite://events.db", "sqlite://?table=events"} {
+		if _, _, err := parseSQLiteURI(uri); err == nil {
+			t.Errorf("Expected %q to be rejected", uri)
+		}
+	}
+}
+
+func TestOpenEventFileSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	for _, statement := range []string{
+		`CREATE TABLE requests (request_id TEXT, created_at INTEGER, user_id TEXT, tokens INTEGER, cost REAL)`,
+		`INSERT INTO requests VALUES ('r1', 1705314600, 'john.doe', 1200, 0.5)`,
+		`INSERT INTO requests VALUES ('r2', 1705314660, 'jane.smith', NULL, 0.25)`,
+		`INSERT INTO requests VALUES ('r3', NULL, 'john.doe', 10, 0)`,
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("Failed to prepare database: %v", err)
+		}
+	}
+	db.Close()
+
+	mapping := &types.FieldMapping{ID: "request_id", Timestamp: types.TimestampMapping{Field: "created_at"}}
+	uri := "sqlite://" + path + "?query=SELECT * FROM requests ORDER BY request_id"
+
+	reader, err := OpenEventFile(uri, ReaderOptions{Mapping: mapping, OnError: OnErrorSkip})
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", uri, err)
+	}
+	defer reader.Close()
+
+	events, err := ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to read events: %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	if events[0].ID != "r1" || events[0].Attributes["user_id"] != "john.doe" || events[0].Attributes["tokens"] != int64(1200) {
+		t.Errorf("Unexpected first event: %+v", events[0])
+	}
+	if !events[0].Timestamp.Equal(time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected timestamp: %v", events[0].Timestamp)
+	}
+
+	recordErrors := reader.Errors()
+	if len(recordErrors) != 1 || recordErrors[0].Unit != "row" || recordErrors[0].Position != 3 || recordErrors[0].File != uri {
+		t.Errorf("Expected the third row to be rejected, got %+v", recordErrors)
+	}
+}
@@ -0,0 +1,230 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+var defaultLayouts = []string{
+	time.RFC3339,
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"20060102150405",
+	"20060102",
+}
+
+// minInferredEpoch is the smallest value read as an epoch when no unit is
+// configured, 1973-03-03 in seconds. Smaller numbers are more likely compact
+// dates or counters than timestamps.
+const minInferredEpoch = 1e8
+
+var epochUnits = map[string]time.Duration{
+	"unix":    time.Second,
+	"unix_s":  time.Second,
+	"unix_ms": time.Millisecond,
+	"unix_us": time.Microsecond,
+	"unix_ns": time.Nanosecond,
+}
+
+var strftimeDirectives = map[byte]string{
+	'Y': "2006",
+	'y': "06",
+	'm': "01",
+	'd': "02",
+	'e': "_2",
+	'j': "002",
+	'H': "15",
+	'I': "03",
+	'M': "04",
+	'S': "05",
+	'f': "000000",
+	'p': "PM",
+	'b': "Jan",
+	'h': "Jan",
+	'B': "January",
+	'a': "Mon",
+	'A': "Monday",
+	'z': "-0700",
+	'Z': "MST",
+	'F': "2006-01-02",
+	'T': "15:04:05",
+	'%': "%",
+}
+
+type timestampParser struct {
+	layouts  []string
+	location *time.Location
+	unit     time.Duration
+}
+
+var defaultTimestampParser = &timestampParser{layouts: defaultLayouts, location: time.UTC}
+
+func newTimestampParser(spec types.TimestampMapping) (*timestampParser, error) {
+	parser := &timestampParser{location: time.UTC}
+
+	if spec.Timezone != "" {
+		location, err := time.LoadLocation(spec.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %s: %w", spec.Timezone, err)
+		}
+		parser.location = location
+	}
+
+	formats := spec.Formats
+	if spec.Format != "" {
+		formats = append([]string{spec.Format}, formats...)
+	}
+
+	for _, format := range formats {
+		if unit, isEpoch := epochUnits[strings.ToLower(format)]; isEpoch {
+			parser.unit = unit
+			continue
+		}
+		if format == "%s" {
+			parser.unit = time.Second
+			continue
+		}
+
+		layout := format
+		if strings.Contains(format, "%") {
+			converted, err := convertStrftime(format)
+			if err != nil {
+				return nil, err
+			}
+			layout = converted
+		}
+		parser.layouts = append(parser.layouts, layout)
+	}
+
+	if len(parser.layouts) == 0 {
+		parser.layouts = defaultLayouts
+	}
+
+	return parser, nil
+}
+
+func (p *timestampParser) parse(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		return p.parseString(v)
+	case json.Number:
+		return p.parseString(v.String())
+	case float64:
+		return p.fromEpoch(v)
+	case int:
+		return p.fromEpochInt(int64(v))
+	case int64:
+		return p.fromEpochInt(v)
+	default:
+		return time.Time{}, fmt.Errorf("unable to parse timestamp: %v", value)
+	}
+}
+
+func (p *timestampParser) parseString(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	for _, layout := range p.layouts {
+		if timestamp, err := time.ParseInLocation(layout, value, p.location); err == nil {
+			return timestamp, nil
+		}
+	}
+
+	if epoch, err := strconv.ParseFloat(value, 64); err == nil && !math.IsInf(epoch, 0) && !math.IsNaN(epoch) {
+		if integer, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return p.fromEpochInt(integer)
+		}
+		return p.fromEpoch(epoch)
+	}
+
+	return time.Time{}, fmt.Errorf("unable to parse timestamp: %s", value)
+}
+
+func (p *timestampParser) fromEpoch(value float64) (time.Time, error) {
+	if value == math.Trunc(value) && math.Abs(value) < math.MaxInt64 {
+		return p.fromEpochInt(int64(value))
+	}
+
+	unit := p.unit
+	if unit == 0 {
+		if math.Abs(value) < minInferredEpoch {
+			return time.Time{}, fmt.Errorf("unable to parse timestamp: %v is too small to be an epoch; set the timestamp format to unix to read it as one", value)
+		}
+		unit = inferEpochUnit(math.Abs(value))
+	}
+	return time.Unix(0, int64(value*float64(unit))).In(p.location), nil
+}
+
+func (p *timestampParser) fromEpochInt(value int64) (time.Time, error) {
+	unit := p.unit
+	if unit == 0 {
+		abs := value
+		if abs < 0 {
+			abs = -abs
+		}
+		if abs < minInferredEpoch {
+			return time.Time{}, fmt.Errorf("unable to parse timestamp: %d is too small to be an epoch; set the timestamp format to unix to read it as one", value)
+		}
+		unit = inferEpochUnit(float64(abs))
+	}
+
+	switch unit {
+	case time.Second:
+		return time.Unix(value, 0).In(p.location), nil
+	case time.Millisecond:
+		return time.UnixMilli(value).In(p.location), nil
+	case time.Microsecond:
+		return time.UnixMicro(value).In(p.location), nil
+	default:
+		return time.Unix(0, value).In(p.location), nil
+	}
+}
+
+// inferEpochUnit guesses the unit of an epoch value from its magnitude:
+// seconds cover dates up to the year 5138, after which values are read as
+// milliseconds, microseconds and finally nanoseconds.
+func inferEpochUnit(abs float64) time.Duration {
+	switch {
+	case abs < 1e11:
+		return time.Second
+	case abs < 1e14:
+		return time.Millisecond
+	case abs < 1e17:
+		return time.Microsecond
+	default:
+		return time.Nanosecond
+	}
+}
+
+func convertStrftime(format string) (string, error) {
+	var layout strings.Builder
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			layout.WriteByte(format[i])
+			continue
+		}
+
+		if i+1 >= len(format) {
+			return "", fmt.Errorf("invalid strftime layout %q: trailing %%", format)
+		}
+		i++
+
+		directive, supported := strftimeDirectives[format[i]]
+		if !supported {
+			return "", fmt.Errorf("invalid strftime layout %q: unsupported directive %%%c", format, format[i])
+		}
+		layout.WriteString(directive)
+	}
+
+	return layout.String(), nil
+}
@@ -0,0 +1,156 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+func TestTimestampParserEpochUnits(t *testing.T) {
+	expected := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name  string
+		value interface{}
+	}{
+		{"seconds", float64(1705314600)},
+		{"millis", float64(1705314600000)},
+		{"micros", float64(1705314600000000)},
+		{"nanos", "1705314600000000000"},
+		{"seconds string", "1705314600"},
+		{"fractional seconds", 1705314600.0},
+		{"int", 1705314600},
+	}
+
+	for _, tc := range testCases {
+		timestamp, err := defaultTimestampParser.parse(tc.value)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		if !timestamp.Equal(expected) {
+			t.Errorf("%s: expected %v, got %v", tc.name, expected, timestamp.UTC())
+		}
+	}
+}
+
+func TestTimestampParserExplicitUnit(t *testing.T) {
+	parser, err := newTimestampParser(types.TimestampMapping{Format: "unix_ms"})
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+
+	timestamp, err := parser.parse(float64(1000))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !timestamp.Equal(time.Unix(1, 0)) {
+		t.Errorf("Expected 1000 to be read as milliseconds, got %v", timestamp.UTC())
+	}
+}
+
+func TestTimestampParserLayouts(t *testing.T) {
+	parser, err := newTimestampParser(types.TimestampMapping{
+		Format:   "%d/%m/%Y %H:%M:%S",
+		Formats:  []string{"Jan 2 2006 15:04"},
+		Timezone: "Europe/Berlin",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+
+	expected := time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)
+
+	for _, value := range []string{"15/01/2024 10:30:00", "Jan 15 2024 10:30"} {
+		timestamp, err := parser.parse(value)
+		if err != nil {
+			t.Errorf("Failed to parse %s: %v", value, err)
+			continue
+		}
+		if !timestamp.Equal(expected) {
+			t.Errorf("Expected %s to be %v, got %v", value, expected, timestamp.UTC())
+		}
+	}
+
+	if _, err := parser.parse("2024-01-15T10:30:00Z"); err == nil {
+		t.Error("Expected custom layouts to replace the default layouts")
+	}
+}
+
+func TestConvertStrftime(t *testing.T) {
+	layout, err := convertStrftime("%Y-%m-%dT%H:%M:%S.%f%z")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if layout != "2006-01-02T15:04:05.000000-0700" {
+		t.Errorf("Unexpected layout: %s", layout)
+	}
+
+	if _, err := convertStrftime("%Q"); err == nil {
+		t.Error("Expected unsupported directive to be rejected")
+	}
+}
+
+func TestEpochTimestampsAcrossFormats(t *testing.T) {
+	expected := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	inputs := map[string]string{
+		"json":  `[{"id": "e1", "timestamp": 1705314600000}]`,
+		"jsonl": `{"id": "e1", "timestamp": 1705314600}`,
+		"csv":   "id,timestamp\ne1,1705314600000000",
+	}
+
+	for format, data := range inputs {
+		reader, err := NewEventReader(strings.NewReader(data), format, ReaderOptions{})
+		if err != nil {
+			t.Fatalf("%s: failed to create reader: %v", format, err)
+		}
+
+		events, err := ReadAll(reader)
+		if err != nil {
+			t.Fatalf("%s: failed to parse events: %v", format, err)
+		}
+		if len(events) != 1 || !events[0].Timestamp.Equal(expected) {
+			t.Errorf("%s: expected one event at %v, got %v", format, expected, events)
+		}
+	}
+}
+
+func TestTimestampParserCompactDatesAndSmallNumbers(t *testing.T) {
+	timestamp, err := defaultTimestampParser.parse("20240115")
+	if err != nil || !timestamp.Equal(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected 20240115 to be a date, got %v (%v)", timestamp, err)
+	}
+
+	timestamp, err = defaultTimestampParser.parse("20240115103000")
+	if err != nil || !timestamp.Equal(time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)) {
+		t.Errorf("Expected 20240115103000 to be a date and time, got %v (%v)", timestamp, err)
+	}
+
+	for _, value := range []interface{}{float64(42), 1000, "12345"} {
+		if _, err := defaultTimestampParser.parse(value); err == nil {
+			t.Errorf("Expected %v to be rejected without an epoch format", value)
+		}
+	}
+}
+
+func TestTimezoneAppliesWithoutFieldMapping(t *testing.T) {
+	mapping := &types.FieldMapping{Timestamp: types.TimestampMapping{Timezone: "Europe/Berlin"}}
+	data := `{"specversion": "1.0", "id": "e1", "source": "s", "type": "t", "time": "2024-01-15 10:30:00"}`
+
+	reader, err := NewEventReader(strings.NewReader(data), "cloudevents", ReaderOptions{Mapping: mapping})
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+	events, err := ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to parse events: %v", err)
+	}
+
+	expected := time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)
+	if len(events) != 1 || !events[0].Timestamp.Equal(expected) {
+		t.Errorf("Expected one event at %v, got %v", expected, events)
+	}
+}
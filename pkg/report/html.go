@@ -0,0 +1,94 @@
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/output"
+)
+
+const (
+	histogramBase   = 145.0
+	histogramHeight = 130.0
+	timelineTicks   = 5
+)
+
+//go:embed report.html.tmpl
+var reportTemplate string
+
+var funcs = template.FuncMap{
+	"shortSHA":  output.ShortSHA,
+	"percent":   output.Percent,
+	"firstLine": firstLine,
+	"opacity":   func(score float64) float64 { return 0.2 + 0.8*max(0, min(score, 1)) },
+	"offset":    func(v, by float64) float64 { return v + by },
+	"add":       func(a, b int) int { return a + b },
+	"mul":       func(a, b int) int { return a * b },
+	"barY":      func(height float64) float64 { return histogramBase - height*histogramHeight },
+	"barHeight": func(height float64) float64 { return height * histogramHeight },
+	"dict":      dict,
+}
+
+// Tick is a labelled position on the timeline axis.
+type Tick struct {
+	X     float64
+	Label string
+}
+
+// Ticks spreads axis labels evenly between the report's start and end.
+func (r *Report) Ticks() []Tick {
+	if r.Start.IsZero() {
+		return nil
+	}
+
+	layout := "01-02 15:04"
+	if span := r.End.Sub(r.Start); span < 24*time.Hour {
+		layout = "15:04"
+	} else if span > 90*24*time.Hour {
+		layout = "2006-01-02"
+	}
+
+	ticks := make([]Tick, 0, timelineTicks)
+	for i := 0; i < timelineTicks; i++ {
+		t := r.Start.Add(r.End.Sub(r.Start) * time.Duration(i) / (timelineTicks - 1))
+		ticks = append(ticks, Tick{X: r.x(t), Label: t.Format(layout)})
+	}
+	return ticks
+}
+
+// Render writes the report as a single HTML page. Styles and charts are
+// inline, so the page has no external dependencies and can be viewed offline.
+func Render(w io.Writer, report *Report) error {
+	tmpl, err := template.New("report").Funcs(funcs).Parse(reportTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse report template: %w", err)
+	}
+	if err := tmpl.Execute(w, report); err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+	return nil
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+func dict(pairs ...interface{}) (map[string]interface{}, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("dict expects key and value pairs")
+	}
+	values := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict keys must be strings")
+		}
+		values[key] = pairs[i+1]
+	}
+	return values, nil
+}
@@ -0,0 +1,302 @@
+package report
+
+import (
+	"sort"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/output"
+	"github.com/fraser-isbester/git-snap/pkg/stats"
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+const (
+	timelineWidth = 1000.0
+	histogramBins = 10
+)
+
+// Report is the data behind the HTML report. Timeline positions are
+// precomputed so that the page renders as static SVG without scripts.
+type Report struct {
+	Title        string
+	GeneratedAt  time.Time
+	Threshold    float64
+	Start        time.Time
+	End          time.Time
+	Summary      stats.Stats
+	Authors      []AuthorTimeline
+	Scores       []Bucket
+	BestScores   []Bucket
+	Repositories []RepositorySummary
+	Unmatched    []types.SnapEvent
+	// EventsRead is the number of events given to Build, which is zero when
+	// only results were available and unmatched events are unknown.
+	EventsRead int
+}
+
+type AuthorTimeline struct {
+	Author       string
+	Email        string
+	Commits      []TimelineCommit
+	Events       []TimelineEvent
+	Links        []TimelineLink
+	Correlations int
+}
+
+type TimelineCommit struct {
+	SHA       string
+	Message   string
+	Timestamp time.Time
+	X         float64
+}
+
+type TimelineEvent struct {
+	ID        string
+	Timestamp time.Time
+	X         float64
+}
+
+// TimelineLink joins an event to a commit it correlated with.
+type TimelineLink struct {
+	EventX  float64
+	CommitX float64
+	Score   float64
+	Label   string
+}
+
+// Bucket is one bar of a score histogram, covering [Low, High).
+type Bucket struct {
+	Low    float64
+	High   float64
+	Count  int
+	Height float64
+}
+
+type RepositorySummary struct {
+	Repository   string
+	Commits      int
+	Events       int
+	Authors      int
+	Correlations int
+	MeanScore    float64
+}
+
+// Build assembles a report from correlation results and, optionally, the
+// events that were correlated, which are needed to list unmatched events.
+func Build(title string, results []types.CorrelationResult, events []types.SnapEvent, threshold float64) *Report {
+	report := &Report{
+		Title:       title,
+		GeneratedAt: time.Now(),
+		Threshold:   threshold,
+		Summary:     stats.Compute(stats.Input{Results: results, Events: len(events)}, stats.DefaultAmbiguityMargin),
+		EventsRead:  len(events),
+	}
+
+	matched := make(map[string]bool)
+	for _, result := range results {
+		matched[result.Event.Key()] = true
+		report.extend(result.Event.Timestamp)
+		report.extend(result.Commit.Timestamp)
+	}
+	for _, event := range events {
+		if !matched[event.Key()] {
+			report.Unmatched = append(report.Unmatched, event)
+		}
+	}
+	sort.SliceStable(report.Unmatched, func(i, j int) bool {
+		return report.Unmatched[i].Timestamp.Before(report.Unmatched[j].Timestamp)
+	})
+
+	report.Authors = report.buildTimelines(results)
+	report.Scores = histogram(allScores(results))
+	report.BestScores = histogram(bestScores(results))
+	report.Repositories = repositorySummaries(results)
+
+	return report
+}
+
+func (r *Report) extend(t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	if r.Start.IsZero() || t.Before(r.Start) {
+		r.Start = t
+	}
+	if r.End.IsZero() || t.After(r.End) {
+		r.End = t
+	}
+}
+
+// x maps a time onto the shared timeline axis.
+func (r *Report) x(t time.Time) float64 {
+	span := r.End.Sub(r.Start)
+	if span <= 0 {
+		return timelineWidth / 2
+	}
+	return float64(t.Sub(r.Start)) / float64(span) * timelineWidth
+}
+
+func (r *Report) buildTimelines(results []types.CorrelationResult) []AuthorTimeline {
+	timelines := make(map[string]*AuthorTimeline)
+	seenCommits := make(map[string]bool)
+	seenEvents := make(map[string]bool)
+
+	for _, result := range results {
+		commit := result.Commit
+		key := commit.AuthorEmail
+		if key == "" {
+			key = commit.Author
+		}
+
+		timeline, exists := timelines[key]
+		if !exists {
+			timeline = &AuthorTimeline{Author: commit.Author, Email: commit.AuthorEmail}
+			timelines[key] = timeline
+		}
+		timeline.Correlations++
+
+		if !seenCommits[commit.SHA] {
+			seenCommits[commit.SHA] = true
+			timeline.Commits = append(timeline.Commits, TimelineCommit{
+				SHA:       commit.SHA,
+				Message:   commit.Message,
+				Timestamp: commit.Timestamp,
+				X:         r.x(commit.Timestamp),
+			})
+		}
+
+		eventKey := key + "\x00" + result.Event.Key()
+		if !seenEvents[eventKey] {
+			seenEvents[eventKey] = true
+			timeline.Events = append(timeline.Events, TimelineEvent{
+				ID:        result.Event.Key(),
+				Timestamp: result.Event.Timestamp,
+				X:         r.x(result.Event.Timestamp),
+			})
+		}
+
+		timeline.Links = append(timeline.Links, TimelineLink{
+			EventX:  r.x(result.Event.Timestamp),
+			CommitX: r.x(commit.Timestamp),
+			Score:   result.Score,
+			Label:   result.Event.Key() + " → " + output.ShortSHA(commit.SHA),
+		})
+	}
+
+	sorted := make([]AuthorTimeline, 0, len(timelines))
+	for _, timeline := range timelines {
+		sorted = append(sorted, *timeline)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Correlations != sorted[j].Correlations {
+			return sorted[i].Correlations > sorted[j].Correlations
+		}
+		return sorted[i].Author < sorted[j].Author
+	})
+	return sorted
+}
+
+func allScores(results []types.CorrelationResult) []float64 {
+	scores := make([]float64, len(results))
+	for i, result := range results {
+		scores[i] = result.Score
+	}
+	return scores
+}
+
+// bestScores returns the highest score of each event.
+func bestScores(results []types.CorrelationResult) []float64 {
+	best := make(map[string]float64)
+	var order []string
+	for _, result := range results {
+		key := result.Event.Key()
+		score, exists := best[key]
+		if !exists {
+			order = append(order, key)
+		}
+		if !exists || result.Score > score {
+			best[key] = result.Score
+		}
+	}
+
+	scores := make([]float64, len(order))
+	for i, key := range order {
+		scores[i] = best[key]
+	}
+	return scores
+}
+
+// histogram buckets scores into tenths; a score of 1 falls in the last
+// bucket. Heights are relative to the tallest bar.
+func histogram(scores []float64) []Bucket {
+	buckets := make([]Bucket, histogramBins)
+	for i := range buckets {
+		buckets[i].Low = float64(i) / histogramBins
+		buckets[i].High = float64(i+1) / histogramBins
+	}
+
+	for _, score := range scores {
+		bin := int(score * histogramBins)
+		bin = max(0, min(bin, histogramBins-1))
+		buckets[bin].Count++
+	}
+
+	tallest := 0
+	for _, bucket := range buckets {
+		tallest = max(tallest, bucket.Count)
+	}
+	if tallest > 0 {
+		for i := range buckets {
+			buckets[i].Height = float64(buckets[i].Count) / float64(tallest)
+		}
+	}
+	return buckets
+}
+
+func repositorySummaries(results []types.CorrelationResult) []RepositorySummary {
+	type accumulator struct {
+		summary RepositorySummary
+		commits map[string]bool
+		events  map[string]bool
+		authors map[string]bool
+		total   float64
+	}
+
+	byRepository := make(map[string]*accumulator)
+	for _, result := range results {
+		name := result.Commit.Repository
+		if name == "" {
+			name = "(unknown)"
+		}
+
+		acc, exists := byRepository[name]
+		if !exists {
+			acc = &accumulator{
+				summary: RepositorySummary{Repository: name},
+				commits: make(map[string]bool),
+				events:  make(map[string]bool),
+				authors: make(map[string]bool),
+			}
+			byRepository[name] = acc
+		}
+
+		acc.summary.Correlations++
+		acc.total += result.Score
+		acc.commits[result.Commit.SHA] = true
+		acc.events[result.Event.Key()] = true
+		acc.authors[result.Commit.AuthorEmail] = true
+	}
+
+	summaries := make([]RepositorySummary, 0, len(byRepository))
+	for _, acc := range byRepository {
+		summary := acc.summary
+		summary.Commits = len(acc.commits)
+		summary.Events = len(acc.events)
+		summary.Authors = len(acc.authors)
+		summary.MeanScore = acc.total / float64(summary.Correlations)
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Repository < summaries[j].Repository
+	})
+	return summaries
+}
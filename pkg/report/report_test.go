@@ -0,0 +1,97 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+func reportResults() ([]types.CorrelationResult, []types.SnapEvent) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	events := []types.SnapEvent{
+		{ID: "evt-1", Timestamp: base},
+		{ID: "evt-2", Timestamp: base.Add(time.Hour)},
+		{ID: "evt-3", Timestamp: base.Add(30 * time.Minute)},
+	}
+	alice := types.EnrichedCommit{SHA: "aaaa1111", Author: "Alice", AuthorEmail: "alice@example.com", Repository: "api", Timestamp: base.Add(2 * time.Hour), Message: "Add <endpoint>\n\nDetails"}
+	bob := types.EnrichedCommit{SHA: "bbbb2222", Author: "Bob", AuthorEmail: "bob@example.com", Timestamp: base.Add(4 * time.Hour)}
+
+	results := []types.CorrelationResult{
+		{Event: events[0], Commit: alice, Score: 0.95},
+		{Event: events[0], Commit: bob, Score: 0.35},
+		{Event: events[1], Commit: alice, Score: 1},
+	}
+	return results, events
+}
+
+func TestBuild(t *testing.T) {
+	results, events := reportResults()
+	report := Build("Report", results, events, 0.3)
+
+	if len(report.Unmatched) != 1 || report.Unmatched[0].ID != "evt-3" {
+		t.Errorf("Expected evt-3 to be unmatched, got %v", report.Unmatched)
+	}
+	if report.EventsRead != 3 {
+		t.Errorf("Expected 3 events read, got %d", report.EventsRead)
+	}
+
+	if len(report.Authors) != 2 || report.Authors[0].Author != "Alice" {
+		t.Fatalf("Expected Alice's timeline first, got %+v", report.Authors)
+	}
+	alice := report.Authors[0]
+	if len(alice.Commits) != 1 || len(alice.Events) != 2 || len(alice.Links) != 2 {
+		t.Errorf("Expected 1 commit, 2 events and 2 links for Alice, got %d, %d and %d", len(alice.Commits), len(alice.Events), len(alice.Links))
+	}
+	if alice.Events[0].X != 0 || alice.Commits[0].X != 500 || report.Authors[1].Commits[0].X != timelineWidth {
+		t.Errorf("Unexpected timeline positions: %+v, %+v", alice, report.Authors[1])
+	}
+
+	if report.Scores[9].Count != 2 || report.Scores[3].Count != 1 || report.Scores[9].Height != 1 || report.Scores[3].Height != 0.5 {
+		t.Errorf("Unexpected score histogram: %+v", report.Scores)
+	}
+	if report.BestScores[9].Count != 2 || report.BestScores[3].Count != 0 {
+		t.Errorf("Unexpected best score histogram: %+v", report.BestScores)
+	}
+
+	if len(report.Repositories) != 2 {
+		t.Fatalf("Expected 2 repositories, got %+v", report.Repositories)
+	}
+	if api := report.Repositories[1]; api.Repository != "api" || api.Commits != 1 || api.Events != 2 || api.MeanScore != 0.975 {
+		t.Errorf("Unexpected summary for api: %+v", api)
+	}
+	if unknown := report.Repositories[0]; unknown.Repository != "(unknown)" || unknown.Correlations != 1 {
+		t.Errorf("Unexpected summary for commits without a repository: %+v", unknown)
+	}
+}
+
+func TestRender(t *testing.T) {
+	results, events := reportResults()
+
+	var buf bytes.Buffer
+	if err := Render(&buf, Build("Weekly <report>", results, events, 0.3)); err != nil {
+		t.Fatalf("Failed to render report: %v", err)
+	}
+	page := buf.String()
+
+	for _, expected := range []string{"<title>Weekly &lt;report&gt;</title>", "Add &lt;endpoint&gt;", "evt-3", "<svg", `class="link"`} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("Expected report to contain %q", expected)
+		}
+	}
+	for _, external := range []string{"http://", "https://", "<script"} {
+		if strings.Contains(page, external) {
+			t.Errorf("Expected a self-contained report, found %q", external)
+		}
+	}
+
+	buf.Reset()
+	if err := Render(&buf, Build("Empty", nil, nil, 0)); err != nil {
+		t.Fatalf("Failed to render empty report: %v", err)
+	}
+	if !strings.Contains(buf.String(), "No correlations.") {
+		t.Errorf("Expected empty report to say there are no correlations")
+	}
+}
@@ -0,0 +1,295 @@
+package stats
+
+import (
+	"math"
+	"sort"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+// DefaultAmbiguityMargin is the score difference under which an event's two
+// best candidates count as near-equal.
+const DefaultAmbiguityMargin = 0.05
+
+// Input is what statistics are computed from. Events is the number of events
+// read, when known; otherwise it is taken from the results and unmatched
+// events. Commits are all commits considered, correlated or not. Candidates
+// are the results before the threshold was applied, from which ambiguity is
+// computed; when nil, Results are used.
+type Input struct {
+	Results    []types.CorrelationResult
+	Candidates []types.CorrelationResult
+	Commits    []types.EnrichedCommit
+	Unmatched  []types.UnmatchedEvent
+	Events     int
+}
+
+type Stats struct {
+	Events            int          `json:"events"`
+	MatchedEvents     int          `json:"matched_events"`
+	MatchRate         float64      `json:"match_rate"`
+	Commits           int          `json:"commits"`
+	CorrelatedCommits int          `json:"correlated_commits"`
+	CommitCoverage    float64      `json:"commit_coverage"`
+	Correlations      int          `json:"correlations"`
+	Score             Distribution `json:"score"`
+	TimeDelta         Distribution `json:"time_delta_seconds"`
+	Rules             []RuleStats  `json:"rules"`
+	Authors           []Coverage   `json:"authors"`
+	Repositories      []Coverage   `json:"repositories"`
+	Ambiguity         Ambiguity    `json:"ambiguity"`
+}
+
+// Distribution summarizes a set of values by percentile, interpolating
+// between the nearest ranks.
+type Distribution struct {
+	Min  float64 `json:"min"`
+	P25  float64 `json:"p25"`
+	P50  float64 `json:"p50"`
+	P75  float64 `json:"p75"`
+	P90  float64 `json:"p90"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+	Mean float64 `json:"mean"`
+}
+
+// RuleStats counts how often the rule on an event key matched among the
+// correlations that evaluated it.
+type RuleStats struct {
+	Rule      string  `json:"rule"`
+	Evaluated int     `json:"evaluated"`
+	Matched   int     `json:"matched"`
+	HitRate   float64 `json:"hit_rate"`
+}
+
+// Coverage is the share of an author's or repository's commits that
+// correlated with at least one event.
+type Coverage struct {
+	Name              string  `json:"name"`
+	Commits           int     `json:"commits"`
+	CorrelatedCommits int     `json:"correlated_commits"`
+	Coverage          float64 `json:"coverage"`
+	Events            int     `json:"events"`
+	Correlations      int     `json:"correlations"`
+}
+
+// Ambiguity counts matched events whose two best candidates score within
+// Margin of each other, including a runner-up that scores below the threshold.
+type Ambiguity struct {
+	Margin float64  `json:"margin"`
+	Events int      `json:"events"`
+	Rate   float64  `json:"rate"`
+	IDs    []string `json:"event_ids"`
+}
+
+func Compute(input Input, margin float64) Stats {
+	stats := Stats{Correlations: len(input.Results)}
+
+	events := make(map[string]bool)
+	matched := make(map[string]bool)
+	for _, result := range input.Results {
+		events[result.Event.Key()] = true
+		matched[result.Event.Key()] = true
+	}
+	for _, unmatched := range input.Unmatched {
+		events[unmatched.Event.Key()] = true
+	}
+	stats.Events = max(input.Events, len(events))
+	stats.MatchedEvents = len(matched)
+	stats.MatchRate = ratio(stats.MatchedEvents, stats.Events)
+
+	commits := make(map[string]types.EnrichedCommit)
+	for _, commit := range input.Commits {
+		commits[commit.SHA] = commit
+	}
+	correlated := make(map[string]bool)
+	for _, result := range input.Results {
+		commits[result.Commit.SHA] = result.Commit
+		correlated[result.Commit.SHA] = true
+	}
+	stats.Commits = len(commits)
+	stats.CorrelatedCommits = len(correlated)
+	stats.CommitCoverage = ratio(stats.CorrelatedCommits, stats.Commits)
+
+	scores := make([]float64, len(input.Results))
+	deltas := make([]float64, len(input.Results))
+	for i, result := range input.Results {
+		scores[i] = result.Score
+		deltas[i] = result.TimeDelta.Seconds()
+	}
+	stats.Score = distribution(scores)
+	stats.TimeDelta = distribution(deltas)
+
+	stats.Rules = ruleStats(input.Results)
+	stats.Authors = coverage(commits, input.Results, func(commit types.EnrichedCommit) string {
+		if commit.AuthorEmail != "" {
+			return commit.AuthorEmail
+		}
+		return commit.Author
+	})
+	stats.Repositories = coverage(commits, input.Results, func(commit types.EnrichedCommit) string {
+		if commit.Repository != "" {
+			return commit.Repository
+		}
+		return "(unknown)"
+	})
+	candidates := input.Candidates
+	if candidates == nil {
+		candidates = input.Results
+	}
+	stats.Ambiguity = ambiguity(candidates, matched, margin)
+
+	return stats
+}
+
+func distribution(values []float64) Distribution {
+	if len(values) == 0 {
+		return Distribution{}
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	var total float64
+	for _, value := range sorted {
+		total += value
+	}
+
+	return Distribution{
+		Min:  sorted[0],
+		P25:  percentile(sorted, 0.25),
+		P50:  percentile(sorted, 0.50),
+		P75:  percentile(sorted, 0.75),
+		P90:  percentile(sorted, 0.90),
+		P99:  percentile(sorted, 0.99),
+		Max:  sorted[len(sorted)-1],
+		Mean: total / float64(len(sorted)),
+	}
+}
+
+func percentile(sorted []float64, p float64) float64 {
+	rank := p * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+func ruleStats(results []types.CorrelationResult) []RuleStats {
+	byRule := make(map[string]*RuleStats)
+	for _, result := range results {
+		for rule, matched := range result.Matches {
+			stats, exists := byRule[rule]
+			if !exists {
+				stats = &RuleStats{Rule: rule}
+				byRule[rule] = stats
+			}
+			stats.Evaluated++
+			if matched {
+				stats.Matched++
+			}
+		}
+	}
+
+	rules := make([]RuleStats, 0, len(byRule))
+	for _, stats := range byRule {
+		stats.HitRate = ratio(stats.Matched, stats.Evaluated)
+		rules = append(rules, *stats)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Rule < rules[j].Rule })
+	return rules
+}
+
+func coverage(commits map[string]types.EnrichedCommit, results []types.CorrelationResult, name func(types.EnrichedCommit) string) []Coverage {
+	type accumulator struct {
+		coverage   Coverage
+		correlated map[string]bool
+		events     map[string]bool
+	}
+
+	byName := make(map[string]*accumulator)
+	get := func(commit types.EnrichedCommit) *accumulator {
+		key := name(commit)
+		acc, exists := byName[key]
+		if !exists {
+			acc = &accumulator{
+				coverage:   Coverage{Name: key},
+				correlated: make(map[string]bool),
+				events:     make(map[string]bool),
+			}
+			byName[key] = acc
+		}
+		return acc
+	}
+
+	for _, commit := range commits {
+		get(commit).coverage.Commits++
+	}
+	for _, result := range results {
+		acc := get(result.Commit)
+		acc.coverage.Correlations++
+		acc.correlated[result.Commit.SHA] = true
+		acc.events[result.Event.Key()] = true
+	}
+
+	coverages := make([]Coverage, 0, len(byName))
+	for _, acc := range byName {
+		c := acc.coverage
+		c.CorrelatedCommits = len(acc.correlated)
+		c.Events = len(acc.events)
+		c.Coverage = ratio(c.CorrelatedCommits, c.Commits)
+		coverages = append(coverages, c)
+	}
+	sort.Slice(coverages, func(i, j int) bool {
+		if coverages[i].Commits != coverages[j].Commits {
+			return coverages[i].Commits > coverages[j].Commits
+		}
+		return coverages[i].Name < coverages[j].Name
+	})
+	return coverages
+}
+
+func ambiguity(results []types.CorrelationResult, matched map[string]bool, margin float64) Ambiguity {
+	type topTwo struct {
+		best, second float64
+		candidates   int
+	}
+
+	byEvent := make(map[string]*topTwo)
+	for _, result := range results {
+		key := result.Event.Key()
+		if !matched[key] {
+			continue
+		}
+		top, exists := byEvent[key]
+		if !exists {
+			top = &topTwo{}
+			byEvent[key] = top
+		}
+		top.candidates++
+		switch {
+		case result.Score > top.best:
+			top.second = top.best
+			top.best = result.Score
+		case result.Score > top.second:
+			top.second = result.Score
+		}
+	}
+
+	ambiguous := Ambiguity{Margin: margin, IDs: []string{}}
+	for key, top := range byEvent {
+		if top.candidates > 1 && top.best-top.second <= margin {
+			ambiguous.IDs = append(ambiguous.IDs, key)
+		}
+	}
+	sort.Strings(ambiguous.IDs)
+	ambiguous.Events = len(ambiguous.IDs)
+	ambiguous.Rate = ratio(ambiguous.Events, len(byEvent))
+	return ambiguous
+}
+
+func ratio(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) / float64(whole)
+}
@@ -0,0 +1,93 @@
+package stats
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+func TestCompute(t *testing.T) {
+	alice1 := types.EnrichedCommit{SHA: "a1", AuthorEmail: "alice@example.com", Repository: "api"}
+	alice2 := types.EnrichedCommit{SHA: "a2", AuthorEmail: "alice@example.com", Repository: "api"}
+	bob := types.EnrichedCommit{SHA: "b1", AuthorEmail: "bob@example.com", Repository: "web"}
+	idle := types.EnrichedCommit{SHA: "b2", AuthorEmail: "bob@example.com", Repository: "web"}
+
+	results := []types.CorrelationResult{
+		{Event: types.SnapEvent{ID: "e1"}, Commit: alice1, Score: 0.9, TimeDelta: time.Minute, Matches: map[string]bool{"user_id": true, "repository": true}},
+		{Event: types.SnapEvent{ID: "e1"}, Commit: alice2, Score: 0.88, TimeDelta: 3 * time.Minute, Matches: map[string]bool{"user_id": true, "repository": false}},
+		{Event: types.SnapEvent{ID: "e2"}, Commit: alice1, Score: 0.8, TimeDelta: 5 * time.Minute, Matches: map[string]bool{"user_id": true, "repository": false}},
+		{Event: types.SnapEvent{ID: "e2"}, Commit: bob, Score: 0.6, TimeDelta: 7 * time.Minute, Matches: map[string]bool{"user_id": true, "repository": false}},
+	}
+	unmatched := []types.UnmatchedEvent{{Event: types.SnapEvent{ID: "e3"}}, {Event: types.SnapEvent{ID: "e4"}}}
+
+	stats := Compute(Input{Results: results, Commits: []types.EnrichedCommit{idle}, Unmatched: unmatched}, DefaultAmbiguityMargin)
+
+	if stats.Events != 4 || stats.MatchedEvents != 2 || stats.MatchRate != 0.5 {
+		t.Errorf("Expected 2 of 4 events matched, got %d of %d (%f)", stats.MatchedEvents, stats.Events, stats.MatchRate)
+	}
+	if stats.Commits != 4 || stats.CorrelatedCommits != 3 || stats.CommitCoverage != 0.75 {
+		t.Errorf("Expected 3 of 4 commits correlated, got %d of %d", stats.CorrelatedCommits, stats.Commits)
+	}
+
+	if stats.Score.Min != 0.6 || stats.Score.Max != 0.9 || math.Abs(stats.Score.P50-0.84) > 1e-9 || math.Abs(stats.Score.Mean-0.795) > 1e-9 {
+		t.Errorf("Unexpected score distribution: %+v", stats.Score)
+	}
+	if stats.TimeDelta.P50 != 240 || stats.TimeDelta.P25 != 150 {
+		t.Errorf("Unexpected time delta distribution: %+v", stats.TimeDelta)
+	}
+
+	if len(stats.Rules) != 2 || stats.Rules[0].Rule != "repository" || stats.Rules[0].HitRate != 0.25 || stats.Rules[1].HitRate != 1 {
+		t.Errorf("Unexpected rule stats: %+v", stats.Rules)
+	}
+
+	if len(stats.Authors) != 2 {
+		t.Fatalf("Expected 2 authors, got %+v", stats.Authors)
+	}
+	for _, author := range stats.Authors {
+		switch author.Name {
+		case "alice@example.com":
+			if author.Commits != 2 || author.Coverage != 1 || author.Events != 2 {
+				t.Errorf("Unexpected coverage for alice: %+v", author)
+			}
+		case "bob@example.com":
+			if author.Commits != 2 || author.Coverage != 0.5 || author.Events != 1 {
+				t.Errorf("Unexpected coverage for bob: %+v", author)
+			}
+		}
+	}
+	if len(stats.Repositories) != 2 || stats.Repositories[0].Name != "api" {
+		t.Errorf("Unexpected repository coverage: %+v", stats.Repositories)
+	}
+
+	if stats.Ambiguity.Events != 1 || stats.Ambiguity.IDs[0] != "e1" || stats.Ambiguity.Rate != 0.5 {
+		t.Errorf("Expected e1 to be ambiguous, got %+v", stats.Ambiguity)
+	}
+}
+
+func TestComputeEmpty(t *testing.T) {
+	stats := Compute(Input{Events: 3}, DefaultAmbiguityMargin)
+	if stats.Events != 3 || stats.MatchRate != 0 || stats.Score != (Distribution{}) {
+		t.Errorf("Unexpected stats without results: %+v", stats)
+	}
+	if stats.Rules == nil || stats.Authors == nil || stats.Ambiguity.IDs == nil {
+		t.Errorf("Expected empty lists rather than null in JSON")
+	}
+}
+
+func TestComputeAmbiguityCountsCandidatesBelowThreshold(t *testing.T) {
+	commit := types.EnrichedCommit{SHA: "a1"}
+	best := types.CorrelationResult{Event: types.SnapEvent{ID: "e1"}, Commit: commit, Score: 0.53}
+	runnerUp := types.CorrelationResult{Event: types.SnapEvent{ID: "e1"}, Commit: types.EnrichedCommit{SHA: "a2"}, Score: 0.49}
+	unmatched := types.CorrelationResult{Event: types.SnapEvent{ID: "e2"}, Commit: commit, Score: 0.3}
+
+	stats := Compute(Input{
+		Results:    []types.CorrelationResult{best},
+		Candidates: []types.CorrelationResult{best, runnerUp, unmatched, {Event: unmatched.Event, Commit: runnerUp.Commit, Score: 0.29}},
+	}, DefaultAmbiguityMargin)
+
+	if stats.Ambiguity.Events != 1 || stats.Ambiguity.IDs[0] != "e1" || stats.Ambiguity.Rate != 1 {
+		t.Errorf("Expected only the matched e1 to be ambiguous, got %+v", stats.Ambiguity)
+	}
+}
@@ -0,0 +1,521 @@
+package store
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+	_ "modernc.org/sqlite"
+)
+
+const SchemaVersion = 2
+
+const versionTable = `CREATE TABLE IF NOT EXISTS schema_version (version INTEGER NOT NULL)`
+
+// Events are keyed by a surrogate integer ID. source_id holds the event's own
+// ID; events without one are told apart by a fingerprint of their content,
+// so that distinct events sharing a timestamp keep their own rows.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		started_at TEXT NOT NULL,
+		config TEXT NOT NULL,
+		repository TEXT NOT NULL,
+		threshold REAL NOT NULL,
+		events INTEGER NOT NULL DEFAULT 0
+	)`,
+	`CREATE TABLE IF NOT EXISTS events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		source_id TEXT UNIQUE,
+		fingerprint TEXT UNIQUE,
+		timestamp TEXT NOT NULL,
+		metadata TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS event_attributes (
+		event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+		key TEXT NOT NULL,
+		value TEXT,
+		PRIMARY KEY (event_id, key)
+	)`,
+	`CREATE TABLE IF NOT EXISTS commits (
+		sha TEXT PRIMARY KEY,
+		author TEXT,
+		author_email TEXT,
+		committer TEXT,
+		timestamp TEXT NOT NULL,
+		message TEXT,
+		branch TEXT,
+		repository TEXT,
+		pr_number INTEGER,
+		additions INTEGER,
+		deletions INTEGER
+	)`,
+	`CREATE TABLE IF NOT EXISTS commit_files (
+		sha TEXT NOT NULL REFERENCES commits(sha) ON DELETE CASCADE,
+		path TEXT NOT NULL,
+		PRIMARY KEY (sha, path)
+	)`,
+	`CREATE TABLE IF NOT EXISTS correlations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		run_id INTEGER NOT NULL REFERENCES runs(id),
+		event_id INTEGER NOT NULL REFERENCES events(id),
+		commit_sha TEXT NOT NULL REFERENCES commits(sha),
+		score REAL NOT NULL,
+		time_delta_ms INTEGER NOT NULL,
+		attributed_lines INTEGER,
+		line_attribution REAL,
+		UNIQUE (run_id, event_id, commit_sha)
+	)`,
+	`CREATE TABLE IF NOT EXISTS correlation_matches (
+		correlation_id INTEGER NOT NULL REFERENCES correlations(id) ON DELETE CASCADE,
+		rule TEXT NOT NULL,
+		matched INTEGER NOT NULL,
+		PRIMARY KEY (correlation_id, rule)
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_events_timestamp ON events(timestamp)`,
+	`CREATE INDEX IF NOT EXISTS idx_event_attributes_key_value ON event_attributes(key, value)`,
+	`CREATE INDEX IF NOT EXISTS idx_commits_timestamp ON commits(timestamp)`,
+	`CREATE INDEX IF NOT EXISTS idx_commits_author_email ON commits(author_email)`,
+	`CREATE INDEX IF NOT EXISTS idx_commits_repository ON commits(repository)`,
+	`CREATE INDEX IF NOT EXISTS idx_correlations_event ON correlations(event_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_correlations_commit ON correlations(commit_sha)`,
+	`CREATE INDEX IF NOT EXISTS idx_correlations_run ON correlations(run_id)`,
+}
+
+// Run describes one correlate run. Events is the number of events it read,
+// correlated or not.
+type Run struct {
+	ID         int64
+	StartedAt  time.Time
+	Config     string
+	Repository string
+	Threshold  float64
+	Events     int
+}
+
+// Store records correlation runs in a SQLite database. Events and commits
+// are upserted so that repeated runs keep one row per event and commit,
+// while each run adds its own correlations.
+type Store struct {
+	db *sql.DB
+}
+
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	if _, err := db.Exec(versionTable); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create schema in %s: %w", path, err)
+	}
+
+	var version int
+	err = db.QueryRow(`SELECT version FROM schema_version`).Scan(&version)
+	switch {
+	case err == sql.ErrNoRows:
+		if _, err := db.Exec(`INSERT INTO schema_version (version) VALUES (?)`, SchemaVersion); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to record schema version: %w", err)
+		}
+	case err != nil:
+		db.Close()
+		return nil, fmt.Errorf("failed to read schema version: %w", err)
+	case version != SchemaVersion:
+		db.Close()
+		return nil, fmt.Errorf("%s has schema version %d, expected %d", path, version, SchemaVersion)
+	}
+
+	for _, statement := range schema {
+		if _, err := db.Exec(statement); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to create schema in %s: %w", path, err)
+		}
+	}
+
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// WriteRun stores a run with its results in one transaction and returns the
+// run ID. run.ID is ignored.
+func (s *Store) WriteRun(run Run, results []types.CorrelationResult) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	inserted, err := tx.Exec(`INSERT INTO runs (started_at, config, repository, threshold, events) VALUES (?, ?, ?, ?, ?)`,
+		formatTime(run.StartedAt), run.Config, run.Repository, run.Threshold, run.Events)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record run: %w", err)
+	}
+	runID, err := inserted.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to record run: %w", err)
+	}
+
+	writtenEvents := make(map[string]int64)
+	writtenCommits := make(map[string]bool)
+
+	for _, result := range results {
+		key := "id:" + result.Event.ID
+		if result.Event.ID == "" {
+			fingerprint, err := eventFingerprint(result.Event)
+			if err != nil {
+				return 0, err
+			}
+			key = "fingerprint:" + fingerprint
+		}
+		eventID, written := writtenEvents[key]
+		if !written {
+			if eventID, err = writeEvent(tx, result.Event); err != nil {
+				return 0, err
+			}
+			writtenEvents[key] = eventID
+		}
+
+		if !writtenCommits[result.Commit.SHA] {
+			if err := writeCommit(tx, result.Commit); err != nil {
+				return 0, err
+			}
+			writtenCommits[result.Commit.SHA] = true
+		}
+
+		correlation, err := tx.Exec(`INSERT INTO correlations
+			(run_id, event_id, commit_sha, score, time_delta_ms, attributed_lines, line_attribution)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (run_id, event_id, commit_sha) DO NOTHING`,
+			runID, eventID, result.Commit.SHA, result.Score, result.TimeDelta.Milliseconds(),
+			result.AttributedLines, result.LineAttribution)
+		if err != nil {
+			return 0, fmt.Errorf("failed to record correlation of %s with %s: %w", result.Event.Key(), result.Commit.SHA, err)
+		}
+		if affected, err := correlation.RowsAffected(); err != nil || affected == 0 {
+			continue
+		}
+		correlationID, err := correlation.LastInsertId()
+		if err != nil {
+			return 0, fmt.Errorf("failed to record correlation of %s with %s: %w", result.Event.Key(), result.Commit.SHA, err)
+		}
+
+		for rule, matched := range result.Matches {
+			if _, err := tx.Exec(`INSERT OR REPLACE INTO correlation_matches (correlation_id, rule, matched) VALUES (?, ?, ?)`,
+				correlationID, rule, matched); err != nil {
+				return 0, fmt.Errorf("failed to record rule matches: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit run: %w", err)
+	}
+
+	return runID, nil
+}
+
+// eventFingerprint identifies an event without an ID by its timestamp,
+// attributes and metadata.
+func eventFingerprint(event types.SnapEvent) (string, error) {
+	content, err := json.Marshal(struct {
+		Timestamp  string                 `json:"timestamp"`
+		Attributes map[string]interface{} `json:"attributes"`
+		Metadata   map[string]interface{} `json:"metadata"`
+	}{formatTime(event.Timestamp), event.Attributes, event.Metadata})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode event %s: %w", event.Key(), err)
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ReadRun returns a run and its correlations, or the latest run when runID is
+// 0. Attribute values are returned as the text they are stored as.
+func (s *Store) ReadRun(runID int64) (Run, []types.CorrelationResult, error) {
+	query := `SELECT id, started_at, config, repository, threshold, events FROM runs WHERE id = ?`
+	args := []interface{}{runID}
+	if runID == 0 {
+		query = `SELECT id, started_at, config, repository, threshold, events FROM runs ORDER BY id DESC LIMIT 1`
+		args = nil
+	}
+
+	var run Run
+	var startedAt string
+	err := s.db.QueryRow(query, args...).Scan(&run.ID, &startedAt, &run.Config, &run.Repository, &run.Threshold, &run.Events)
+	if err == sql.ErrNoRows {
+		if runID == 0 {
+			return Run{}, nil, fmt.Errorf("no runs stored")
+		}
+		return Run{}, nil, fmt.Errorf("run %d not found", runID)
+	}
+	if err != nil {
+		return Run{}, nil, fmt.Errorf("failed to read run: %w", err)
+	}
+	if run.StartedAt, err = parseTime(startedAt); err != nil {
+		return Run{}, nil, err
+	}
+
+	results, err := s.readCorrelations(run.ID)
+	if err != nil {
+		return Run{}, nil, err
+	}
+	return run, results, nil
+}
+
+func (s *Store) readCorrelations(runID int64) ([]types.CorrelationResult, error) {
+	rows, err := s.db.Query(`SELECT c.id, c.event_id, c.commit_sha, c.score, c.time_delta_ms,
+			c.attributed_lines, c.line_attribution, e.source_id, e.timestamp, e.metadata
+		FROM correlations c JOIN events e ON e.id = c.event_id
+		WHERE c.run_id = ? ORDER BY c.id`, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read correlations: %w", err)
+	}
+	defer rows.Close()
+
+	var results []types.CorrelationResult
+	var correlationIDs, eventIDs []int64
+	for rows.Next() {
+		var result types.CorrelationResult
+		var correlationID, eventID, timeDelta int64
+		var sourceID, metadata sql.NullString
+		var timestamp string
+		if err := rows.Scan(&correlationID, &eventID, &result.Commit.SHA, &result.Score, &timeDelta,
+			&result.AttributedLines, &result.LineAttribution, &sourceID, &timestamp, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to read correlations: %w", err)
+		}
+
+		result.Event.ID = sourceID.String
+		result.TimeDelta = time.Duration(timeDelta) * time.Millisecond
+		if result.Event.Timestamp, err = parseTime(timestamp); err != nil {
+			return nil, err
+		}
+		if metadata.Valid {
+			if err := json.Unmarshal([]byte(metadata.String), &result.Event.Metadata); err != nil {
+				return nil, fmt.Errorf("invalid metadata of event %d: %w", eventID, err)
+			}
+		}
+
+		results = append(results, result)
+		correlationIDs = append(correlationIDs, correlationID)
+		eventIDs = append(eventIDs, eventID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read correlations: %w", err)
+	}
+
+	commits := make(map[string]types.EnrichedCommit)
+	for i := range results {
+		if results[i].Event.Attributes, err = s.readAttributes(eventIDs[i]); err != nil {
+			return nil, err
+		}
+		if results[i].Matches, err = s.readMatches(correlationIDs[i]); err != nil {
+			return nil, err
+		}
+
+		commit, read := commits[results[i].Commit.SHA]
+		if !read {
+			if commit, err = s.readCommit(results[i].Commit.SHA); err != nil {
+				return nil, err
+			}
+			commits[commit.SHA] = commit
+		}
+		results[i].Commit = commit
+	}
+
+	return results, nil
+}
+
+func (s *Store) readAttributes(eventID int64) (map[string]interface{}, error) {
+	rows, err := s.db.Query(`SELECT key, value FROM event_attributes WHERE event_id = ?`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attributes of event %d: %w", eventID, err)
+	}
+	defer rows.Close()
+
+	attributes := make(map[string]interface{})
+	for rows.Next() {
+		var key string
+		var value interface{}
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to read attributes of event %d: %w", eventID, err)
+		}
+		if text, ok := value.([]byte); ok {
+			value = string(text)
+		}
+		attributes[key] = value
+	}
+	return attributes, rows.Err()
+}
+
+func (s *Store) readMatches(correlationID int64) (map[string]bool, error) {
+	rows, err := s.db.Query(`SELECT rule, matched FROM correlation_matches WHERE correlation_id = ?`, correlationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rule matches: %w", err)
+	}
+	defer rows.Close()
+
+	matches := make(map[string]bool)
+	for rows.Next() {
+		var rule string
+		var matched bool
+		if err := rows.Scan(&rule, &matched); err != nil {
+			return nil, fmt.Errorf("failed to read rule matches: %w", err)
+		}
+		matches[rule] = matched
+	}
+	return matches, rows.Err()
+}
+
+func (s *Store) readCommit(sha string) (types.EnrichedCommit, error) {
+	commit := types.EnrichedCommit{SHA: sha}
+	var author, authorEmail, committer, message, branch, repository sql.NullString
+	var prNumber sql.NullInt64
+	var additions, deletions sql.NullInt64
+	var timestamp string
+	err := s.db.QueryRow(`SELECT author, author_email, committer, timestamp, message, branch, repository,
+			pr_number, additions, deletions
+		FROM commits WHERE sha = ?`, sha).Scan(&author, &authorEmail, &committer, &timestamp, &message,
+		&branch, &repository, &prNumber, &additions, &deletions)
+	if err != nil {
+		return commit, fmt.Errorf("failed to read commit %s: %w", sha, err)
+	}
+
+	commit.Author = author.String
+	commit.AuthorEmail = authorEmail.String
+	commit.Committer = committer.String
+	commit.Message = message.String
+	commit.Branch = branch.String
+	commit.Repository = repository.String
+	commit.Additions = int(additions.Int64)
+	commit.Deletions = int(deletions.Int64)
+	if prNumber.Valid {
+		pr := int(prNumber.Int64)
+		commit.PRNumber = &pr
+	}
+	if commit.Timestamp, err = parseTime(timestamp); err != nil {
+		return commit, err
+	}
+
+	rows, err := s.db.Query(`SELECT path FROM commit_files WHERE sha = ? ORDER BY path`, sha)
+	if err != nil {
+		return commit, fmt.Errorf("failed to read files of commit %s: %w", sha, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return commit, fmt.Errorf("failed to read files of commit %s: %w", sha, err)
+		}
+		commit.Files = append(commit.Files, path)
+	}
+	return commit, rows.Err()
+}
+
+// writeEvent upserts the event by its source ID, or by its fingerprint when it
+// has none, and returns the event's row ID.
+func writeEvent(tx *sql.Tx, event types.SnapEvent) (int64, error) {
+	metadata, err := json.Marshal(event.Metadata)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode metadata of event %s: %w", event.Key(), err)
+	}
+
+	var sourceID, fingerprint interface{}
+	conflict := "source_id"
+	if event.ID != "" {
+		sourceID = event.ID
+	} else {
+		if fingerprint, err = eventFingerprint(event); err != nil {
+			return 0, err
+		}
+		conflict = "fingerprint"
+	}
+
+	var eventID int64
+	err = tx.QueryRow(`INSERT INTO events (source_id, fingerprint, timestamp, metadata) VALUES (?, ?, ?, ?)
+		ON CONFLICT (`+conflict+`) DO UPDATE SET timestamp = excluded.timestamp, metadata = excluded.metadata
+		RETURNING id`,
+		sourceID, fingerprint, formatTime(event.Timestamp), string(metadata)).Scan(&eventID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record event %s: %w", event.Key(), err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM event_attributes WHERE event_id = ?`, eventID); err != nil {
+		return 0, fmt.Errorf("failed to record event %s: %w", event.Key(), err)
+	}
+	for key, value := range event.Attributes {
+		if _, err := tx.Exec(`INSERT INTO event_attributes (event_id, key, value) VALUES (?, ?, ?)`,
+			eventID, key, attributeValue(value)); err != nil {
+			return 0, fmt.Errorf("failed to record attributes of event %s: %w", event.Key(), err)
+		}
+	}
+
+	return eventID, nil
+}
+
+func writeCommit(tx *sql.Tx, commit types.EnrichedCommit) error {
+	var prNumber interface{}
+	if commit.PRNumber != nil {
+		prNumber = *commit.PRNumber
+	}
+
+	if _, err := tx.Exec(`INSERT INTO commits
+		(sha, author, author_email, committer, timestamp, message, branch, repository, pr_number, additions, deletions)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (sha) DO UPDATE SET
+			author = excluded.author, author_email = excluded.author_email, committer = excluded.committer,
+			timestamp = excluded.timestamp, message = excluded.message, branch = excluded.branch,
+			repository = excluded.repository, pr_number = excluded.pr_number,
+			additions = excluded.additions, deletions = excluded.deletions`,
+		commit.SHA, commit.Author, commit.AuthorEmail, commit.Committer, formatTime(commit.Timestamp),
+		commit.Message, commit.Branch, commit.Repository, prNumber, commit.Additions, commit.Deletions); err != nil {
+		return fmt.Errorf("failed to record commit %s: %w", commit.SHA, err)
+	}
+
+	for _, path := range commit.Files {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO commit_files (sha, path) VALUES (?, ?)`, commit.SHA, path); err != nil {
+			return fmt.Errorf("failed to record files of commit %s: %w", commit.SHA, err)
+		}
+	}
+
+	return nil
+}
+
+// attributeValue stores strings as is and other values as JSON, so numbers
+// and booleans remain comparable in SQL.
+func attributeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return v
+	case bool, int, int64, float64:
+		return v
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(encoded)
+	}
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func parseTime(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q in store: %w", value, err)
+	}
+	return t, nil
+}
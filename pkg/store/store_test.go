@@ -0,0 +1,208 @@
+package store
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fraser-isbester/git-snap/pkg/types"
+)
+
+func TestWriteRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.db")
+	now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	prNumber := 42
+
+	commit := types.EnrichedCommit{
+		SHA:         "abc123",
+		Author:      "John Doe",
+		AuthorEmail: "john.doe@example.com",
+		Timestamp:   now.Add(5 * time.Minute),
+		Message:     "Add feature",
+		Files:       []string{"main.go", "README.md"},
+		Repository:  "git-snap",
+		PRNumber:    &prNumber,
+	}
+	results := []types.CorrelationResult{
+		{
+			Event: types.SnapEvent{
+				ID:         "evt-1",
+				Timestamp:  now,
+				Attributes: map[string]interface{}{"user_id": "john.doe", "tokens": 1200},
+			},
+			Commit:    commit,
+			Score:     0.9,
+			Matches:   map[string]bool{"user_id->author_email": true},
+			TimeDelta: 5 * time.Minute,
+		},
+		{
+			Event: types.SnapEvent{
+				Timestamp:  now.Add(time.Minute),
+				Attributes: map[string]interface{}{"user_id": "john.doe"},
+			},
+			Commit:    commit,
+			Score:     0.6,
+			TimeDelta: 4 * time.Minute,
+		},
+		{
+			// Same timestamp as the event above but a different event.
+			Event: types.SnapEvent{
+				Timestamp:  now.Add(time.Minute),
+				Attributes: map[string]interface{}{"user_id": "jane.smith"},
+			},
+			Commit:    commit,
+			Score:     0.55,
+			TimeDelta: 4 * time.Minute,
+		},
+	}
+
+	for run := 1; run <= 2; run++ {
+		s, err := Open(path)
+		if err != nil {
+			t.Fatalf("Failed to open store: %v", err)
+		}
+		runID, err := s.WriteRun(Run{StartedAt: now, Config: "default", Repository: "/repo", Threshold: 0.5}, results)
+		if err != nil {
+			t.Fatalf("Failed to write run: %v", err)
+		}
+		if runID != int64(run) {
+			t.Errorf("Expected run %d, got %d", run, runID)
+		}
+		s.Close()
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	counts := map[string]int{
+		"runs":                2,
+		"events":              3,
+		"event_attributes":    4,
+		"commits":             1,
+		"commit_files":        2,
+		"correlations":        6,
+		"correlation_matches": 2,
+	}
+	for table, expected := range counts {
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil {
+			t.Fatalf("Failed to count %s: %v", table, err)
+		}
+		if count != expected {
+			t.Errorf("Expected %d rows in %s, got %d", expected, table, count)
+		}
+	}
+
+	var eventID string
+	var score float64
+	err = db.QueryRow(`SELECT e.source_id, c.score FROM correlations c
+		JOIN events e ON e.id = c.event_id
+		JOIN event_attributes a ON a.event_id = c.event_id
+		WHERE a.key = 'tokens' AND a.value = 1200 AND c.run_id = 2`).Scan(&eventID, &score)
+	if err != nil {
+		t.Fatalf("Failed to query correlations: %v", err)
+	}
+	if eventID != "evt-1" || score != 0.9 {
+		t.Errorf("Unexpected correlation: %s %.2f", eventID, score)
+	}
+
+	var pr int
+	if err := db.QueryRow(`SELECT pr_number FROM commits WHERE sha = 'abc123'`).Scan(&pr); err != nil || pr != 42 {
+		t.Errorf("Expected PR 42, got %d (%v)", pr, err)
+	}
+}
+
+func TestReadRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.db")
+	now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	prNumber := 42
+
+	commit := types.EnrichedCommit{
+		SHA:         "abc123",
+		Author:      "John Doe",
+		AuthorEmail: "john.doe@example.com",
+		Timestamp:   now.Add(5 * time.Minute),
+		Files:       []string{"main.go", "README.md"},
+		PRNumber:    &prNumber,
+	}
+	results := []types.CorrelationResult{
+		{
+			Event: types.SnapEvent{
+				ID:         "evt-1",
+				Timestamp:  now,
+				Attributes: map[string]interface{}{"user_id": "john.doe", "tokens": 1200},
+				Metadata:   map[string]interface{}{"source_file": "events.jsonl"},
+			},
+			Commit:          commit,
+			Score:           0.9,
+			Matches:         map[string]bool{"user_id": true, "repository": false},
+			TimeDelta:       5 * time.Minute,
+			AttributedLines: 3,
+		},
+		{
+			Event:     types.SnapEvent{Timestamp: now.Add(time.Minute)},
+			Commit:    commit,
+			Score:     0.6,
+			TimeDelta: 4 * time.Minute,
+		},
+	}
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	defer s.Close()
+
+	if _, _, err := s.ReadRun(0); err == nil {
+		t.Error("Expected an error for a store without runs")
+	}
+	if _, err := s.WriteRun(Run{StartedAt: now, Config: "first", Events: 7}, results[:1]); err != nil {
+		t.Fatalf("Failed to write run: %v", err)
+	}
+	if _, err := s.WriteRun(Run{StartedAt: now, Config: "second", Threshold: 0.5, Events: 9}, results); err != nil {
+		t.Fatalf("Failed to write run: %v", err)
+	}
+
+	run, read, err := s.ReadRun(0)
+	if err != nil {
+		t.Fatalf("Failed to read latest run: %v", err)
+	}
+	if run.ID != 2 || run.Config != "second" || run.Events != 9 || run.Threshold != 0.5 || !run.StartedAt.Equal(now) {
+		t.Errorf("Unexpected run: %+v", run)
+	}
+	if len(read) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(read))
+	}
+
+	first := read[0]
+	if first.Event.ID != "evt-1" || !first.Event.Timestamp.Equal(now) || first.Score != 0.9 || first.TimeDelta != 5*time.Minute || first.AttributedLines != 3 {
+		t.Errorf("Unexpected first result: %+v", first)
+	}
+	if first.Event.Attributes["user_id"] != "john.doe" || first.Event.Attributes["tokens"] != "1200" {
+		t.Errorf("Unexpected attributes: %v", first.Event.Attributes)
+	}
+	if first.Event.Metadata["source_file"] != "events.jsonl" {
+		t.Errorf("Unexpected metadata: %v", first.Event.Metadata)
+	}
+	if !first.Matches["user_id"] || first.Matches["repository"] || len(first.Matches) != 2 {
+		t.Errorf("Unexpected matches: %v", first.Matches)
+	}
+	if first.Commit.AuthorEmail != "john.doe@example.com" || first.Commit.PRNumber == nil || *first.Commit.PRNumber != 42 || len(first.Commit.Files) != 2 {
+		t.Errorf("Unexpected commit: %+v", first.Commit)
+	}
+	if read[1].Event.ID != "" || !read[1].Event.Timestamp.Equal(now.Add(time.Minute)) {
+		t.Errorf("Expected the event without ID, got %+v", read[1].Event)
+	}
+
+	run, read, err = s.ReadRun(1)
+	if err != nil || run.Config != "first" || len(read) != 1 {
+		t.Errorf("Expected run 1 with one result, got %+v, %d results (%v)", run, len(read), err)
+	}
+	if _, _, err := s.ReadRun(3); err == nil {
+		t.Error("Expected an error for a missing run")
+	}
+}
@@ -9,26 +9,95 @@ type SnapEvent struct {
 	Metadata   map[string]interface{} `json:"metadata"`
 }
 
+// Key identifies an event in output that references events rather than
+// embedding them: its ID, or its UTC timestamp when it has none.
+func (e SnapEvent) Key() string {
+	if e.ID != "" {
+		return e.ID
+	}
+	return "@" + e.Timestamp.UTC().Format(time.RFC3339Nano)
+}
+
 type EnrichedCommit struct {
-	SHA         string    `json:"sha"`
-	Author      string    `json:"author"`
-	AuthorEmail string    `json:"author_email"`
-	Committer   string    `json:"committer"`
-	Timestamp   time.Time `json:"timestamp"`
-	Message     string    `json:"message"`
-	Files       []string  `json:"files"`
-	Branch      string    `json:"branch"`
-	Repository  string    `json:"repository"`
-	PRNumber    *int      `json:"pr_number,omitempty"`
-	Additions   int       `json:"additions"`
-	Deletions   int       `json:"deletions"`
-	Parents     []string  `json:"parents"`
+	SHA         string     `json:"sha"`
+	Author      string     `json:"author"`
+	AuthorEmail string     `json:"author_email"`
+	Committer   string     `json:"committer"`
+	Timestamp   time.Time  `json:"timestamp"`
+	Message     string     `json:"message"`
+	Files       []string   `json:"files"`
+	Branch      string     `json:"branch"`
+	Repository  string     `json:"repository"`
+	PRNumber    *int       `json:"pr_number,omitempty"`
+	Additions   int        `json:"additions"`
+	Deletions   int        `json:"deletions"`
+	Parents     []string   `json:"parents"`
+	Hunks       []DiffHunk `json:"hunks,omitempty"`
+}
+
+type DiffHunk struct {
+	File       string   `json:"file"`
+	OldStart   int      `json:"old_start"`
+	OldLines   int      `json:"old_lines"`
+	NewStart   int      `json:"new_start"`
+	NewLines   int      `json:"new_lines"`
+	AddedLines []string `json:"added_lines"`
+}
+
+type HunkMatch struct {
+	File      string `json:"file"`
+	NewStart  int    `json:"new_start"`
+	NewLines  int    `json:"new_lines"`
+	MatchedBy string `json:"matched_by"`
 }
 
 type SnapConfig struct {
 	TimeWindow     time.Duration      `yaml:"time_window"`
 	AttributeRules []AttributeRule    `yaml:"attribute_rules"`
 	ScoreWeights   map[string]float64 `yaml:"score_weights"`
+	HunkMatching   HunkMatchConfig    `yaml:"hunk_matching"`
+	FieldMapping   *FieldMapping      `yaml:"field_mapping,omitempty"`
+	Schema         *EventSchema       `yaml:"schema,omitempty"`
+}
+
+type HunkMatchConfig struct {
+	FileKey    string `yaml:"file_key"`
+	SnippetKey string `yaml:"snippet_key"`
+	HashKey    string `yaml:"hash_key"`
+}
+
+type FieldMapping struct {
+	ID          string                 `yaml:"id"`
+	Timestamp   TimestampMapping       `yaml:"timestamp"`
+	Attributes  []string               `yaml:"attributes"`
+	Metadata    []string               `yaml:"metadata"`
+	Rename      map[string]string      `yaml:"rename"`
+	Constants   map[string]interface{} `yaml:"constants"`
+	GenerateIDs bool                   `yaml:"generate_ids"`
+	Pattern     string                 `yaml:"pattern"`
+}
+
+type EventSchema struct {
+	RequireID  bool                   `yaml:"require_id"`
+	Strict     bool                   `yaml:"strict"`
+	Attributes map[string]FieldSchema `yaml:"attributes"`
+	Metadata   map[string]FieldSchema `yaml:"metadata"`
+}
+
+type FieldSchema struct {
+	Type     string        `yaml:"type"`
+	Required bool          `yaml:"required"`
+	Enum     []interface{} `yaml:"enum"`
+	Pattern  string        `yaml:"pattern"`
+	Min      *float64      `yaml:"min"`
+	Max      *float64      `yaml:"max"`
+}
+
+type TimestampMapping struct {
+	Field    string   `yaml:"field"`
+	Format   string   `yaml:"format"`
+	Formats  []string `yaml:"formats"`
+	Timezone string   `yaml:"timezone"`
 }
 
 type AttributeRule struct {
@@ -72,25 +141,42 @@ func (m *MatchType) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		return err
 	}
 
+	*m = ParseMatchType(s)
+	return nil
+}
+
+func ParseMatchType(s string) MatchType {
 	switch s {
 	case "exact":
-		*m = EXACT
+		return EXACT
 	case "contains":
-		*m = CONTAINS
+		return CONTAINS
 	case "regex":
-		*m = REGEX
+		return REGEX
 	case "fuzzy":
-		*m = FUZZY
+		return FUZZY
 	default:
-		*m = EXACT
+		return EXACT
 	}
-	return nil
+}
+
+// UnmatchedEvent is an event that produced no correlation at or above the
+// threshold. Candidates counts the commits within the time window.
+type UnmatchedEvent struct {
+	Event       SnapEvent `json:"event"`
+	Reason      string    `json:"reason"`
+	Candidates  int       `json:"candidates"`
+	FailedRules []string  `json:"failed_rules,omitempty"`
+	BestScore   float64   `json:"best_score,omitempty"`
 }
 
 type CorrelationResult struct {
-	Event     SnapEvent       `json:"event"`
-	Commit    EnrichedCommit  `json:"commit"`
-	Score     float64         `json:"score"`
-	Matches   map[string]bool `json:"matches"`
-	TimeDelta time.Duration   `json:"time_delta"`
+	Event           SnapEvent       `json:"event"`
+	Commit          EnrichedCommit  `json:"commit"`
+	Score           float64         `json:"score"`
+	Matches         map[string]bool `json:"matches"`
+	TimeDelta       time.Duration   `json:"time_delta"`
+	HunkMatches     []HunkMatch     `json:"hunk_matches,omitempty"`
+	AttributedLines int             `json:"attributed_lines,omitempty"`
+	LineAttribution float64         `json:"line_attribution,omitempty"`
 }
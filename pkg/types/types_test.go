@@ -53,6 +53,17 @@ func TestSnapEventCreation(t *testing.T) {
 	}
 }
 
+func TestSnapEventKey(t *testing.T) {
+	timestamp := time.Date(2024, 1, 15, 10, 30, 0, 0, time.FixedZone("CET", 3600))
+
+	if key := (SnapEvent{ID: "evt-1", Timestamp: timestamp}).Key(); key != "evt-1" {
+		t.Errorf("Expected the event ID, got %s", key)
+	}
+	if key := (SnapEvent{Timestamp: timestamp}).Key(); key != "@2024-01-15T09:30:00Z" {
+		t.Errorf("Expected a timestamp key, got %s", key)
+	}
+}
+
 func TestEnrichedCommitCreation(t *testing.T) {
 	timestamp := time.Now()
 	prNumber := 123